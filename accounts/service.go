@@ -23,7 +23,14 @@ import (
 	"go.uber.org/ratelimit"
 )
 
-const maxGasLimit = 9999
+// gasLimit returns limit, or configs.DefaultGasLimit if no limit is
+// configured.
+func gasLimit(limit uint64) uint64 {
+	if limit == 0 {
+		return configs.DefaultGasLimit
+	}
+	return limit
+}
 
 type Service interface {
 	List(limit, offset int) (result []Account, err error)
@@ -70,8 +77,8 @@ func NewService(
 	}
 
 	// Register asynchronous job executors
-	wp.RegisterExecutor(AccountCreateJobType, svc.executeAccountCreateJob)
-	wp.RegisterExecutor(SyncAccountKeyCountJobType, svc.executeSyncAccountKeyCountJob)
+	wp.RegisterExecutor(AccountCreateJobType, svc.executeAccountCreateJob, jobs.WithExecutorTimeout(transactions.TransactionJobTimeout), jobs.WithStatusNotifications())
+	wp.RegisterExecutor(SyncAccountKeyCountJobType, svc.executeSyncAccountKeyCountJob, jobs.WithExecutorTimeout(transactions.TransactionJobTimeout), jobs.WithStatusNotifications())
 
 	return svc
 }
@@ -90,7 +97,7 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Accoun
 	log.WithFields(log.Fields{"sync": sync}).Trace("Create account")
 
 	if !sync {
-		job, err := s.wp.CreateJob(AccountCreateJobType, "")
+		job, err := s.wp.CreateJob(AccountCreateJobType, "", jobs.WithContextCallbackURL(ctx))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -289,7 +296,7 @@ func (s *ServiceImpl) syncAccountKeyCount(ctx context.Context, address flow.Addr
 		entry.WithFields(log.Fields{"args": args}).Debug("args prepared")
 
 		// NOTE: sync, so will wait for transaction to be sent & sealed
-		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, code, args, transactions.General)
+		_, tx, err := s.txs.Create(transactions.WithoutCodePolicy(ctx), true, dbAccount.Address, nil, code, args, transactions.General)
 		if err != nil {
 			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
 			return 0, tx.TransactionId, err
@@ -369,7 +376,7 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit)
+		SetGasLimit(gasLimit(s.cfg.AccountCreationGasLimit))
 
 	// Check if we want to use a custom account create script
 	if s.cfg.ScriptPathCreateAccount != "" {
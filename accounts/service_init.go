@@ -87,7 +87,7 @@ func (s *ServiceImpl) addAdminProposalKeys(ctx context.Context, count uint16) er
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(payer.Address, payer.Key.Index, payer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(gasLimit(s.cfg.TransactionGasLimit)).
 		SetScript([]byte(code))
 
 	if err := flowTx.AddArgument(cadence.NewInt(s.cfg.AdminKeyIndex)); err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
 	"github.com/flow-hydraulics/flow-wallet-api/templates/template_strings"
@@ -44,7 +45,7 @@ func AddContract(
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(configs.DefaultGasLimit).
 		SetScript([]byte(template_strings.AddAccountContractWithAdmin)).
 		AddAuthorizer(payer.Address)
 
@@ -25,6 +25,7 @@ type Listener interface {
 type ListenerImpl struct {
 	ticker         *time.Ticker
 	stopChan       chan struct{}
+	done           chan struct{}
 	fc             flow_helpers.FlowClient
 	db             Store
 	getTypes       GetEventTypes
@@ -57,6 +58,7 @@ func NewListener(
 	listener := &ListenerImpl{
 		ticker:         nil,
 		stopChan:       make(chan struct{}),
+		done:           make(chan struct{}),
 		fc:             fc,
 		db:             db,
 		getTypes:       getTypes,
@@ -122,6 +124,9 @@ func (l *ListenerImpl) Start() Listener {
 	l.ticker = time.NewTicker(l.interval)
 
 	go func() {
+		defer close(l.done)
+
+		// Not cancelled on Stop, the current height range is finished
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
@@ -135,6 +140,13 @@ func (l *ListenerImpl) Start() Listener {
 			case <-l.stopChan:
 				return
 			case <-l.ticker.C:
+				// Stop may have been called while waiting for the tick
+				select {
+				case <-l.stopChan:
+					return
+				default:
+				}
+
 				// Check for maintenance mode
 				if halted, err := l.systemHalted(); err != nil {
 					entry.
@@ -219,6 +231,8 @@ func (l *ListenerImpl) initHeight() error {
 	})
 }
 
+// Stop stops the listener. If the listener is handling a height range, Stop
+// waits for it to finish so that the range is not handled again on restart.
 func (l *ListenerImpl) Stop() {
 	log.Debug("Stopping Flow event listener")
 
@@ -226,6 +240,7 @@ func (l *ListenerImpl) Stop() {
 
 	if l.ticker != nil {
 		l.ticker.Stop()
+		<-l.done
 	}
 }
 
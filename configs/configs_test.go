@@ -36,3 +36,21 @@ func TestParseConfig(t *testing.T) {
 		)
 	}
 }
+
+func TestParseConfigGasLimits(t *testing.T) {
+	t.Setenv("FLOW_WALLET_ADMIN_ADDRESS", "admin-address")
+	t.Setenv("FLOW_WALLET_ADMIN_PRIVATE_KEY", "admin-private-key")
+	t.Setenv("FLOW_WALLET_ENCRYPTION_KEY", "encryption-key")
+	t.Setenv("FLOW_WALLET_ACCESS_API_HOST", "access-api-host")
+	t.Setenv("FLOW_WALLET_MAX_TRANSACTION_GAS_LIMIT", "1000")
+	t.Setenv("FLOW_WALLET_TRANSACTION_GAS_LIMIT", "1000")
+
+	if _, err := Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FLOW_WALLET_TRANSACTION_GAS_LIMIT", "1001")
+	if _, err := Parse(); err == nil {
+		t.Error("expected an error for a transaction gas limit above the maximum")
+	}
+}
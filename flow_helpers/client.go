@@ -0,0 +1,55 @@
+package flow_helpers
+
+import (
+	"context"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	"github.com/onflow/flow/protobuf/go/flow/access"
+	"google.golang.org/grpc"
+)
+
+// Client is a gRPC client for the Flow Access API. It extends the Flow Go SDK
+// client with data the SDK client leaves out of its responses.
+type Client struct {
+	*client.Client
+	rpcClient access.AccessAPIClient
+	conn      *grpc.ClientConn
+}
+
+// NewClient initializes a Flow client with the default gRPC provider.
+func NewClient(addr string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcClient := access.NewAccessAPIClient(conn)
+
+	return &Client{
+		Client:    client.NewFromRPCClient(rpcClient),
+		rpcClient: rpcClient,
+		conn:      conn,
+	}, nil
+}
+
+// Close closes the client connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetTransactionResultBlockID gets the ID of the block a transaction was
+// included in, or flow.EmptyID if the transaction has not been included
+// in a block yet.
+func (c *Client) GetTransactionResultBlockID(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (flow.Identifier, error) {
+	req := &access.GetTransactionRequest{
+		Id: txID.Bytes(),
+	}
+
+	res, err := c.rpcClient.GetTransactionResult(ctx, req, opts...)
+	if err != nil {
+		return flow.EmptyID, client.RPCError{GRPCErr: err}
+	}
+
+	return flow.BytesToID(res.GetBlockId()), nil
+}
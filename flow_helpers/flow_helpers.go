@@ -24,12 +24,34 @@ type FlowClient interface {
 	GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.Transaction, error)
 	GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error)
 	GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error)
+	GetBlockHeaderByID(ctx context.Context, blockID flow.Identifier, opts ...grpc.CallOption) (*flow.BlockHeader, error)
 	GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) ([]client.BlockEvents, error)
 	SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error
+	GetTransactionResultBlockID(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (flow.Identifier, error)
 }
 
 const hexPrefix = "0x"
 
+// TransactionExpiry is the number of blocks after its reference block a
+// transaction can be included in.
+const TransactionExpiry = 600
+
+// ReferenceBlockExpired tells whether transactions referencing the block with
+// the given id can no longer be included.
+func ReferenceBlockExpired(ctx context.Context, flowClient FlowClient, id flow.Identifier) (bool, error) {
+	ref, err := flowClient.GetBlockHeaderByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	latest, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return false, err
+	}
+
+	return latest.Height >= ref.Height+TransactionExpiry, nil
+}
+
 // LatestBlockId retuns the flow.Identifier for the latest block in the chain.
 func LatestBlockId(ctx context.Context, flowClient FlowClient) (*flow.Identifier, error) {
 	block, err := flowClient.GetLatestBlockHeader(ctx, true)
@@ -43,7 +65,7 @@ func LatestBlockId(ctx context.Context, flowClient FlowClient) (*flow.Identifier
 // - an error occurs while fetching the transaction result
 // - the transaction gets an error status
 // - the transaction gets a "TransactionStatusSealed" or "TransactionStatusExpired" status
-// - timeout is reached
+// - timeout is reached or ctx is done
 func WaitForSeal(ctx context.Context, flowClient FlowClient, id flow.Identifier, timeout time.Duration) (*flow.TransactionResult, error) {
 	var (
 		result *flow.TransactionResult
@@ -84,7 +106,11 @@ func WaitForSeal(ctx context.Context, flowClient FlowClient, id flow.Identifier,
 			return result, nil
 		}
 
-		time.Sleep(b.Duration())
+		select {
+		case <-time.After(b.Duration()):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
 }
 
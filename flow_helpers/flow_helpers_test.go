@@ -9,6 +9,7 @@ import (
 
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers/internal"
 	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
 )
 
 func TestAddressValidationAndFormatting(t *testing.T) {
@@ -56,3 +57,37 @@ func TestWaitForSeal(t *testing.T) {
 		}
 	})
 }
+
+// heightFlowClient has a reference block at height 100 and a latest block at
+// height `latest`.
+type heightFlowClient struct {
+	internal.MockFlowClient
+	latest uint64
+}
+
+func (c *heightFlowClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{Height: c.latest}, nil
+}
+
+func (c *heightFlowClient) GetBlockHeaderByID(ctx context.Context, blockID flow.Identifier, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{ID: blockID, Height: 100}, nil
+}
+
+func TestReferenceBlockExpired(t *testing.T) {
+	ctx := context.Background()
+
+	for latest, expected := range map[uint64]bool{
+		100:                         false,
+		100 + TransactionExpiry - 1: false,
+		100 + TransactionExpiry:     true,
+	} {
+		expired, err := ReferenceBlockExpired(ctx, &heightFlowClient{latest: latest}, flow.EmptyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expired != expected {
+			t.Errorf("expected expired = %t at height %d, got %t", expected, latest, expired)
+		}
+	}
+}
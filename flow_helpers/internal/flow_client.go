@@ -42,6 +42,10 @@ func (c *MockFlowClient) GetLatestBlockHeader(ctx context.Context, isSealed bool
 	return nil, nil
 }
 
+func (c *MockFlowClient) GetBlockHeaderByID(ctx context.Context, blockID flow.Identifier, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return nil, nil
+}
+
 func (c *MockFlowClient) GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) ([]client.BlockEvents, error) {
 	return nil, nil
 }
@@ -49,3 +53,7 @@ func (c *MockFlowClient) GetEventsForHeightRange(ctx context.Context, query clie
 func (c *MockFlowClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
 	return nil
 }
+
+func (c *MockFlowClient) GetTransactionResultBlockID(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (flow.Identifier, error) {
+	return flow.EmptyID, nil
+}
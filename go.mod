@@ -19,6 +19,7 @@ require (
 	github.com/lib/pq v1.10.4
 	github.com/onflow/cadence v0.20.1
 	github.com/onflow/flow-go-sdk v0.24.0
+	github.com/onflow/flow/protobuf/go/flow v0.2.3
 	github.com/sirupsen/logrus v1.8.1
 	go.uber.org/goleak v1.1.12
 	go.uber.org/ratelimit v0.2.0
@@ -75,7 +76,6 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.10 // indirect
 	github.com/onflow/atree v0.1.0-beta1.0.20211027184039-559ee654ece9 // indirect
 	github.com/onflow/flow-go/crypto v0.21.3 // indirect
-	github.com/onflow/flow/protobuf/go/flow v0.2.3 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
@@ -29,7 +29,8 @@ func (s *Accounts) List() http.Handler {
 }
 
 func (s *Accounts) Create() http.Handler {
-	return http.HandlerFunc(s.CreateFunc)
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseCallbackURL(h)
 }
 
 func (s *Accounts) AddNonCustodialAccount() http.Handler {
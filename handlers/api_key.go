@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+)
+
+// API key Handler middleware
+// ===========================================================================
+
+// APIKeyHeader identifies the client of a request for the code allowlist.
+// The wallet does not authenticate clients, the header is expected to be set
+// by a gateway in front of it.
+const APIKeyHeader = "X-Api-Key"
+
+// APIKeyHandler adds the API key from the APIKeyHeader header to the request
+// context. Code allowlist entries can be limited to an API key.
+func APIKeyHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if k := r.Header.Get(APIKeyHeader); k != "" {
+			r = r.WithContext(transactions.ContextWithAPIKey(r.Context(), k))
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/gorilla/mux"
+)
+
+// Backpressure Handler middleware
+// ===========================================================================
+
+// BackpressureRoutes is a set of routes of asynchronous endpoints which are
+// rejected while the worker pool reports backpressure.
+type BackpressureRoutes map[*mux.Route]bool
+
+// Add adds route to the set and returns it.
+func (br BackpressureRoutes) Add(route *mux.Route) *mux.Route {
+	br[route] = true
+	return route
+}
+
+// BackpressureHandler rejects asynchronous requests (without the `sync` query
+// parameter) matching one of routes of router with 503 Service Unavailable
+// and a Retry-After header while wp reports backpressure.
+//
+// It is meant to wrap the idempotency middleware, so that the Idempotency-Key
+// of a rejected request is not used up and the request can be retried as is.
+func BackpressureHandler(h http.Handler, wp jobs.WorkerPool, router *mux.Router, routes BackpressureRoutes) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// The query is used instead of r.FormValue to leave the body untouched
+		if len(routes) == 0 || r.URL.Query().Get(SyncQueryParameter) != "" {
+			h.ServeHTTP(rw, r)
+			return
+		}
+
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || !routes[match.Route] {
+			h.ServeHTTP(rw, r)
+			return
+		}
+
+		if err := wp.CheckBackpressure(); err != nil {
+			handleError(rw, r, err)
+			return
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// handleBackpressureError responds with 503 Service Unavailable, a
+// Retry-After header and a JSON body describing the error.
+func handleBackpressureError(rw http.ResponseWriter, err *jobs.BackpressureError) {
+	rw.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	handleJsonResponse(rw, http.StatusServiceUnavailable, err.ToJSONResponse())
+}
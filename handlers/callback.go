@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+)
+
+// Callback URL Handler middleware
+// ===========================================================================
+
+// CallbackURLHeader can be used to give a per-request callback url for async
+// requests. Alternatively it can be given as "callbackUrl" in a JSON body.
+const CallbackURLHeader = "X-Callback-Url"
+
+var InvalidCallbackURLError = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("invalid callbackUrl")}
+
+// CallbackURLHandler reads a callback url from the CallbackURLHeader header or
+// the "callbackUrl" field of a JSON body and adds it to the request context.
+// Jobs created while handling the request deliver their final status to it.
+func CallbackURLHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		u := r.Header.Get(CallbackURLHeader)
+
+		if u == "" && r.Body != nil && r.Body != http.NoBody {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				handleError(rw, r, InvalidBodyError)
+				return
+			}
+
+			// Restore the body for the next handler
+			r.Body = io.NopCloser(bytes.NewReader(b))
+
+			var body struct {
+				CallbackURL string `json:"callbackUrl"`
+			}
+
+			// Other decoding errors are left for the next handler
+			err = json.Unmarshal(b, &body)
+			if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field == "callbackUrl" {
+				handleError(rw, r, InvalidCallbackURLError)
+				return
+			}
+
+			u = body.CallbackURL
+		}
+
+		if u != "" {
+			if err := jobs.ValidateCallbackURL(u); err != nil {
+				handleError(rw, r, InvalidCallbackURLError)
+				return
+			}
+
+			r = r.WithContext(jobs.ContextWithCallbackURL(r.Context(), u))
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+)
+
+// CodeTemplates is a HTTP server for named transaction and script templates.
+// It provides list, add and details API, and sends transactions and executes
+// scripts using the templates.
+type CodeTemplates struct {
+	templates    templates.Service
+	transactions transactions.Service
+}
+
+// NewCodeTemplates initiates a new code templates server.
+func NewCodeTemplates(templates templates.Service, transactions transactions.Service) *CodeTemplates {
+	return &CodeTemplates{templates, transactions}
+}
+
+func (s *CodeTemplates) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *CodeTemplates) Add() http.Handler {
+	h := http.HandlerFunc(s.AddFunc)
+	return UseJson(h)
+}
+
+func (s *CodeTemplates) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *CodeTemplates) CreateTransaction() http.Handler {
+	h := http.HandlerFunc(s.CreateTransactionFunc)
+	return UseJson(UseAPIKey(UseCallbackURL(h)))
+}
+
+func (s *CodeTemplates) ExecuteScript() http.Handler {
+	h := http.HandlerFunc(s.ExecuteScriptFunc)
+	return UseJson(h)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/gorilla/mux"
+)
+
+// List returns all versions of all code templates.
+func (s *CodeTemplates) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	tt, err := s.templates.ListCodeTemplates()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, tt)
+}
+
+// Add registers a new version of a code template.
+func (s *CodeTemplates) AddFunc(rw http.ResponseWriter, r *http.Request) {
+	var req templates.CodeTemplateJSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	t, err := s.templates.AddCodeTemplate(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, t)
+}
+
+// Details returns the latest version of a code template, or the version
+// given in the `version` query parameter.
+func (s *CodeTemplates) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	version := 0
+
+	if v := r.FormValue("version"); v != "" {
+		var err error
+		version, err = strconv.Atoi(v)
+		if err != nil || version < 1 {
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid version"),
+			}
+			handleError(rw, r, err)
+			return
+		}
+	}
+
+	vars := mux.Vars(r)
+
+	t, err := s.templates.GetCodeTemplate(vars["templateName"], version)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, t)
+}
+
+// CreateTransaction sends a transaction template for an account.
+func (s *CodeTemplates) CreateTransactionFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	t, args, err := s.call(r, templates.TransactionTemplate)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decide whether to serve sync or async, default async
+	sync := r.FormValue(SyncQueryParameter) != ""
+	job, transaction, err := s.transactions.Create(r.Context(), sync, vars["address"], args.Authorizers, t.Code, args.arguments, transactions.General)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var res interface{}
+	if sync {
+		res = transaction.ToJSONResponse()
+	} else {
+		res = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// ExecuteScript executes a script template.
+func (s *CodeTemplates) ExecuteScriptFunc(rw http.ResponseWriter, r *http.Request) {
+	t, args, err := s.call(r, templates.ScriptTemplate)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res, err := s.transactions.ExecuteScript(r.Context(), t.Code, args.arguments)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+type codeTemplateCall struct {
+	templates.CodeTemplateCallJSONRequest
+	arguments []transactions.Argument
+}
+
+// call decodes a call of the code template named in the request path, which
+// must be of the given kind.
+func (s *CodeTemplates) call(r *http.Request, kind templates.CodeTemplateKind) (*templates.CodeTemplate, *codeTemplateCall, error) {
+	if err := checkNonEmptyBody(r); err != nil {
+		return nil, nil, err
+	}
+
+	var call codeTemplateCall
+
+	if err := json.NewDecoder(r.Body).Decode(&call.CodeTemplateCallJSONRequest); err != nil {
+		return nil, nil, InvalidBodyError
+	}
+
+	vars := mux.Vars(r)
+
+	t, err := s.templates.GetCodeTemplate(vars["templateName"], call.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if t.Kind != kind {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(`code template "%s" is a %s, expected a %s`, t.Name, t.Kind, kind),
+		}
+	}
+
+	values, err := t.CadenceArguments(call.Arguments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, v := range values {
+		call.arguments = append(call.arguments, v)
+	}
+
+	return t, &call, nil
+}
@@ -9,10 +9,12 @@ import (
 	"strings"
 
 	gorilla "github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/handlers/middleware"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 )
 
 const SyncQueryParameter = "sync"
@@ -41,13 +43,31 @@ func UseIdempotency(h http.Handler, opts IdempotencyHandlerOptions, store Idempo
 	return IdempotencyHandler(h, opts, store)
 }
 
+func UseCallbackURL(h http.Handler) http.Handler {
+	return CallbackURLHandler(h)
+}
+
+func UseAPIKey(h http.Handler) http.Handler {
+	return APIKeyHandler(h)
+}
+
+func UseBackpressure(h http.Handler, wp jobs.WorkerPool, router *mux.Router, routes BackpressureRoutes) http.Handler {
+	return BackpressureHandler(h, wp, router, routes)
+}
+
 // handleError is a helper function for unified HTTP error handling.
 func handleError(rw http.ResponseWriter, r *http.Request, err error) {
 	log.
 		WithFields(log.Fields{"error": err}).
 		Warn("Error while handling request")
 
-		// Check if the error was an errors.RequestError
+	// Check if the request was rejected due to job queue backpressure
+	if bpErr, ok := err.(*jobs.BackpressureError); ok {
+		handleBackpressureError(rw, bpErr)
+		return
+	}
+
+	// Check if the error was an errors.RequestError
 	reqErr, isReqErr := err.(*errors.RequestError)
 	if isReqErr {
 		http.Error(rw, reqErr.Error(), reqErr.StatusCode)
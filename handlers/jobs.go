@@ -7,7 +7,7 @@ import (
 )
 
 // Jobs is a HTTP server for jobs.
-// It provides details API.
+// It provides details, history, cancel and retry API.
 // It uses jobs service to interface with data.
 type Jobs struct {
 	service jobs.Service
@@ -25,3 +25,24 @@ func (s *Jobs) List() http.Handler {
 func (s *Jobs) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
+
+func (s *Jobs) Cancel() http.Handler {
+	return http.HandlerFunc(s.CancelFunc)
+}
+
+func (s *Jobs) Retry() http.Handler {
+	return http.HandlerFunc(s.RetryFunc)
+}
+
+func (s *Jobs) RetryFailed() http.Handler {
+	h := http.HandlerFunc(s.RetryFailedFunc)
+	return UseJson(h)
+}
+
+func (s *Jobs) History() http.Handler {
+	return http.HandlerFunc(s.HistoryFunc)
+}
+
+func (s *Jobs) Stream() http.Handler {
+	return http.HandlerFunc(s.StreamFunc)
+}
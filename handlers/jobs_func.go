@@ -1,14 +1,30 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 )
 
-// List returns all jobs.
+const (
+	NextCursorHeader     = "X-Next-Cursor"
+	attributeQueryPrefix = "attributes."
+
+	streamBatchSize         = 100
+	streamPollInterval      = time.Second // Covers transitions made by other instances
+	streamKeepAliveInterval = 15 * time.Second
+)
+
+// List returns jobs matching the filter in query parameters.
+// A cursor for the next page is returned in the NextCursorHeader header.
 func (s *Jobs) ListFunc(rw http.ResponseWriter, r *http.Request) {
 	limit, err := strconv.Atoi(r.FormValue("limit"))
 	if err != nil {
@@ -20,7 +36,13 @@ func (s *Jobs) ListFunc(rw http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	jobsSlice, err := s.service.List(limit, offset)
+	filter, err := parseJobFilter(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	jobsSlice, next, err := s.service.List(filter, limit, offset)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -32,16 +54,157 @@ func (s *Jobs) ListFunc(rw http.ResponseWriter, r *http.Request) {
 		res[i] = job.ToJSONResponse()
 	}
 
+	if next != "" {
+		rw.Header().Set(NextCursorHeader, next)
+	}
+
 	handleJsonResponse(rw, http.StatusOK, res)
 }
 
+// parseJobFilter parses a jobs.Filter from query parameters. State and type
+// can be given multiple times or as comma separated lists. Attribute values
+// are given as "attributes.<key path>=<value>".
+func parseJobFilter(r *http.Request) (jobs.Filter, error) {
+	q := r.URL.Query()
+
+	f := jobs.Filter{
+		TransactionID: q.Get("transactionId"),
+		Cursor:        q.Get("cursor"),
+	}
+
+	for _, s := range splitQueryValues(q["state"]) {
+		f.States = append(f.States, jobs.State(strings.ToUpper(s)))
+	}
+
+	f.Types = splitQueryValues(q["type"])
+
+	timeParams := map[string]**time.Time{
+		"createdAfter":  &f.CreatedAfter,
+		"createdBefore": &f.CreatedBefore,
+		"updatedAfter":  &f.UpdatedAfter,
+		"updatedBefore": &f.UpdatedBefore,
+	}
+
+	for name, dst := range timeParams {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid %s, expected RFC 3339 timestamp", name),
+			}
+		}
+		*dst = &t
+	}
+
+	for k, vv := range q {
+		if !strings.HasPrefix(k, attributeQueryPrefix) || len(vv) == 0 {
+			continue
+		}
+		path := strings.TrimPrefix(k, attributeQueryPrefix)
+		if path == "" {
+			continue
+		}
+		if f.Attributes == nil {
+			f.Attributes = make(map[string]string)
+		}
+		f.Attributes[path] = vv[0]
+	}
+
+	return f, nil
+}
+
+func splitQueryValues(vv []string) []string {
+	var res []string
+	for _, v := range vv {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
 // Details returns details regarding a job.
 // It reads the job id for the wanted job from URL.
+// If the "wait" query parameter is set, it waits up to the given duration
+// for the job to reach a terminal state before responding.
 // Job service is responsible for validating the job id.
 func (s *Jobs) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	job, err := s.service.Details(vars["jobId"])
+	var job *jobs.Job
+	var err error
+
+	if w := r.FormValue("wait"); w != "" {
+		wait, parseErr := time.ParseDuration(w)
+		if parseErr != nil || wait < 0 {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid wait, expected a duration such as 30s"),
+			})
+			return
+		}
+		job, err = s.service.Wait(r.Context(), vars["jobId"], wait)
+	} else {
+		job, err = s.service.Details(vars["jobId"])
+	}
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := job.ToJSONResponse()
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// History returns the state transition history of a job.
+// It reads the job id for the wanted job from URL.
+// Job service is responsible for validating the job id.
+func (s *Jobs) HistoryFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	events, err := s.service.History(vars["jobId"])
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, events)
+}
+
+// Cancel cancels a job that has not yet been successfully executed.
+// It reads the job id for the wanted job from URL.
+// Job service is responsible for validating the job id.
+func (s *Jobs) CancelFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.Cancel(vars["jobId"])
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := job.ToJSONResponse()
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Retry puts a FAILED job back into the workerpool.
+// It reads the job id for the wanted job from URL.
+// Job service is responsible for validating the job id.
+func (s *Jobs) RetryFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.Retry(vars["jobId"])
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -52,3 +215,149 @@ func (s *Jobs) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 
 	handleJsonResponse(rw, http.StatusOK, res)
 }
+
+// RetryFailed puts all FAILED jobs matching the filter in request body
+// back into the workerpool.
+func (s *Jobs) RetryFailedFunc(rw http.ResponseWriter, r *http.Request) {
+	var filter jobs.RetryFilter
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	jobsSlice, err := s.service.RetryFailed(filter)
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]jobs.JSONResponse, len(*jobsSlice))
+	for i, job := range *jobsSlice {
+		res[i] = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Stream pushes job state transitions as Server-Sent Events. Each event has
+// a resume token as its id; a reconnecting client can resume by sending it in
+// the "Last-Event-ID" header (or "lastEventId" query parameter). Transitions
+// can become visible out of id order, so a resumed stream may repeat some
+// transitions, which clients dedupe by the transition id in the event data.
+// Without a resume token only new transitions are sent. Events can be limited
+// to a single job with the "jobId" query parameter.
+func (s *Jobs) StreamFunc(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		handleError(rw, r, &errors.RequestError{
+			StatusCode: http.StatusInternalServerError,
+			Err:        fmt.Errorf("streaming not supported"),
+		})
+		return
+	}
+
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.FormValue("lastEventId")
+	}
+
+	var reader *jobs.EventReader
+	if resume != "" {
+		id, err := strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid last event id"),
+			})
+			return
+		}
+		// Transitions up to the resume token have been read
+		reader, err = jobs.NewEventReader(s.service, id, 0)
+		if err != nil {
+			handleError(rw, r, err)
+			return
+		}
+	} else {
+		id, err := s.service.LatestEventID()
+		if err != nil {
+			handleError(rw, r, err)
+			return
+		}
+		// Transitions committed late are still new
+		reader, err = jobs.NewEventReader(s.service, id, streamBatchSize)
+		if err != nil {
+			handleError(rw, r, err)
+			return
+		}
+	}
+
+	jobID := r.FormValue("jobId")
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(streamPollInterval)
+	defer poll.Stop()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		// Get the transition channel before reading events to not miss
+		// transitions in between
+		transition := s.service.Transitions()
+
+		events, more, err := reader.Next(streamBatchSize)
+		if err != nil {
+			log.
+				WithFields(log.Fields{"error": err}).
+				Warn("Error while streaming job events")
+			return
+		}
+
+		for _, e := range events {
+			if jobID != "" && e.JobID.String() != jobID {
+				continue
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+
+			if _, err := fmt.Fprintf(rw, "id: %d\nevent: job\ndata: %s\n\n", reader.ResumeID(e.ID), data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+
+		if more {
+			// More events pending
+			continue
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-transition:
+		case <-poll.C:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+)
+
+// Schedules is a HTTP server for recurring job schedules.
+// It provides list, create, details, update and delete API.
+// It uses schedule service to interface with data.
+type Schedules struct {
+	service jobs.ScheduleService
+}
+
+// NewSchedules initiates a new schedules server.
+func NewSchedules(service jobs.ScheduleService) *Schedules {
+	return &Schedules{service}
+}
+
+func (s *Schedules) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *Schedules) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *Schedules) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *Schedules) Update() http.Handler {
+	h := http.HandlerFunc(s.UpdateFunc)
+	return UseJson(h)
+}
+
+func (s *Schedules) Delete() http.Handler {
+	return http.HandlerFunc(s.DeleteFunc)
+}
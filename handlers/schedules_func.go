@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/gorilla/mux"
+)
+
+// List returns all job schedules.
+func (s *Schedules) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	schedules, err := s.service.List()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]jobs.JobScheduleJSONResponse, len(schedules))
+	for i, sch := range schedules {
+		res[i] = sch.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create adds a new job schedule.
+func (s *Schedules) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req jobs.JobScheduleJSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	sch, err := s.service.Create(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, sch.ToJSONResponse())
+}
+
+// Details returns a job schedule.
+func (s *Schedules) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sch, err := s.service.Details(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sch.ToJSONResponse())
+}
+
+// Update replaces a job schedule.
+func (s *Schedules) UpdateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req jobs.JobScheduleJSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	sch, err := s.service.Update(vars["id"], req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sch.ToJSONResponse())
+}
+
+// Delete removes a job schedule.
+func (s *Schedules) DeleteFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.Delete(vars["id"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
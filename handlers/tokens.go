@@ -17,7 +17,7 @@ func NewTokens(service tokens.Service) *Tokens {
 
 func (s *Tokens) Setup() http.Handler {
 	h := http.HandlerFunc(s.SetupFunc)
-	return h
+	return UseCallbackURL(h)
 }
 
 func (s *Tokens) AccountTokens(tType templates.TokenType) http.Handler {
@@ -31,7 +31,7 @@ func (s *Tokens) Details() http.Handler {
 
 func (s *Tokens) CreateWithdrawal() http.Handler {
 	h := http.HandlerFunc(s.CreateWithdrawalFunc)
-	return UseJson(h)
+	return UseJson(UseCallbackURL(h))
 }
 
 func (s *Tokens) ListWithdrawals() http.Handler {
@@ -21,12 +21,31 @@ func (s *Transactions) List() http.Handler {
 
 func (s *Transactions) Create() http.Handler {
 	h := http.HandlerFunc(s.CreateFunc)
-	return UseJson(h)
+	return UseJson(UseAPIKey(UseCallbackURL(h)))
 }
 
 func (s *Transactions) Sign() http.Handler {
 	h := http.HandlerFunc(s.SignFunc)
-	return UseJson(h)
+	return UseJson(UseAPIKey(h))
+}
+
+func (s *Transactions) Submit() http.Handler {
+	h := http.HandlerFunc(s.SubmitFunc)
+	return UseJson(UseAPIKey(UseCallbackURL(h)))
+}
+
+func (s *Transactions) CreateCoSigningSession() http.Handler {
+	h := http.HandlerFunc(s.CreateCoSigningSessionFunc)
+	return UseJson(UseAPIKey(UseCallbackURL(h)))
+}
+
+func (s *Transactions) CoSigningSessionDetails() http.Handler {
+	return http.HandlerFunc(s.CoSigningSessionDetailsFunc)
+}
+
+func (s *Transactions) AddCoSigningSignature() http.Handler {
+	h := http.HandlerFunc(s.AddCoSigningSignatureFunc)
+	return UseJson(UseCallbackURL(h))
 }
 
 func (s *Transactions) Details() http.Handler {
@@ -37,3 +56,20 @@ func (s *Transactions) ExecuteScript() http.Handler {
 	h := http.HandlerFunc(s.ExecuteScriptFunc)
 	return UseJson(h)
 }
+
+func (s *Transactions) AllowedCode() http.Handler {
+	return http.HandlerFunc(s.AllowedCodeFunc)
+}
+
+func (s *Transactions) AddAllowedCode() http.Handler {
+	h := http.HandlerFunc(s.AddAllowedCodeFunc)
+	return UseJson(h)
+}
+
+func (s *Transactions) RemoveAllowedCode() http.Handler {
+	return http.HandlerFunc(s.RemoveAllowedCodeFunc)
+}
+
+func (s *Transactions) CodeAuditLog() http.Handler {
+	return http.HandlerFunc(s.CodeAuditLogFunc)
+}
@@ -81,7 +81,7 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
-	job, transaction, err := s.service.Create(r.Context(), sync, vars["address"], txReq.Code, txReq.Arguments, transactions.General)
+	job, transaction, err := s.service.Create(r.Context(), sync, vars["address"], txReq.Authorizers, txReq.Code, txReq.Arguments, transactions.General, transactions.WithGasLimit(txReq.GasLimit))
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -98,6 +98,50 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusCreated, res)
 }
 
+func (s *Transactions) SubmitFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var submitReq transactions.SubmitJSONRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&submitReq)
+	if err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	flowTx, err := submitReq.ToFlowTransaction()
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid body: %w", err),
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decide whether to serve sync or async, default async
+	sync := r.FormValue(SyncQueryParameter) != ""
+	job, transaction, err := s.service.Submit(r.Context(), sync, flowTx)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var res interface{}
+	if sync {
+		res = transaction.ToJSONResponse()
+	} else {
+		res = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
 func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 	err := checkNonEmptyBody(r)
 	if err != nil {
@@ -120,7 +164,7 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := s.service.Sign(r.Context(), vars["address"], txReq.Code, txReq.Arguments)
+	tx, err := s.service.Sign(r.Context(), vars["address"], txReq.Authorizers, txReq.Code, txReq.Arguments, transactions.WithGasLimit(txReq.GasLimit))
 	if err != nil {
 		handleError(rw, r, err)
 		return
@@ -139,6 +183,151 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusCreated, resp)
 }
 
+func (s *Transactions) CreateCoSigningSessionFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req transactions.CoSigningSessionJSONRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	session, err := s.service.CreateCoSigningSession(r.Context(), req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleCoSigningSessionResponse(rw, r, http.StatusCreated, session)
+}
+
+func (s *Transactions) CoSigningSessionDetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	session, err := s.service.CoSigningSessionDetails(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleCoSigningSessionResponse(rw, r, http.StatusOK, session)
+}
+
+func (s *Transactions) AddCoSigningSignatureFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req transactions.TransactionSignatureJSON
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	session, err := s.service.AddCoSigningSignature(r.Context(), vars["id"], req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleCoSigningSessionResponse(rw, r, http.StatusOK, session)
+}
+
+func (s *Transactions) AllowedCodeFunc(rw http.ResponseWriter, r *http.Request) {
+	entries, err := s.service.AllowedCode()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, entries)
+}
+
+func (s *Transactions) AddAllowedCodeFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req transactions.AllowedCodeJSONRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	entry, err := s.service.AddAllowedCode(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, entry)
+}
+
+func (s *Transactions) RemoveAllowedCodeFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.RemoveAllowedCode(vars["id"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// CodeAuditLog returns requests rejected by the code allowlist, newest first.
+func (s *Transactions) CodeAuditLogFunc(rw http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	offset, err := strconv.Atoi(r.FormValue("offset"))
+	if err != nil {
+		offset = 0
+	}
+
+	entries, err := s.service.CodeAuditLog(limit, offset)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, entries)
+}
+
+func handleCoSigningSessionResponse(rw http.ResponseWriter, r *http.Request, status int, session *transactions.CoSigningSession) {
+	res, err := session.ToJSONResponse()
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusInternalServerError,
+			Err:        fmt.Errorf("cannot decode co-signing session transaction"),
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, status, res)
+}
+
 func (s *Transactions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	var (
 		transaction *transactions.Transaction
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+)
+
+// Webhooks is a HTTP server for job status webhook subscriptions.
+// It provides subscription management, delivery log and redelivery API.
+// It uses webhook service to interface with data.
+type Webhooks struct {
+	service jobs.WebhookService
+}
+
+// NewWebhooks initiates a new webhooks server.
+func NewWebhooks(service jobs.WebhookService) *Webhooks {
+	return &Webhooks{service}
+}
+
+func (s *Webhooks) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *Webhooks) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *Webhooks) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *Webhooks) Update() http.Handler {
+	h := http.HandlerFunc(s.UpdateFunc)
+	return UseJson(h)
+}
+
+func (s *Webhooks) Delete() http.Handler {
+	return http.HandlerFunc(s.DeleteFunc)
+}
+
+func (s *Webhooks) Deliveries() http.Handler {
+	return http.HandlerFunc(s.DeliveriesFunc)
+}
+
+func (s *Webhooks) Redeliver() http.Handler {
+	return http.HandlerFunc(s.RedeliverFunc)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// List returns all webhook subscriptions. Secrets are not included.
+func (s *Webhooks) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	subs, err := s.service.List()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]jobs.WebhookSubscriptionJSONResponse, len(subs))
+	for i, sub := range subs {
+		res[i] = sub.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create adds a new webhook subscription. The secret is only included in
+// this response.
+func (s *Webhooks) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req jobs.WebhookSubscriptionJSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	sub, err := s.service.Create(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := sub.ToJSONResponse()
+	res.Secret = sub.Secret
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// Details returns a webhook subscription. The secret is not included.
+func (s *Webhooks) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sub, err := s.service.Details(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sub.ToJSONResponse())
+}
+
+// Update replaces a webhook subscription.
+func (s *Webhooks) UpdateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req jobs.WebhookSubscriptionJSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	sub, err := s.service.Update(vars["id"], req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sub.ToJSONResponse())
+}
+
+// Delete removes a webhook subscription.
+func (s *Webhooks) DeleteFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.Delete(vars["id"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries returns webhook delivery attempts, newest first. Deliveries can
+// be filtered by "subscriptionId", "jobId" (the job whose status was
+// delivered) and "failed=true".
+func (s *Webhooks) DeliveriesFunc(rw http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	offset, err := strconv.Atoi(r.FormValue("offset"))
+	if err != nil {
+		offset = 0
+	}
+
+	var filter jobs.DeliveryFilter
+
+	if v := r.FormValue("subscriptionId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid subscriptionId"),
+			})
+			return
+		}
+		filter.SubscriptionID = &id
+	}
+
+	if v := r.FormValue("jobId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid jobId"),
+			})
+			return
+		}
+		filter.ParentJobID = &id
+	}
+
+	if v := r.FormValue("failed"); v != "" {
+		failed, err := strconv.ParseBool(v)
+		if err != nil {
+			handleError(rw, r, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid failed"),
+			})
+			return
+		}
+		filter.FailedOnly = failed
+	}
+
+	deliveries, err := s.service.Deliveries(filter, limit, offset)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, deliveries)
+}
+
+// Redeliver schedules a new delivery of the payload of a previous delivery.
+func (s *Webhooks) RedeliverFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.Redeliver(vars["deliveryId"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, job.ToJSONResponse())
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jpillora/backoff"
+)
+
+var (
+	// Default backoff for re-scheduling jobs in ERROR state.
+	defaultRetryBackoffMin    = 1 * time.Minute
+	defaultRetryBackoffMax    = 30 * time.Minute
+	defaultRetryBackoffFactor = 2.0
+)
+
+// RetryBackoff defines a jittered exponential backoff for re-scheduling
+// jobs that resulted in an error.
+type RetryBackoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+}
+
+// Duration returns the time to wait before the next execution of a job that
+// has been executed `execCount` times.
+func (b RetryBackoff) Duration(execCount int) time.Duration {
+	attempt := execCount - 1
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	bo := &backoff.Backoff{
+		Min:    b.Min,
+		Max:    b.Max,
+		Factor: b.Factor,
+		Jitter: true,
+	}
+
+	return bo.ForAttempt(float64(attempt))
+}
+
+// ParseJobTypeRetryBackoff parses a per job type backoff definition in the
+// format "<jobType>:<min>:<max>[:<factor>]", e.g. "transaction:10s:5m:3".
+func ParseJobTypeRetryBackoff(s string) (string, RetryBackoff, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q, expected <jobType>:<min>:<max>[:<factor>]", s)
+	}
+
+	jobType := strings.TrimSpace(parts[0])
+	if jobType == "" {
+		return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q, empty job type", s)
+	}
+
+	min, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q: %w", s, err)
+	}
+
+	max, err := time.ParseDuration(parts[2])
+	if err != nil {
+		return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q: %w", s, err)
+	}
+
+	if min <= 0 || max < min {
+		return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q, expected 0 < min <= max", s)
+	}
+
+	factor := defaultRetryBackoffFactor
+	if len(parts) == 4 {
+		factor, err = strconv.ParseFloat(parts[3], 64)
+		if err != nil || factor < 1 {
+			return "", RetryBackoff{}, fmt.Errorf("invalid job retry backoff %q, expected factor >= 1", s)
+		}
+	}
+
+	return jobType, RetryBackoff{Min: min, Max: max, Factor: factor}, nil
+}
+
+// retryBackoffFor returns the backoff for the given job type.
+func (wp *WorkerPoolImpl) retryBackoffFor(jobType string) RetryBackoff {
+	if b, ok := wp.jobTypeRetryBackoffs[jobType]; ok {
+		return b
+	}
+	return wp.retryBackoff
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBackpressureRetryAfter = 30 * time.Second
+
+	// How long the number of outstanding jobs in the DB is cached for, so
+	// that a burst of requests does not turn into a burst of status queries
+	backlogCheckInterval = 1 * time.Second
+)
+
+// Backpressure reasons, see BackpressureError.
+const (
+	BackpressureQueueFull   = "job_queue_full"   // Too many jobs in the in-memory queue
+	BackpressureBacklogFull = "job_backlog_full" // Too many outstanding jobs in the DB
+)
+
+// BackpressureError is returned by WorkerPool.CheckBackpressure when the
+// number of outstanding jobs has reached a high-water mark.
+type BackpressureError struct {
+	Reason        string
+	Outstanding   int
+	HighWaterMark int
+	RetryAfter    time.Duration
+}
+
+// BackpressureErrorResponse is the JSON response body of a request rejected
+// due to backpressure.
+type BackpressureErrorResponse struct {
+	Error         string `json:"error"`
+	Reason        string `json:"reason"`
+	Outstanding   int    `json:"outstanding"`
+	HighWaterMark int    `json:"highWaterMark"`
+	RetryAfter    int    `json:"retryAfter"` // Seconds
+}
+
+func (e *BackpressureError) Error() string {
+	switch e.Reason {
+	case BackpressureQueueFull:
+		return fmt.Sprintf("job queue full: %d queued jobs, high-water mark %d", e.Outstanding, e.HighWaterMark)
+	default:
+		return fmt.Sprintf("job backlog full: %d outstanding jobs, high-water mark %d", e.Outstanding, e.HighWaterMark)
+	}
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, at least 1.
+func (e *BackpressureError) RetryAfterSeconds() int {
+	s := int(math.Ceil(e.RetryAfter.Seconds()))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+func (e *BackpressureError) ToJSONResponse() BackpressureErrorResponse {
+	return BackpressureErrorResponse{
+		Error:         e.Error(),
+		Reason:        e.Reason,
+		Outstanding:   e.Outstanding,
+		HighWaterMark: e.HighWaterMark,
+		RetryAfter:    e.RetryAfterSeconds(),
+	}
+}
+
+// backlogCounter caches the number of outstanding jobs in the DB.
+type backlogCounter struct {
+	mu        sync.Mutex
+	count     int
+	checkedAt time.Time
+}
+
+// CheckBackpressure returns a *BackpressureError if the in-memory job queue
+// or the outstanding jobs in the DB have reached their high-water marks, in
+// which case callers should not create new jobs. Outstanding jobs are jobs
+// in INIT, ACCEPTED, NO_AVAILABLE_WORKERS and ERROR state; jobs SCHEDULED to
+// run later are not counted.
+func (wp *WorkerPoolImpl) CheckBackpressure() error {
+	if wp.queueHighWaterMark > 0 {
+		if n := wp.queue.len(); n >= wp.queueHighWaterMark {
+			return &BackpressureError{
+				Reason:        BackpressureQueueFull,
+				Outstanding:   n,
+				HighWaterMark: wp.queueHighWaterMark,
+				RetryAfter:    wp.backpressureRetryAfter,
+			}
+		}
+	}
+
+	if wp.backlogHighWaterMark > 0 {
+		n, err := wp.outstandingJobs()
+		if err != nil {
+			return err
+		}
+
+		if n >= wp.backlogHighWaterMark {
+			return &BackpressureError{
+				Reason:        BackpressureBacklogFull,
+				Outstanding:   n,
+				HighWaterMark: wp.backlogHighWaterMark,
+				RetryAfter:    wp.backpressureRetryAfter,
+			}
+		}
+	}
+
+	return nil
+}
+
+// outstandingJobs returns the number of outstanding jobs in the DB, at most
+// backlogCheckInterval old.
+func (wp *WorkerPoolImpl) outstandingJobs() (int, error) {
+	wp.backlog.mu.Lock()
+	defer wp.backlog.mu.Unlock()
+
+	if time.Since(wp.backlog.checkedAt) < backlogCheckInterval {
+		return wp.backlog.count, nil
+	}
+
+	counts, err := wp.store.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, c := range counts {
+		switch c.State {
+		case Init, Accepted, NoAvailableWorkers, Error:
+			n += c.Count
+		}
+	}
+
+	wp.backlog.count = n
+	wp.backlog.checkedAt = time.Now()
+
+	return n, nil
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Key of the callback url in job attributes.
+const callbackURLAttribute = "callbackUrl"
+
+type callbackURLContextKey struct{}
+
+// ContextWithCallbackURL returns a copy of ctx carrying the callback url of
+// the request. Jobs created with WithContextCallbackURL(ctx) deliver their
+// final status to it.
+func ContextWithCallbackURL(ctx context.Context, u string) context.Context {
+	return context.WithValue(ctx, callbackURLContextKey{}, u)
+}
+
+// CallbackURLFromContext returns the callback url carried by ctx, if any.
+func CallbackURLFromContext(ctx context.Context) string {
+	u, _ := ctx.Value(callbackURLContextKey{}).(string)
+	return u
+}
+
+// ValidateCallbackURL checks that u is an absolute http(s) url.
+func ValidateCallbackURL(u string) error {
+	valid, err := url.ParseRequestURI(u)
+	if err != nil || (valid.Scheme != "http" && valid.Scheme != "https") || valid.Host == "" {
+		return fmt.Errorf("invalid callback url")
+	}
+	return nil
+}
+
+// WithCallbackURL stores a callback url in the job's attributes. The final
+// status of the job is delivered to it. Existing attributes are kept, so this
+// option should come after WithAttributes.
+func WithCallbackURL(u string) JobOption {
+	return func(job *Job) {
+		if u == "" {
+			return
+		}
+
+		setAttribute(job, callbackURLAttribute, u)
+	}
+}
+
+// WithContextCallbackURL stores the callback url carried by ctx in the job's
+// attributes, see WithCallbackURL.
+func WithContextCallbackURL(ctx context.Context) JobOption {
+	return WithCallbackURL(CallbackURLFromContext(ctx))
+}
+
+// CallbackURL returns the callback url stored in the job's attributes, if any.
+func (j Job) CallbackURL() string {
+	if len(j.Attributes) == 0 {
+		return ""
+	}
+
+	var attrs struct {
+		CallbackURL string `json:"callbackUrl"`
+	}
+
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return ""
+	}
+
+	return attrs.CallbackURL
+}
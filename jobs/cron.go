@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	location                      *time.Location
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Bit set for a field that was given as "*"
+const cronStar = uint64(1) << 63
+
+// ParseCronSchedule parses a standard five field cron expression
+// ("<minute> <hour> <day of month> <month> <day of week>") evaluated in the
+// given location. Fields support "*", lists ("1,15"), ranges ("1-5"), steps
+// ("*/15", "0-30/10") and month and weekday names ("jan", "mon"). The
+// descriptors "@yearly", "@monthly", "@weekly", "@daily" and "@hourly" are
+// also accepted.
+//
+// As in cron, if both day of month and day of week are restricted a time
+// matches if either of them matches.
+func ParseCronSchedule(expr string, loc *time.Location) (CronSchedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	spec := strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("invalid cron expression %q, expected 5 fields", expr)
+	}
+
+	s := CronSchedule{location: loc}
+
+	for i, dst := range []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow} {
+		f := []cronField{cronMinute, cronHour, cronDom, cronMonth, cronDow}[i]
+		bits, err := f.parse(fields[i])
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		*dst = bits
+	}
+
+	// Sunday can be given as 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow &^ (1 << 7)) | 1
+	}
+
+	return s, nil
+}
+
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(s, ",") {
+		b, err := f.parsePart(part)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+
+	return bits, nil
+}
+
+func (f cronField) parsePart(s string) (uint64, error) {
+	rangeAndStep := strings.Split(s, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+
+	var (
+		start, end int
+		star       bool
+		err        error
+	)
+
+	r := rangeAndStep[0]
+
+	switch {
+	case r == "*":
+		start, end, star = f.min, f.max, true
+		if f.name == cronDow.name {
+			// 7 is only an alias for sunday
+			end = 6
+		}
+	case strings.Contains(r, "-"):
+		bounds := strings.Split(r, "-")
+		if len(bounds) != 2 {
+			return 0, fmt.Errorf("invalid %s %q", f.name, s)
+		}
+		if start, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if end, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+	default:
+		if start, err = f.value(r); err != nil {
+			return 0, err
+		}
+		end = start
+	}
+
+	step := 1
+	if len(rangeAndStep) == 2 {
+		step, err = strconv.Atoi(rangeAndStep[1])
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid %s step %q", f.name, s)
+		}
+		if !star && !strings.Contains(r, "-") {
+			// "5/15" means "5-max/15"
+			end = f.max
+		}
+		star = false
+	}
+
+	if start > end {
+		return 0, fmt.Errorf("invalid %s range %q", f.name, s)
+	}
+
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << uint(v)
+	}
+
+	if star {
+		bits |= cronStar
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// Next returns the first time matching the schedule strictly after t, or the
+// zero time if there is none within five years.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.location)
+
+	// Start from the next whole minute
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+
+	yearLimit := t.Year() + 5
+
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t.In(origLoc)
+	}
+
+	return time.Time{}
+}
+
+func (s CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.dom&cronStar != 0 || s.dow&cronStar != 0 {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is a recorded state transition of a Job.
+type Event struct {
+	ID         uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	JobID      uuid.UUID `gorm:"column:job_id;type:uuid;index" json:"jobId"`
+	FromState  State     `gorm:"column:from_state" json:"fromState"`
+	ToState    State     `gorm:"column:to_state" json:"toState"`
+	Attempt    int       `gorm:"column:attempt" json:"attempt"`
+	InstanceID string    `gorm:"column:instance_id" json:"instanceId"`
+	Error      string    `gorm:"column:error" json:"error"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (Event) TableName() string {
+	return "job_events"
+}
+
+// defaultInstanceID identifies this process in job events when no instance
+// ID has been configured.
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
+// recordTransition stores a job state transition from state `from` to the
+// current state of the job. Failing to record is not considered critical.
+func (wp *WorkerPoolImpl) recordTransition(job *Job, from State, errMsg string) {
+	e := &Event{
+		JobID:      job.ID,
+		FromState:  from,
+		ToState:    job.State,
+		Attempt:    job.ExecCount,
+		InstanceID: wp.instanceID,
+		Error:      errMsg,
+	}
+
+	if err := wp.store.InsertEvent(e); err != nil {
+		job.logEntry(wp.logger.WithFields(log.Fields{
+			"package":  "jobs",
+			"function": "WorkerPool.recordTransition",
+			"error":    err,
+		})).Warn("Could not record job state transition")
+	}
+
+	wp.transitions.broadcast()
+}
+
+// Transitions returns a channel that is closed on the next job state
+// transition made by this workerpool instance.
+func (wp *WorkerPoolImpl) Transitions() <-chan struct{} {
+	return wp.transitions.wait()
+}
+
+// EventGapTimeout is how long an EventReader waits for a skipped event id.
+// Ids are assigned before a transition is committed, so transitions can
+// become visible out of id order. Ids of rolled back inserts never show up.
+const EventGapTimeout = 30 * time.Second
+
+// maxEventGaps limits the number of skipped ids tracked by an EventReader.
+const maxEventGaps = 1000
+
+// EventSource is where an EventReader reads job state transitions from.
+type EventSource interface {
+	EventsAfter(afterID uint64, limit int) ([]Event, error)
+	EventsByID(ids []uint64) ([]Event, error)
+}
+
+// EventReader reads job state transitions of all jobs in order of
+// visibility, each once. Ids skipped over are re-read until the transition
+// shows up or EventGapTimeout passes.
+type EventReader struct {
+	src    EventSource
+	lastID uint64
+	gaps   map[uint64]time.Time // Skipped ids and when they were skipped
+}
+
+// NewEventReader returns a reader of transitions recorded after the
+// transition with id `afterID`. With gapWindow > 0 ids within gapWindow
+// before afterID which are not yet visible are read once they show up.
+func NewEventReader(src EventSource, afterID uint64, gapWindow int) (*EventReader, error) {
+	r := &EventReader{
+		src:    src,
+		lastID: afterID,
+		gaps:   make(map[uint64]time.Time),
+	}
+
+	if gapWindow <= 0 || afterID == 0 {
+		return r, nil
+	}
+
+	from := uint64(0)
+	if afterID > uint64(gapWindow) {
+		from = afterID - uint64(gapWindow)
+	}
+
+	events, err := src.EventsAfter(from, gapWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[uint64]bool, len(events))
+	for _, e := range events {
+		visible[e.ID] = true
+	}
+
+	now := time.Now()
+	for id := from + 1; id <= afterID && len(r.gaps) < maxEventGaps; id++ {
+		if !visible[id] {
+			r.gaps[id] = now
+		}
+	}
+
+	return r, nil
+}
+
+// Next returns transitions not read before, at most `limit` new ones after
+// previously skipped ones which have shown up. more tells whether there are
+// possibly more new transitions to read.
+func (r *EventReader) Next(limit int) (events []Event, more bool, err error) {
+	now := time.Now()
+
+	if len(r.gaps) > 0 {
+		ids := make([]uint64, 0, len(r.gaps))
+		for id, skipped := range r.gaps {
+			if now.Sub(skipped) > EventGapTimeout {
+				delete(r.gaps, id)
+				continue
+			}
+			ids = append(ids, id)
+		}
+
+		if len(ids) > 0 {
+			late, err := r.src.EventsByID(ids)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, e := range late {
+				delete(r.gaps, e.ID)
+			}
+			events = append(events, late...)
+		}
+	}
+
+	next, err := r.src.EventsAfter(r.lastID, limit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, e := range next {
+		for id := r.lastID + 1; id < e.ID && len(r.gaps) < maxEventGaps; id++ {
+			r.gaps[id] = now
+		}
+		r.lastID = e.ID
+	}
+
+	return append(events, next...), len(next) == limit, nil
+}
+
+// ResumeID returns the id to resume reading from after the transition with
+// id `eventID` has been read. It is below any id still awaited, so resuming
+// may read some transitions again.
+func (r *EventReader) ResumeID(eventID uint64) uint64 {
+	for id := range r.gaps {
+		if id <= eventID {
+			eventID = id - 1
+		}
+	}
+	return eventID
+}
+
+// broadcaster wakes up any number of waiters at once. The zero value is
+// ready for use.
+type broadcaster struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func (b *broadcaster) wait() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch == nil {
+		b.ch = make(chan struct{})
+	}
+	return b.ch
+}
+
+func (b *broadcaster) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch != nil {
+		close(b.ch)
+		b.ch = nil
+	}
+}
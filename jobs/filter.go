@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Filter narrows down a job listing. Empty fields are ignored.
+type Filter struct {
+	States        []State
+	Types         []string
+	TransactionID string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// Attributes maps a dot separated key path in Job.Attributes
+	// (e.g. "request.currencyName") to the expected value.
+	Attributes map[string]string
+	// Cursor is an opaque token returned by a previous listing, jobs
+	// after the cursor position are returned.
+	Cursor string
+}
+
+// Cursor is a position in a job listing ordered by creation time and ID,
+// both descending.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// NewCursor returns the cursor pointing right after the given job.
+func NewCursor(j Job) Cursor {
+	return Cursor{CreatedAt: j.CreatedAt, ID: j.ID}
+}
+
+// Encode returns the cursor as an opaque string.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c) // nolint
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -20,41 +20,76 @@ type State string
 
 const (
 	Init               State = "INIT"
+	Scheduled          State = "SCHEDULED"
 	Accepted           State = "ACCEPTED"
 	NoAvailableWorkers State = "NO_AVAILABLE_WORKERS"
 	Error              State = "ERROR"
 	Complete           State = "COMPLETE"
 	Failed             State = "FAILED"
+	Cancelled          State = "CANCELLED"
 )
 
+// Terminal returns true if a job in state s will not be executed again
+// (unless explicitly retried).
+func (s State) Terminal() bool {
+	return s == Complete || s == Failed || s == Cancelled
+}
+
 // Job database model
 type Job struct {
 	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
 	Type                   string         `gorm:"column:type"`
-	State                  State          `gorm:"column:state;default:INIT;index:idx_jobs_state_updated_at"`
+	State                  State          `gorm:"column:state;default:INIT;index:idx_jobs_state_updated_at;index:idx_jobs_state_next_run_at;index:idx_jobs_state_lease_expires_at"`
 	Error                  string         `gorm:"column:error"`
 	Errors                 pq.StringArray `gorm:"column:errors;type:text[]"`
 	Result                 string         `gorm:"column:result"`
 	TransactionID          string         `gorm:"column:transaction_id"`
 	ExecCount              int            `gorm:"column:exec_count;default:0"`
+	NextRunAt              time.Time      `gorm:"column:next_run_at;index:idx_jobs_state_next_run_at"`           // Earliest time for (re-)scheduling a job in SCHEDULED, ERROR or NO_AVAILABLE_WORKERS state
+	LeaseOwner             string         `gorm:"column:lease_owner"`                                            // Instance executing an ACCEPTED job
+	LeaseExpiresAt         time.Time      `gorm:"column:lease_expires_at;index:idx_jobs_state_lease_expires_at"` // An ACCEPTED job can be taken over by another instance after this
 	CreatedAt              time.Time      `gorm:"column:created_at"`
 	UpdatedAt              time.Time      `gorm:"column:updated_at;index:idx_jobs_state_updated_at"`
 	DeletedAt              gorm.DeletedAt `gorm:"column:deleted_at;index"`
 	ShouldSendNotification bool           `gorm:"-"` // Whether or not to notify admin (via webhook for example)
 	Attributes             datatypes.JSON `gorm:"attributes"`
+
+	claimed bool // Accepted by Store.ClaimJobs for this instance
 }
 
 func (Job) TableName() string {
 	return "jobs"
 }
 
+// JobStateCount is the number of jobs in a state. The counts are maintained
+// by the store on every state change so that reading the status does not
+// require counting the jobs table.
+type JobStateCount struct {
+	State State `gorm:"column:state;primaryKey"`
+	Count int   `gorm:"column:count"`
+}
+
+func (JobStateCount) TableName() string {
+	return "job_state_counts"
+}
+
 type JobQueueStatus struct {
 	JobsInit        int `json:"jobsInit"`
+	JobsScheduled   int `json:"jobsScheduled"`
 	JobsNotAccepted int `json:"jobsNotAccepted"`
 	JobsAccepted    int `json:"jobsAccepted"`
 	JobsErrored     int `json:"jobsErrored"`
 	JobsFailed      int `json:"jobsFailed"`
 	JobsCompleted   int `json:"jobsCompleted"`
+	JobsCancelled   int `json:"jobsCancelled"`
+}
+
+// RetryFilter selects FAILED jobs for a bulk retry. Empty fields are ignored.
+type RetryFilter struct {
+	Type          string     `json:"type"`
+	ErrorContains string     `json:"errorContains"`
+	FailedAfter   *time.Time `json:"failedAfter"`
+	FailedBefore  *time.Time `json:"failedBefore"`
 }
 
 // Job HTTP response
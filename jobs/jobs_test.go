@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"net/http"
 	"net/http/httptest"
@@ -17,20 +20,82 @@ import (
 	"github.com/google/uuid"
 )
 
+// typedJobStore returns jobs of jobType.
+type typedJobStore struct {
+	dummyStore
+	jobType string
+}
+
+func (s *typedJobStore) Job(id uuid.UUID) (Job, error) { return Job{ID: id, Type: s.jobType}, nil }
+
 type dummyStore struct{}
 
-func (*dummyStore) Jobs(datastore.ListOptions) ([]Job, error) { return nil, nil }
-func (*dummyStore) Job(id uuid.UUID) (Job, error)             { return Job{}, nil }
-func (*dummyStore) InsertJob(*Job) error                      { return nil }
-func (*dummyStore) UpdateJob(*Job) error                      { return nil }
-func (*dummyStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
+func (*dummyStore) Jobs(Filter, datastore.ListOptions) ([]Job, error) { return nil, nil }
+func (*dummyStore) Job(id uuid.UUID) (Job, error)                     { return Job{}, nil }
+func (*dummyStore) InsertJob(*Job) error                              { return nil }
+func (*dummyStore) UpdateJob(*Job) error                              { return nil }
+func (*dummyStore) AcceptJob(j *Job, owner string, lease time.Duration) error {
 	j.ExecCount = j.ExecCount + 1
 	return nil
 }
-func (*dummyStore) SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error) {
+func (*dummyStore) RenewLease(j *Job, owner string, lease time.Duration) error { return nil }
+func (*dummyStore) ReleaseJob(j *Job, owner string) error                      { return nil }
+func (*dummyStore) CancelJob(j *Job) error {
+	j.State = Cancelled
+	return nil
+}
+func (*dummyStore) RetryJob(j *Job) error {
+	j.State = Init
+	j.ExecCount = 0
+	return nil
+}
+func (*dummyStore) FailedJobs(f RetryFilter) ([]Job, error) { return nil, nil }
+func (*dummyStore) SchedulableJobs(acceptedGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error) {
+	return nil, nil
+}
+func (*dummyStore) Status() ([]StatusQuery, error)          { return nil, nil }
+func (*dummyStore) InsertEvent(*Event) error                { return nil }
+func (*dummyStore) Events(jobID uuid.UUID) ([]Event, error) { return nil, nil }
+func (*dummyStore) EventsAfter(afterID uint64, limit int) ([]Event, error) {
+	return nil, nil
+}
+func (*dummyStore) EventsByID(ids []uint64) ([]Event, error) { return nil, nil }
+func (*dummyStore) LatestEventID() (uint64, error)           { return 0, nil }
+func (*dummyStore) WebhookSubscriptions() ([]WebhookSubscription, error) {
+	return nil, nil
+}
+func (*dummyStore) WebhookSubscription(id uuid.UUID) (WebhookSubscription, error) {
+	return WebhookSubscription{}, nil
+}
+func (*dummyStore) InsertWebhookSubscription(*WebhookSubscription) error { return nil }
+func (*dummyStore) UpdateWebhookSubscription(*WebhookSubscription) error { return nil }
+func (*dummyStore) DeleteWebhookSubscription(id uuid.UUID) error         { return nil }
+func (*dummyStore) InsertWebhookDelivery(*WebhookDelivery) error         { return nil }
+func (*dummyStore) WebhookDeliveries(f DeliveryFilter, o datastore.ListOptions) ([]WebhookDelivery, error) {
+	return nil, nil
+}
+func (*dummyStore) WebhookDelivery(id uint64) (WebhookDelivery, error) {
+	return WebhookDelivery{}, nil
+}
+func (*dummyStore) JobSchedules() ([]JobSchedule, error)                 { return nil, nil }
+func (*dummyStore) JobSchedule(id uuid.UUID) (JobSchedule, error)        { return JobSchedule{}, nil }
+func (*dummyStore) InsertJobSchedule(*JobSchedule) error                 { return nil }
+func (*dummyStore) UpdateJobSchedule(*JobSchedule) error                 { return nil }
+func (*dummyStore) DeleteJobSchedule(id uuid.UUID) error                 { return nil }
+func (*dummyStore) DueJobSchedules(now time.Time) ([]JobSchedule, error) { return nil, nil }
+func (*dummyStore) MaterializeJobSchedule(s *JobSchedule, next time.Time, j *Job) error {
+	return nil
+}
+func (*dummyStore) ArchiveJobs(f PruneFilter, limit int) (int, error) { return 0, nil }
+func (*dummyStore) NextDueAt(t0 time.Time) (*time.Time, error) {
 	return nil, nil
 }
-func (*dummyStore) Status() ([]StatusQuery, error) { return nil, nil }
+func (*dummyStore) ClaimJobs(acceptedGracePeriod time.Duration, owner string, lease time.Duration, limit int) ([]Claim, error) {
+	return nil, nil
+}
+func (*dummyStore) DeleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error) (int, error) {
+	return 0, nil
+}
 
 func TestScheduleSendNotification(t *testing.T) {
 	logger, hook := test.NewNullLogger()
@@ -40,12 +105,13 @@ func TestScheduleSendNotification(t *testing.T) {
 		context:       ctx,
 		cancelContext: cancel,
 		executors:     make(map[string]ExecutorFunc),
-		jobChan:       make(chan *Job, 1),
+		queue:         newJobQueue(1, nil, nil),
 		store:         &dummyStore{},
 	}
 
 	WithJobStatusWebhook("http://localhost", time.Minute)(&wp)
 	WithLogger(logger)(&wp)
+	WithLogger(logger)(&wp)
 
 	sendNotificationCalled := false
 
@@ -69,11 +135,11 @@ func TestScheduleSendNotification(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(wp.jobChan) == 0 {
+	if wp.queue.len() == 0 {
 		t.Fatal("expected job channel to contain a job")
 	}
 
-	sendNotificationJob := <-wp.jobChan
+	sendNotificationJob := wp.queue.pop()
 
 	if sendNotificationJob.Type != "send_job_status" {
 		t.Fatalf("expected pool to have a send_job_status job")
@@ -109,7 +175,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:       ctx,
 			cancelContext: cancel,
 			executors:     make(map[string]ExecutorFunc),
-			jobChan:       make(chan *Job, 1),
+			queue:         newJobQueue(1, nil, nil),
 			store:         &dummyStore{},
 		}
 
@@ -132,7 +198,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if err := wp.process(<-wp.jobChan); err != nil {
+		if err := wp.process(wp.queue.pop()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -165,7 +231,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:       ctx,
 			cancelContext: cancel,
 			executors:     make(map[string]ExecutorFunc),
-			jobChan:       make(chan *Job, 1),
+			queue:         newJobQueue(1, nil, nil),
 			store:         &dummyStore{},
 		}
 
@@ -187,7 +253,7 @@ func TestExecuteSendNotification(t *testing.T) {
 		if err := wp.process(job); err != nil {
 			t.Fatal(err)
 		}
-		if err := wp.process(<-wp.jobChan); err != nil {
+		if err := wp.process(wp.queue.pop()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -212,13 +278,14 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: 1,
 		}
 
 		WithJobStatusWebhook("http://localhost", time.Minute)(&wp)
 		WithLogger(logger)(&wp)
+		WithLogger(logger)(&wp)
 
 		wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
 
@@ -236,7 +303,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if len(wp.jobChan) != 0 {
+		if wp.queue.len() != 0 {
 			t.Errorf("did not expect a job to be queued")
 		}
 	})
@@ -254,7 +321,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: 1,
 		}
@@ -278,7 +345,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal()
 		}
 
-		sendNotificationJob := <-wp.jobChan
+		sendNotificationJob := wp.queue.pop()
 
 		if err := wp.process(sendNotificationJob); err != nil {
 			t.Fatal(err)
@@ -294,6 +361,58 @@ func TestExecuteSendNotification(t *testing.T) {
 	})
 }
 
+func TestCancelSendNotification(t *testing.T) {
+	var webhookJob Job
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&webhookJob); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer svr.Close()
+
+	logger, hook := test.NewNullLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := WorkerPoolImpl{
+		context:       ctx,
+		cancelContext: cancel,
+		executors:     make(map[string]ExecutorFunc),
+		queue:         newJobQueue(1, nil, nil),
+		store:         &typedJobStore{jobType: "notifying"},
+	}
+
+	WithJobStatusWebhook(svr.URL, time.Minute)(&wp)
+	WithLogger(logger)(&wp)
+
+	wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+	wp.RegisterExecutor("notifying", func(ctx context.Context, j *Job) error { return nil }, WithStatusNotifications())
+
+	job, err := wp.Cancel(uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != Cancelled {
+		t.Fatalf("expected job to be in state '%s' got '%s'", Cancelled, job.State)
+	}
+
+	if wp.queue.len() == 0 {
+		t.Fatal("expected job channel to contain a job")
+	}
+
+	if err := wp.process(wp.queue.pop()); err != nil {
+		t.Fatal(err)
+	}
+
+	if webhookJob.State != Cancelled {
+		t.Fatalf("expected webhook endpoint to have received a notification with state '%s' got '%s'", Cancelled, webhookJob.State)
+	}
+
+	if len(hook.Entries) > 0 {
+		t.Fatalf("did not expect a warning, got %s", hook.LastEntry().Message)
+	}
+}
+
 func TestJobErrorMessages(t *testing.T) {
 	t.Run("all error messages are stored & published when retries occur", func(t *testing.T) {
 		retryCount := 3
@@ -318,7 +437,7 @@ func TestJobErrorMessages(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: retryCount,
 		}
@@ -356,7 +475,7 @@ func TestJobErrorMessages(t *testing.T) {
 		}
 
 		// Send the notification
-		sendNotificationJob := <-wp.jobChan
+		sendNotificationJob := wp.queue.pop()
 		if err := wp.process(sendNotificationJob); err != nil {
 			t.Fatal(err)
 		}
@@ -378,3 +497,691 @@ func TestJobErrorMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestRetryBackoff(t *testing.T) {
+	t.Run("duration stays within bounds", func(t *testing.T) {
+		b := RetryBackoff{Min: time.Second, Max: time.Minute, Factor: 2}
+
+		for execCount := 0; execCount < 20; execCount++ {
+			d := b.Duration(execCount)
+			if d < b.Min || d > b.Max {
+				t.Fatalf("expected duration for exec count %d to be within [%s, %s], got %s", execCount, b.Min, b.Max, d)
+			}
+		}
+	})
+
+	t.Run("parse job type backoff", func(t *testing.T) {
+		jobType, b, err := ParseJobTypeRetryBackoff("transaction:10s:5m:3")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := RetryBackoff{Min: 10 * time.Second, Max: 5 * time.Minute, Factor: 3}
+		if jobType != "transaction" || b != expected {
+			t.Fatalf("expected %q %+v, got %q %+v", "transaction", expected, jobType, b)
+		}
+
+		_, b, err = ParseJobTypeRetryBackoff("transaction:10s:5m")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.Factor != defaultRetryBackoffFactor {
+			t.Fatalf("expected default factor %f, got %f", defaultRetryBackoffFactor, b.Factor)
+		}
+
+		invalid := []string{"", "transaction", "transaction:10s", ":10s:5m", "transaction:5m:10s", "transaction:10s:5m:0.5", "transaction:x:5m"}
+		for _, s := range invalid {
+			if _, _, err := ParseJobTypeRetryBackoff(s); err == nil {
+				t.Errorf("expected an error for %q", s)
+			}
+		}
+	})
+
+	t.Run("erroring job gets next run time from job type backoff", func(t *testing.T) {
+		logger, _ := test.NewNullLogger()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wp := WorkerPoolImpl{
+			context:          ctx,
+			cancelContext:    cancel,
+			executors:        make(map[string]ExecutorFunc),
+			queue:            newJobQueue(1, nil, nil),
+			store:            &dummyStore{},
+			maxJobErrorCount: 10,
+		}
+
+		WithLogger(logger)(&wp)
+		WithJobRetryBackoff(time.Hour, time.Hour, 1)(&wp)
+		WithJobTypeRetryBackoffs([]string{"TestJobType:1m:1m"})(&wp)
+
+		wp.RegisterExecutor("TestJobType", func(ctx context.Context, j *Job) error {
+			return fmt.Errorf("test error")
+		})
+
+		job, err := wp.CreateJob("TestJobType", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t0 := time.Now()
+
+		if err := wp.process(job); err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != Error {
+			t.Fatalf("expected job to be in state '%s' got '%s'", Error, job.State)
+		}
+
+		if d := job.NextRunAt.Sub(t0); d < time.Minute || d > time.Minute+time.Second {
+			t.Fatalf("expected job to be re-scheduled in about %s, got %s", time.Minute, d)
+		}
+	})
+}
+
+func TestSignedJobStatusWebhook(t *testing.T) {
+	secret := "test-secret"
+
+	var signature, expected string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature = r.Header.Get(WebhookSignatureHeader)
+		expected = SignWebhookPayload(secret, timestamp, body)
+	}))
+	defer svr.Close()
+
+	logger, _ := test.NewNullLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := WorkerPoolImpl{
+		context:       ctx,
+		cancelContext: cancel,
+		executors:     make(map[string]ExecutorFunc),
+		queue:         newJobQueue(1, nil, nil),
+		store:         &dummyStore{},
+	}
+
+	WithJobStatusWebhook(svr.URL, time.Minute)(&wp)
+	WithJobStatusWebhookSecret(secret)(&wp)
+	WithLogger(logger)(&wp)
+
+	wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+
+	wp.RegisterExecutor("TestJobType", func(ctx context.Context, j *Job) error {
+		j.ShouldSendNotification = true
+		return nil
+	})
+
+	job, err := wp.CreateJob("TestJobType", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.process(job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.process(wp.queue.pop()); err != nil {
+		t.Fatal(err)
+	}
+
+	if signature == "" {
+		t.Fatal("expected webhook request to be signed")
+	}
+
+	if signature != expected {
+		t.Fatalf("expected signature %s, got %s", expected, signature)
+	}
+
+	if SignWebhookPayload("other-secret", 0, []byte("{}")) == SignWebhookPayload(secret, 0, []byte("{}")) {
+		t.Fatal("expected signatures with different secrets to differ")
+	}
+}
+
+func TestCallbackURL(t *testing.T) {
+	t.Run("attributes are kept", func(t *testing.T) {
+		job := &Job{}
+		WithAttributes([]byte(`{"address":"0x01"}`))(job)
+		WithCallbackURL("http://localhost/callback")(job)
+
+		if job.CallbackURL() != "http://localhost/callback" {
+			t.Fatalf("expected callback url to be stored, got %q", job.CallbackURL())
+		}
+
+		var attrs map[string]string
+		if err := json.Unmarshal(job.Attributes, &attrs); err != nil {
+			t.Fatal(err)
+		}
+
+		if attrs["address"] != "0x01" {
+			t.Fatalf("expected existing attributes to be kept, got %s", job.Attributes)
+		}
+	})
+
+	for _, replaces := range []bool{false, true} {
+		replaces := replaces
+		t.Run(fmt.Sprintf("replaces global webhook: %t", replaces), func(t *testing.T) {
+			var globalCalled, callbackCalled bool
+			global := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				globalCalled = true
+			}))
+			defer global.Close()
+
+			callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				callbackCalled = true
+			}))
+			defer callback.Close()
+
+			logger, _ := test.NewNullLogger()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wp := WorkerPoolImpl{
+				context:       ctx,
+				cancelContext: cancel,
+				executors:     make(map[string]ExecutorFunc),
+				queue:         newJobQueue(2, nil, nil),
+				store:         &dummyStore{},
+			}
+
+			WithJobStatusWebhook(global.URL, time.Minute)(&wp)
+			WithCallbackReplacesJobStatusWebhook(replaces)(&wp)
+			WithLogger(logger)(&wp)
+
+			wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+
+			wp.RegisterExecutor("TestJobType", func(ctx context.Context, j *Job) error {
+				j.ShouldSendNotification = true
+				return nil
+			})
+
+			reqCtx := ContextWithCallbackURL(context.Background(), callback.URL)
+
+			job, err := wp.CreateJob("TestJobType", "", WithContextCallbackURL(reqCtx))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wp.process(job); err != nil {
+				t.Fatal(err)
+			}
+
+			for wp.queue.len() > 0 {
+				if err := wp.process(wp.queue.pop()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !callbackCalled {
+				t.Fatal("expected callback url to have received a notification")
+			}
+
+			if globalCalled == replaces {
+				t.Fatalf("expected global webhook called = %t, got %t", !replaces, globalCalled)
+			}
+		})
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	t.Run("higher priority first", func(t *testing.T) {
+		q := newJobQueue(10, map[string]int{"high": 10, "low": -1}, nil)
+
+		for _, jobType := range []string{"low", "normal", "high", "normal", "high"} {
+			if !q.push(&Job{Type: jobType}, false) {
+				t.Fatalf("expected job to be queued")
+			}
+		}
+
+		var got []string
+		for q.len() > 0 {
+			j := q.pop()
+			got = append(got, j.Type)
+			q.done(j)
+		}
+
+		expected := []string{"high", "high", "normal", "normal", "low"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected order %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("concurrency limit", func(t *testing.T) {
+		q := newJobQueue(10, nil, map[string]int{"limited": 1})
+
+		q.push(&Job{Type: "limited"}, false)
+		if q.push(&Job{Type: "limited"}, false) {
+			t.Fatalf("expected limited job type queue to be full")
+		}
+		q.push(&Job{Type: "other"}, false)
+
+		first := q.pop()
+		if first.Type != "limited" {
+			t.Fatalf("expected limited job first, got %s", first.Type)
+		}
+
+		if !q.push(&Job{Type: "limited"}, false) {
+			t.Fatalf("expected job to be queued")
+		}
+
+		// Limited job type is at its limit, other job types are not blocked
+		if j := q.pop(); j.Type != "other" {
+			t.Fatalf("expected other job, got %s", j.Type)
+		}
+
+		status := q.status()
+		if status["limited"].Running != 1 || status["limited"].Queued != 1 || status["limited"].MaxConcurrency != 1 {
+			t.Fatalf("unexpected status %+v", status["limited"])
+		}
+
+		q.done(first)
+
+		if j := q.pop(); j.Type != "limited" {
+			t.Fatalf("expected limited job, got %s", j.Type)
+		}
+	})
+
+	t.Run("capacity", func(t *testing.T) {
+		q := newJobQueue(1, nil, nil)
+
+		if !q.push(&Job{Type: "job"}, false) {
+			t.Fatalf("expected job to be queued")
+		}
+
+		if q.push(&Job{Type: "job"}, false) {
+			t.Fatalf("expected queue to be full")
+		}
+
+		q.close()
+
+		if q.push(&Job{Type: "job"}, true) {
+			t.Fatalf("expected closed queue to reject jobs")
+		}
+
+		if q.pop() == nil {
+			t.Fatalf("expected queued job to be handed out after close")
+		}
+
+		if q.pop() != nil {
+			t.Fatalf("expected closed and empty queue to return nil")
+		}
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		q := newJobQueue(10, map[string]int{"high": 10}, nil)
+
+		for _, jobType := range []string{"low", "high", "low"} {
+			q.push(&Job{Type: jobType}, false)
+		}
+
+		var got []string
+		for _, j := range q.drain() {
+			got = append(got, j.Type)
+		}
+
+		expected := []string{"low", "high", "low"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected drained jobs %v, got %v", expected, got)
+		}
+
+		if q.len() != 0 || q.push(&Job{Type: "low"}, false) {
+			t.Fatalf("expected drained queue to be empty and closed")
+		}
+
+		if q.pop() != nil {
+			t.Fatalf("expected drained queue to return nil")
+		}
+	})
+}
+
+func TestCronSchedule(t *testing.T) {
+	base := time.Date(2026, time.October, 17, 10, 30, 15, 0, time.UTC) // Saturday
+
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", base, time.Date(2026, time.October, 17, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", base, time.Date(2026, time.October, 17, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", base, time.Date(2026, time.October, 18, 2, 0, 0, 0, time.UTC)},
+		{"@daily", base, time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", base, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", base, time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", base, time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", base, time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", base, time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week
+		{"0 0 1 * mon", base, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)},
+		{"30 10 17 10 *", base, time.Date(2027, time.October, 17, 10, 30, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		s, err := ParseCronSchedule(c.expr, time.UTC)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", c.expr, err)
+		}
+		if got := s.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("%q: expected next run at %s, got %s", c.expr, c.want, got)
+		}
+	}
+
+	t.Run("location", func(t *testing.T) {
+		loc := time.FixedZone("UTC+2", 2*60*60)
+		s, err := ParseCronSchedule("0 0 * * *", loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := time.Date(2026, time.October, 17, 22, 0, 0, 0, time.UTC)
+		if got := s.Next(base); !got.Equal(want) {
+			t.Errorf("expected next run at %s, got %s", want, got)
+		}
+	})
+
+	t.Run("never matches", func(t *testing.T) {
+		s, err := ParseCronSchedule("0 0 30 feb *", time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Next(base); !got.IsZero() {
+			t.Errorf("expected zero time, got %s", got)
+		}
+	})
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := ParseCronSchedule(expr, time.UTC); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
+
+func TestRunAt(t *testing.T) {
+	runAt := time.Now().Add(time.Hour)
+
+	job := &Job{State: Init}
+	WithRunAt(runAt)(job)
+
+	if job.State != Scheduled || !job.NextRunAt.Equal(runAt) {
+		t.Fatalf("expected job to be scheduled at %s, got state %s at %s", runAt, job.State, job.NextRunAt)
+	}
+
+	job = &Job{State: Init}
+	WithRunAt(time.Now().Add(-time.Hour))(job)
+
+	if job.State != Init {
+		t.Fatalf("expected a job with a past run time to be in state %s, got %s", Init, job.State)
+	}
+}
+
+func TestRetentionPolicies(t *testing.T) {
+	valid := map[string]RetentionPolicy{
+		"send_job_status:COMPLETE:24h": {JobType: "send_job_status", State: Complete, MaxAge: 24 * time.Hour},
+		"*:failed:720h":                {State: Failed, MaxAge: 720 * time.Hour},
+		"transaction:*:1h":             {JobType: "transaction", MaxAge: time.Hour},
+	}
+
+	for spec, want := range valid {
+		got, err := ParseRetentionPolicy(spec)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", spec, err)
+		}
+		if got != want {
+			t.Fatalf("%q: expected %+v, got %+v", spec, want, got)
+		}
+	}
+
+	for _, spec := range []string{"", "a:COMPLETE", ":COMPLETE:1h", "a:ERROR:1h", "a:INIT:1h", "a:COMPLETE:0s", "a:COMPLETE:x", "a:COMPLETE:1h:x"} {
+		if _, err := ParseRetentionPolicy(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+
+	now := time.Now()
+	all := RetentionPolicy{MaxAge: 720 * time.Hour}
+	complete := RetentionPolicy{State: Complete, MaxAge: 48 * time.Hour}
+	tx := RetentionPolicy{JobType: "transaction", State: Complete, MaxAge: 8760 * time.Hour}
+
+	filters := pruneFilters([]RetentionPolicy{all, complete, tx}, now)
+
+	if len(filters) != 3 {
+		t.Fatalf("expected 3 filters, got %d", len(filters))
+	}
+
+	if len(filters[0].States) != 3 || len(filters[0].Exclude) != 2 {
+		t.Fatalf("expected policy for all jobs to exclude the more specific policies, got %+v", filters[0])
+	}
+
+	if len(filters[1].Exclude) != 1 || filters[1].Exclude[0] != tx {
+		t.Fatalf("expected policy for COMPLETE jobs to exclude the transaction policy, got %+v", filters[1])
+	}
+
+	if len(filters[2].Exclude) != 0 || filters[2].JobType != "transaction" || !filters[2].UpdatedBefore.Equal(now.Add(-tx.MaxAge)) {
+		t.Fatalf("unexpected filter for transaction policy %+v", filters[2])
+	}
+}
+
+func TestExecutionTimeout(t *testing.T) {
+	t.Run("parse job type timeout", func(t *testing.T) {
+		jobType, d, err := ParseJobTypeExecutionTimeout("transaction:10m")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if jobType != "transaction" || d != 10*time.Minute {
+			t.Fatalf("expected %q %s, got %q %s", "transaction", 10*time.Minute, jobType, d)
+		}
+
+		invalid := []string{"", "transaction", ":10m", "transaction:0s", "transaction:-1m", "transaction:x", "transaction:1m:2m"}
+		for _, s := range invalid {
+			if _, _, err := ParseJobTypeExecutionTimeout(s); err == nil {
+				t.Errorf("expected an error for %q", s)
+			}
+		}
+	})
+
+	t.Run("job type timeout overrides executor timeout", func(t *testing.T) {
+		wp := NewWorkerPool(&dummyStore{}, 1, 1,
+			WithJobExecutionTimeout(time.Minute),
+			WithJobTypeExecutionTimeouts([]string{"overridden:3m"}),
+		).(*WorkerPoolImpl)
+
+		executor := func(ctx context.Context, j *Job) error { return nil }
+		wp.RegisterExecutor("default", executor)
+		wp.RegisterExecutor("declared", executor, WithExecutorTimeout(2*time.Minute))
+		wp.RegisterExecutor("overridden", executor, WithExecutorTimeout(2*time.Minute))
+
+		expected := map[string]time.Duration{
+			"default":    time.Minute,
+			"declared":   2 * time.Minute,
+			"overridden": 3 * time.Minute,
+		}
+
+		for jobType, d := range expected {
+			if timeout := wp.executionTimeout(jobType); timeout != d {
+				t.Errorf("expected %s timeout to be %s, got %s", jobType, d, timeout)
+			}
+		}
+	})
+
+	t.Run("timed out job is retried", func(t *testing.T) {
+		logger, _ := test.NewNullLogger()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wp := WorkerPoolImpl{
+			context:          ctx,
+			cancelContext:    cancel,
+			executors:        make(map[string]ExecutorFunc),
+			queue:            newJobQueue(1, nil, nil),
+			store:            &dummyStore{},
+			maxJobErrorCount: 10,
+		}
+
+		WithLogger(logger)(&wp)
+		WithJobRetryBackoff(time.Minute, time.Minute, 1)(&wp)
+
+		// Stuck until the deadline, then fails permanently
+		wp.RegisterExecutor("TestJobType", func(ctx context.Context, j *Job) error {
+			<-ctx.Done()
+			return PermanentFailure(ctx.Err())
+		}, WithExecutorTimeout(50*time.Millisecond))
+
+		job, err := wp.CreateJob("TestJobType", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t0 := time.Now()
+
+		if err := wp.process(job); err != nil {
+			t.Fatal(err)
+		}
+
+		if d := time.Since(t0); d > time.Second {
+			t.Fatalf("expected execution to be cancelled after the timeout, took %s", d)
+		}
+
+		if job.State != Error {
+			t.Fatalf("expected job to be in state '%s' got '%s'", Error, job.State)
+		}
+
+		if !strings.HasPrefix(job.Error, ErrJobTimeout.Error()) {
+			t.Fatalf("expected a timeout error, got %q", job.Error)
+		}
+
+		if len(job.Errors) != 1 || job.Errors[0] != job.Error {
+			t.Fatalf("expected the timeout error to be recorded, got %v", job.Errors)
+		}
+	})
+}
+
+func TestCancelWithoutNotification(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := WorkerPoolImpl{
+		context:       ctx,
+		cancelContext: cancel,
+		executors:     make(map[string]ExecutorFunc),
+		queue:         newJobQueue(1, nil, nil),
+		store:         &typedJobStore{jobType: "silent"},
+	}
+
+	WithJobStatusWebhook("http://localhost", time.Minute)(&wp)
+	WithLogger(logger)(&wp)
+
+	wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+	wp.RegisterExecutor("silent", func(ctx context.Context, j *Job) error { return nil })
+
+	job, err := wp.Cancel(uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != Cancelled {
+		t.Fatalf("expected job to be in state '%s' got '%s'", Cancelled, job.State)
+	}
+
+	if wp.queue.len() != 0 {
+		t.Fatal("expected no notification for a job type without status notifications")
+	}
+}
+
+// eventList is an EventSource of the visible events.
+type eventList []Event
+
+func (l eventList) EventsAfter(afterID uint64, limit int) ([]Event, error) {
+	var ee []Event
+	for _, e := range l {
+		if e.ID > afterID && len(ee) < limit {
+			ee = append(ee, e)
+		}
+	}
+	return ee, nil
+}
+
+func (l eventList) EventsByID(ids []uint64) ([]Event, error) {
+	var ee []Event
+	for _, e := range l {
+		for _, id := range ids {
+			if e.ID == id {
+				ee = append(ee, e)
+			}
+		}
+	}
+	return ee, nil
+}
+
+func TestEventReaderOutOfOrderCommit(t *testing.T) {
+	// Event 2 has been assigned its id but is committed after event 3
+	src := eventList{{ID: 1}, {ID: 3}}
+
+	r, err := NewEventReader(src, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _, err := r.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].ID != 1 || events[1].ID != 3 {
+		t.Fatalf("expected events 1 and 3, got %v", events)
+	}
+
+	if id := r.ResumeID(3); id != 1 {
+		t.Fatalf("expected resume id 1 while event 2 is awaited, got %d", id)
+	}
+
+	r.src = eventList{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	events, _, err = r.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].ID != 2 {
+		t.Fatalf("expected late event 2, got %v", events)
+	}
+
+	if id := r.ResumeID(2); id != 2 {
+		t.Fatalf("expected resume id 2, got %d", id)
+	}
+
+	events, _, err = r.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("expected no events to be read twice, got %v", events)
+	}
+}
+
+func TestEventReaderGapWindow(t *testing.T) {
+	// Event 2 is not yet visible when starting to read after the latest event
+	r, err := NewEventReader(eventList{{ID: 1}, {ID: 3}}, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.src = eventList{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+
+	events, _, err := r.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].ID != 2 || events[1].ID != 4 {
+		t.Fatalf("expected events 2 and 4, got %v", events)
+	}
+}
@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Default duration of a lease on an ACCEPTED job. The lease is renewed every
+// third of its duration while the job is being executed.
+const defaultJobLeaseDuration = 1 * time.Minute
+
+func (wp *WorkerPoolImpl) lease() time.Duration {
+	if wp.leaseDuration <= 0 {
+		return defaultJobLeaseDuration
+	}
+	return wp.leaseDuration
+}
+
+// keepLease renews the lease of this instance on job j until the returned
+// stop function is called. The returned context is cancelled if the lease is
+// lost, i.e. the job was taken over by another worker. stop returns true if
+// the lease was lost.
+func (wp *WorkerPoolImpl) keepLease(j *Job) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(wp.context)
+
+	entry := j.logEntry(wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.keepLease",
+	}))
+
+	var lost int32
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		interval := wp.lease() / 3
+		if interval <= 0 {
+			interval = wp.lease()
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if err := wp.store.RenewLease(j, wp.instanceID, wp.lease()); err != nil {
+				if errors.Is(err, ErrLeaseLost) {
+					entry.Warn("Lost lease on job, stopping execution")
+					atomic.StoreInt32(&lost, 1)
+					cancel()
+					return
+				}
+
+				entry.
+					WithFields(log.Fields{"error": err}).
+					Warn("Could not renew lease on job")
+			}
+		}
+	}()
+
+	return ctx, func() bool {
+		close(done)
+		wg.Wait()
+		cancel()
+		return atomic.LoadInt32(&lost) == 1
+	}
+}
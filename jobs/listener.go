@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// JobNotificationChannel is the PostgreSQL notification channel a job is
+// announced on when it becomes schedulable, see migration m20261017_7.
+const JobNotificationChannel = "flow_wallet_jobs"
+
+const (
+	listenerMinReconnectInterval = 1 * time.Second
+	listenerMaxReconnectInterval = 1 * time.Minute
+	listenerPingInterval         = 90 * time.Second
+	listenerBlockTimeout         = 5 * time.Second
+)
+
+// JobListener announces that jobs have become schedulable, possibly on
+// another instance. Notifications are coalesced; a receive means "at least
+// one job may be waiting".
+type JobListener interface {
+	Notifications() <-chan struct{}
+	Close() error
+}
+
+// Claim is a job accepted by Store.ClaimJobs and the state it was claimed
+// from.
+type Claim struct {
+	Job  Job
+	From State
+}
+
+type postgresJobListener struct {
+	listener      *pq.Listener
+	notifications chan struct{}
+	done          chan struct{}
+}
+
+// NewPostgresJobListener listens for job notifications using PostgreSQL
+// LISTEN/NOTIFY on the database at dsn.
+func NewPostgresJobListener(dsn string) (JobListener, error) {
+	entry := log.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "NewPostgresJobListener",
+	})
+
+	l := pq.NewListener(dsn, listenerMinReconnectInterval, listenerMaxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			entry.WithFields(log.Fields{"error": err}).Warn("Job listener connection error")
+		}
+	})
+
+	if err := l.Listen(JobNotificationChannel); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	pl := &postgresJobListener{
+		listener:      l,
+		notifications: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	go pl.run()
+
+	return pl, nil
+}
+
+func (l *postgresJobListener) run() {
+	for {
+		select {
+		case <-l.done:
+			return
+		case _, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established and
+			// notifications may have been lost, wake up in any case
+			l.notify()
+		case <-time.After(listenerPingInterval):
+			go l.listener.Ping()
+		}
+	}
+}
+
+func (l *postgresJobListener) notify() {
+	select {
+	case l.notifications <- struct{}{}:
+	default:
+	}
+}
+
+func (l *postgresJobListener) Notifications() <-chan struct{} {
+	return l.notifications
+}
+
+func (l *postgresJobListener) Close() error {
+	close(l.done)
+	return l.listener.Close()
+}
+
+type redisJobListener struct {
+	pool          *redis.Pool
+	stream        string
+	notifications chan struct{}
+	done          chan struct{}
+}
+
+// NewRedisJobListener listens for jobs added to the job stream of a
+// RedisStore with keys prefixed with keyPrefix.
+func NewRedisJobListener(pool *redis.Pool, keyPrefix string) JobListener {
+	l := &redisJobListener{
+		pool:          pool,
+		stream:        redisJobStreamKey(keyPrefix),
+		notifications: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	go l.run()
+
+	return l
+}
+
+func (l *redisJobListener) run() {
+	entry := log.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "redisJobListener.run",
+	})
+
+	// Only entries added from now on are of interest
+	lastID := fmt.Sprintf("%d-0", time.Now().UnixMilli())
+
+	c := l.pool.Get()
+	defer func() { c.Close() }()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+
+		reply, err := redis.Values(c.Do("XREAD", "COUNT", 100, "BLOCK", listenerBlockTimeout.Milliseconds(), "STREAMS", l.stream, lastID))
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			entry.WithFields(log.Fields{"error": err}).Warn("Job listener connection error")
+			c.Close()
+			select {
+			case <-l.done:
+				return
+			case <-time.After(listenerMinReconnectInterval):
+			}
+			c = l.pool.Get()
+			// Entries may have been missed, wake up in any case
+			l.notify()
+			continue
+		}
+
+		for _, stream := range reply {
+			values, err := redis.Values(stream, nil)
+			if err != nil || len(values) != 2 {
+				continue
+			}
+			entries, err := parseStreamEntries(values[1])
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			lastID = entries[len(entries)-1].ID
+		}
+
+		l.notify()
+	}
+}
+
+func (l *redisJobListener) notify() {
+	select {
+	case l.notifications <- struct{}{}:
+	default:
+	}
+}
+
+func (l *redisJobListener) Notifications() <-chan struct{} {
+	return l.notifications
+}
+
+// Close stops listening. A pending read on the stream is not interrupted but
+// ignored.
+func (l *redisJobListener) Close() error {
+	close(l.done)
+	return nil
+}
+
+// wakeScheduler makes the DB scheduler claim jobs without waiting for the
+// next poll.
+func (wp *WorkerPoolImpl) wakeScheduler() {
+	select {
+	case wp.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextDueAt returns when the next job becomes schedulable, nil if unknown.
+func (wp *WorkerPoolImpl) nextDueAt() *time.Time {
+	next, err := wp.store.NextDueAt(time.Now())
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not get next due job from DB")
+		return nil
+	}
+	return next
+}
+
+// claimJobs accepts as many schedulable jobs as there are idle workers and
+// enqueues them. Jobs that can not be enqueued after all are released back
+// for other instances.
+func (wp *WorkerPoolImpl) claimJobs() {
+	entry := wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.claimJobs",
+	})
+
+	idle := wp.queue.idle(int(wp.workerCount))
+	if idle <= 0 {
+		// Claim once a worker is done
+		atomic.StoreInt32(&wp.claimBacklog, 1)
+		return
+	}
+
+	claims, err := wp.store.ClaimJobs(wp.acceptedGracePeriod, wp.instanceID, wp.lease(), idle)
+	if err != nil {
+		entry.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not claim schedulable jobs from DB")
+		return
+	}
+
+	if len(claims) == idle {
+		// There may be more jobs waiting
+		atomic.StoreInt32(&wp.claimBacklog, 1)
+	}
+
+	jobs := make([]Job, len(claims))
+	for i := range claims {
+		jobs[i] = claims[i].Job
+		wp.recordTransition(&claims[i].Job, claims[i].From, "")
+	}
+
+	// Enqueue higher priority jobs first in case the queue fills up
+	wp.queue.sortByPriority(jobs)
+
+	for i := range jobs {
+		job := &jobs[i]
+		job.claimed = true
+
+		if wp.tryEnqueue(job, false) {
+			continue
+		}
+
+		job.State = NoAvailableWorkers
+		job.NextRunAt = time.Now()
+
+		if err := wp.store.ReleaseJob(job, wp.instanceID); err != nil {
+			job.logEntry(entry).
+				WithFields(log.Fields{"error": err}).
+				Warn("Could not release claimed job")
+			continue
+		}
+
+		wp.recordTransition(job, Accepted, "")
+	}
+}
@@ -3,21 +3,68 @@ package jobs
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const SendJobStatusJobType = "send_job_status"
 
+const (
+	// WebhookTimestampHeader contains the unix timestamp (seconds) of a
+	// webhook request.
+	WebhookTimestampHeader = "X-Flow-Wallet-Timestamp"
+	// WebhookSignatureHeader contains the HMAC-SHA256 signature of a webhook
+	// request, see SignWebhookPayload.
+	WebhookSignatureHeader = "X-Flow-Wallet-Signature"
+)
+
 type NotificationConfig struct {
 	jobStatusWebhookUrl     *url.URL
 	jobStatusWebhookTimeout time.Duration
+	jobStatusWebhookSecret  string
+	// If true, jobs with a callback url are not delivered to the global
+	// job status webhook
+	callbackReplacesJobStatusWebhook bool
+}
+
+// notificationTarget is stored in the Attributes of a send_job_status job
+// and defines where the job status is delivered. A target without a
+// subscription or a callback url is delivered to the global job status
+// webhook.
+type notificationTarget struct {
+	ParentJobID    uuid.UUID  `json:"parentJobId"`
+	SubscriptionID *uuid.UUID `json:"subscriptionId,omitempty"`
+	CallbackURL    string     `json:"callbackUrl,omitempty"`
 }
 
 func (cfg *NotificationConfig) ShouldSendJobStatus() bool {
-	return cfg.jobStatusWebhookUrl != nil
+	return cfg != nil && cfg.jobStatusWebhookUrl != nil
+}
+
+func (cfg *NotificationConfig) callbackReplacesWebhook() bool {
+	return cfg != nil && cfg.callbackReplacesJobStatusWebhook
+}
+
+func (cfg *NotificationConfig) secret() string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.jobStatusWebhookSecret
+}
+
+func (cfg *NotificationConfig) timeout() time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	return cfg.jobStatusWebhookTimeout
 }
 
 func (cfg *NotificationConfig) SendJobStatus(ctx context.Context, content string) error {
@@ -35,30 +82,61 @@ func (cfg *NotificationConfig) SendJobStatus(ctx context.Context, content string
 }
 
 func (cfg *NotificationConfig) SendJobStatusWebhook(ctx context.Context, content string) error {
-	if cfg.jobStatusWebhookUrl == nil {
+	_, err := cfg.sendJobStatusWebhook(ctx, content)
+	return err
+}
+
+func (cfg *NotificationConfig) sendJobStatusWebhook(ctx context.Context, content string) (int, error) {
+	if !cfg.ShouldSendJobStatus() {
 		// Do nothing as config has no 'jobStatusWebhookUrl'
-		return nil
+		return 0, nil
 	}
 
+	return sendWebhook(ctx, cfg.jobStatusWebhookUrl.String(), cfg.jobStatusWebhookTimeout, cfg.jobStatusWebhookSecret, content)
+}
+
+// SignWebhookPayload returns the signature of a webhook request body sent at
+// the given unix timestamp: "sha256=" followed by the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" using the secret as key.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook POSTs content to url u. The request is signed if secret is not
+// empty. Returns the response status code, if any.
+func sendWebhook(ctx context.Context, u string, timeout time.Duration, secret, content string) (int, error) {
 	client := http.Client{
-		Timeout: cfg.jobStatusWebhookTimeout,
+		Timeout: timeout,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", cfg.jobStatusWebhookUrl.String(), bytes.NewBuffer([]byte(content)))
+	body := []byte(content)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("error while creating webhook request: %w", err)
+		return 0, fmt.Errorf("error while creating webhook request: %w", err)
 	}
 
+	timestamp := time.Now().Unix()
+
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+
+	if secret != "" {
+		req.Header.Add(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, body))
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("error while sending webhook request: %w", err)
+		return 0, fmt.Errorf("error while sending webhook request: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("webhook endpoint responded with an unexpected status code: %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with an unexpected status code: %d", resp.StatusCode)
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
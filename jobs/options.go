@@ -1,6 +1,8 @@
 package jobs
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"time"
 
@@ -14,6 +16,13 @@ type JobOption func(*Job)
 
 func WithJobStatusWebhook(u string, timeout time.Duration) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
+		if wp.notificationConfig == nil {
+			wp.notificationConfig = &NotificationConfig{}
+		}
+
+		// The timeout also applies to webhook subscriptions
+		wp.notificationConfig.jobStatusWebhookTimeout = timeout
+
 		if u == "" {
 			return
 		}
@@ -23,12 +32,32 @@ func WithJobStatusWebhook(u string, timeout time.Duration) WorkerPoolOption {
 			panic("invalid job status webhook url")
 		}
 
+		wp.notificationConfig.jobStatusWebhookUrl = valid
+	}
+}
+
+// WithJobStatusWebhookSecret sets the secret used to sign requests to the
+// job status webhook.
+func WithJobStatusWebhookSecret(secret string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
 		if wp.notificationConfig == nil {
 			wp.notificationConfig = &NotificationConfig{}
 		}
 
-		wp.notificationConfig.jobStatusWebhookUrl = valid
-		wp.notificationConfig.jobStatusWebhookTimeout = timeout
+		wp.notificationConfig.jobStatusWebhookSecret = secret
+	}
+}
+
+// WithCallbackReplacesJobStatusWebhook sets whether the status of a job with
+// a callback url is delivered only to the callback url (true) or also to the
+// global job status webhook (false).
+func WithCallbackReplacesJobStatusWebhook(replaces bool) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.notificationConfig == nil {
+			wp.notificationConfig = &NotificationConfig{}
+		}
+
+		wp.notificationConfig.callbackReplacesJobStatusWebhook = replaces
 	}
 }
 
@@ -44,6 +73,13 @@ func WithLogger(logger *log.Logger) WorkerPoolOption {
 	}
 }
 
+// WithInstanceID sets the ID used to identify this instance in job events.
+func WithInstanceID(id string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.instanceID = id
+	}
+}
+
 func WithMaxJobErrorCount(count int) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
 		wp.maxJobErrorCount = count
@@ -68,8 +104,227 @@ func WithReSchedulableGracePeriod(d time.Duration) WorkerPoolOption {
 	}
 }
 
+// WithJobLeaseDuration sets the duration of the lease a worker holds on a job
+// it is executing. The lease is renewed every third of its duration; another
+// instance may take over the job once the lease has expired.
+func WithJobLeaseDuration(d time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.leaseDuration = d
+	}
+}
+
+// WithJobRetryBackoff sets the default backoff for re-scheduling jobs in
+// ERROR state.
+func WithJobRetryBackoff(min, max time.Duration, factor float64) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.retryBackoff = RetryBackoff{Min: min, Max: max, Factor: factor}
+	}
+}
+
+// WithJobTypeRetryBackoffs sets per job type backoffs for re-scheduling jobs
+// in ERROR state. See ParseJobTypeRetryBackoff for the format.
+func WithJobTypeRetryBackoffs(specs []string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.jobTypeRetryBackoffs == nil {
+			wp.jobTypeRetryBackoffs = make(map[string]RetryBackoff)
+		}
+
+		for _, spec := range specs {
+			jobType, b, err := ParseJobTypeRetryBackoff(spec)
+			if err != nil {
+				panic(err)
+			}
+			wp.jobTypeRetryBackoffs[jobType] = b
+		}
+	}
+}
+
+// WithJobTypePriorities sets the priority of job types, given as
+// "<jobType>:<priority>". Jobs of types with a higher priority are executed
+// first. Panics on an invalid definition.
+func WithJobTypePriorities(specs []string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.jobTypePriorities == nil {
+			wp.jobTypePriorities = make(map[string]int)
+		}
+
+		for _, spec := range specs {
+			jobType, priority, err := ParseJobTypePriority(spec)
+			if err != nil {
+				panic(err)
+			}
+			wp.jobTypePriorities[jobType] = priority
+		}
+	}
+}
+
+// WithJobExecutionTimeout sets the execution timeout of job types whose
+// executor was registered without a timeout.
+func WithJobExecutionTimeout(d time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if d > 0 {
+			wp.defaultExecutionTimeout = d
+		}
+	}
+}
+
+// WithJobTypeExecutionTimeouts sets the execution timeout of job types, given
+// as "<jobType>:<timeout>", overriding the timeout the executor was registered
+// with. Panics on an invalid definition.
+func WithJobTypeExecutionTimeouts(specs []string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.jobTypeTimeouts == nil {
+			wp.jobTypeTimeouts = make(map[string]time.Duration)
+		}
+
+		for _, spec := range specs {
+			jobType, timeout, err := ParseJobTypeExecutionTimeout(spec)
+			if err != nil {
+				panic(err)
+			}
+			wp.jobTypeTimeouts[jobType] = timeout
+		}
+	}
+}
+
+// WithJobTypeConcurrencyLimits sets the maximum number of concurrent
+// executions of job types, given as "<jobType>:<limit>". Panics on an invalid
+// definition.
+func WithJobTypeConcurrencyLimits(specs []string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.jobTypeConcurrency == nil {
+			wp.jobTypeConcurrency = make(map[string]int)
+		}
+
+		for _, spec := range specs {
+			jobType, limit, err := ParseJobTypeConcurrency(spec)
+			if err != nil {
+				panic(err)
+			}
+			wp.jobTypeConcurrency[jobType] = limit
+		}
+	}
+}
+
+// WithJobRetentionPolicies sets how long jobs in a terminal state are kept,
+// given as "<jobType>:<state>:<maxAge>". See ParseRetentionPolicy for the
+// format. Jobs are only pruned if at least one policy is set. Panics on an
+// invalid policy.
+func WithJobRetentionPolicies(specs []string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		for _, spec := range specs {
+			p, err := ParseRetentionPolicy(spec)
+			if err != nil {
+				panic(err)
+			}
+			wp.retentionPolicies = append(wp.retentionPolicies, p)
+		}
+	}
+}
+
+// WithJobArchive sets what happens to pruned jobs: JobArchiveNone deletes
+// them, JobArchiveTable moves them to the `jobs_archive` table and
+// JobArchiveFile appends them to the NDJSON file at path. Panics on an
+// invalid mode.
+func WithJobArchive(mode, path string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		switch mode {
+		case "", JobArchiveNone:
+			wp.archiveMode = JobArchiveNone
+		case JobArchiveTable:
+			wp.archiveMode = JobArchiveTable
+		case JobArchiveFile:
+			if path == "" {
+				panic("job archive file path is required")
+			}
+			wp.archiveMode = JobArchiveFile
+		default:
+			panic(fmt.Sprintf("invalid job archive mode %q, expected one of none, table, file", mode))
+		}
+
+		wp.archiveFile = path
+	}
+}
+
+// WithJobPruning sets how often jobs are pruned and how many jobs are pruned
+// per database transaction.
+func WithJobPruning(interval time.Duration, batchSize int) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if interval > 0 {
+			wp.pruneInterval = interval
+		}
+		if batchSize > 0 {
+			wp.pruneBatchSize = batchSize
+		}
+	}
+}
+
+// WithJobListener enables push based scheduling: schedulable jobs are claimed
+// from the database as soon as l announces them and whenever a worker
+// becomes available, in addition to polling every DB job poll interval.
+func WithJobListener(l JobListener) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.jobListener = l
+	}
+}
+
+// WithJobBackpressure sets the high-water marks for the number of jobs in the
+// in-memory queue and outstanding jobs in the database at which
+// CheckBackpressure reports backpressure, asking clients to retry after
+// retryAfter. A high-water mark of 0 disables the check.
+func WithJobBackpressure(queueHighWaterMark, backlogHighWaterMark int, retryAfter time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if queueHighWaterMark < 0 || backlogHighWaterMark < 0 {
+			panic("invalid job backpressure high-water mark")
+		}
+		wp.queueHighWaterMark = queueHighWaterMark
+		wp.backlogHighWaterMark = backlogHighWaterMark
+		if retryAfter > 0 {
+			wp.backpressureRetryAfter = retryAfter
+		}
+	}
+}
+
 func WithAttributes(attributes datatypes.JSON) JobOption {
 	return func(job *Job) {
 		job.Attributes = attributes
 	}
 }
+
+// WithRunAt defers the first execution of the job until time t. A job with a
+// run time in the future is created in SCHEDULED state and picked up by the
+// DB scheduler once due.
+func WithRunAt(t time.Time) JobOption {
+	return func(job *Job) {
+		if !t.After(time.Now()) {
+			return
+		}
+
+		job.State = Scheduled
+		job.NextRunAt = t
+	}
+}
+
+// setAttribute sets key to value v in the job's attributes object. Existing
+// attributes are kept.
+func setAttribute(job *Job, key string, v interface{}) {
+	attrs := make(map[string]json.RawMessage)
+	if len(job.Attributes) > 0 && string(job.Attributes) != "null" {
+		if err := json.Unmarshal(job.Attributes, &attrs); err != nil {
+			log.
+				WithFields(log.Fields{"error": err, "jobType": job.Type, "attribute": key}).
+				Warn("Could not add attribute to job attributes")
+			return
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	attrs[key] = b
+
+	if b, err := json.Marshal(attrs); err == nil {
+		job.Attributes = b
+	}
+}
@@ -0,0 +1,314 @@
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// jobQueue is the in-memory queue of jobs waiting for a worker.
+//
+// Jobs are handed out by the priority of their job type, oldest first within
+// the same priority. A job type with a concurrency limit has at most `limit`
+// jobs executing and at most `limit` jobs waiting in the queue at a time,
+// further jobs of that type are left for the database scheduler.
+type jobQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	capacity int
+	size     int
+	seq      uint64
+	waiting  int // Number of workers waiting for a job
+	busy     int // Number of jobs being executed
+	closed   bool
+
+	lanes   map[string][]queuedJob // Per job type, oldest first
+	running map[string]int
+
+	priorities map[string]int
+	limits     map[string]int
+}
+
+type queuedJob struct {
+	job *Job
+	seq uint64
+}
+
+// JobTypeQueueStatus is the in-memory queue status of a job type.
+type JobTypeQueueStatus struct {
+	Priority       int `json:"priority"`
+	MaxConcurrency int `json:"maxConcurrency"` // 0 means unlimited
+	Queued         int `json:"queued"`
+	Running        int `json:"running"`
+}
+
+func newJobQueue(capacity uint, priorities, limits map[string]int) *jobQueue {
+	q := &jobQueue{
+		capacity:   int(capacity),
+		lanes:      make(map[string][]queuedJob),
+		running:    make(map[string]int),
+		priorities: priorities,
+		limits:     limits,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a job to the queue. If block is true, push waits for room in the
+// queue. Returns false if the job was not queued.
+func (q *jobQueue) push(j *Job, block bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.closed {
+			return false
+		}
+
+		if limit, ok := q.limits[j.Type]; ok && len(q.lanes[j.Type]) >= limit {
+			// Do not let a single job type fill the queue
+			return false
+		}
+
+		// Jobs taken by waiting workers do not use up capacity
+		if q.size-q.waiting < q.capacity {
+			break
+		}
+
+		if !block {
+			return false
+		}
+
+		q.cond.Wait()
+	}
+
+	q.seq++
+	q.lanes[j.Type] = append(q.lanes[j.Type], queuedJob{job: j, seq: q.seq})
+	q.size++
+
+	q.cond.Broadcast()
+
+	return true
+}
+
+// pop waits for the next job that can be executed and marks it running.
+// Returns nil once the queue is closed and has no jobs left.
+func (q *jobQueue) pop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if j := q.next(); j != nil {
+			return j
+		}
+
+		if q.closed && q.size == 0 {
+			return nil
+		}
+
+		q.waiting++
+		q.cond.Wait()
+		q.waiting--
+	}
+}
+
+// next removes and returns the highest priority job whose type is below its
+// concurrency limit. Must be called with q.mu held.
+func (q *jobQueue) next() *Job {
+	var (
+		best     string
+		bestSeq  uint64
+		bestPrio int
+		found    bool
+	)
+
+	for jobType, lane := range q.lanes {
+		if len(lane) == 0 {
+			continue
+		}
+
+		if limit, ok := q.limits[jobType]; ok && q.running[jobType] >= limit {
+			continue
+		}
+
+		prio := q.priorities[jobType]
+		if !found || prio > bestPrio || (prio == bestPrio && lane[0].seq < bestSeq) {
+			best, bestSeq, bestPrio, found = jobType, lane[0].seq, prio, true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	j := q.lanes[best][0].job
+	q.lanes[best] = q.lanes[best][1:]
+	if len(q.lanes[best]) == 0 {
+		delete(q.lanes, best)
+	}
+	q.size--
+	q.busy++
+	q.running[best]++
+
+	// Room for pushers
+	q.cond.Broadcast()
+
+	return j
+}
+
+// done marks a job returned by pop as finished.
+func (q *jobQueue) done(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.busy--
+	q.running[j.Type]--
+	if q.running[j.Type] <= 0 {
+		delete(q.running, j.Type)
+	}
+
+	// A job of this type may now be executed
+	q.cond.Broadcast()
+}
+
+// idle returns the number of the given workers that are neither executing a
+// job nor have a job queued for them.
+func (q *jobQueue) idle(workers int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || workers <= q.busy+q.size {
+		return 0
+	}
+	return workers - q.busy - q.size
+}
+
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// close stops accepting jobs. Queued jobs are still handed out.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// drain closes the queue and removes and returns the jobs waiting in it,
+// oldest first. Jobs being executed are not affected.
+func (q *jobQueue) drain() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var queued []queuedJob
+	for _, lane := range q.lanes {
+		queued = append(queued, lane...)
+	}
+
+	sort.Slice(queued, func(a, b int) bool {
+		return queued[a].seq < queued[b].seq
+	})
+
+	jj := make([]*Job, len(queued))
+	for i := range queued {
+		jj[i] = queued[i].job
+	}
+
+	q.lanes = make(map[string][]queuedJob)
+	q.size = 0
+	q.closed = true
+	q.cond.Broadcast()
+
+	return jj
+}
+
+// status returns the queue status of each configured or active job type.
+func (q *jobQueue) status() map[string]JobTypeQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := make(map[string]JobTypeQueueStatus)
+
+	get := func(jobType string) JobTypeQueueStatus {
+		s, ok := res[jobType]
+		if !ok {
+			s.Priority = q.priorities[jobType]
+			s.MaxConcurrency = q.limits[jobType]
+		}
+		return s
+	}
+
+	for jobType := range q.priorities {
+		res[jobType] = get(jobType)
+	}
+
+	for jobType := range q.limits {
+		res[jobType] = get(jobType)
+	}
+
+	for jobType, lane := range q.lanes {
+		s := get(jobType)
+		s.Queued = len(lane)
+		res[jobType] = s
+	}
+
+	for jobType, n := range q.running {
+		s := get(jobType)
+		s.Running = n
+		res[jobType] = s
+	}
+
+	return res
+}
+
+// sortByPriority orders jobs by the priority of their type, highest first.
+// The order of jobs with the same priority is kept.
+func (q *jobQueue) sortByPriority(jj []Job) {
+	sort.SliceStable(jj, func(a, b int) bool {
+		return q.priorities[jj[a].Type] > q.priorities[jj[b].Type]
+	})
+}
+
+// ParseJobTypePriority parses a per job type priority definition in the
+// format "<jobType>:<priority>", e.g. "withdrawal_create:10". Jobs of types
+// with a higher priority are executed first, the default priority is 0.
+func ParseJobTypePriority(s string) (string, int, error) {
+	return parseJobTypeInt(s, "priority")
+}
+
+// ParseJobTypeConcurrency parses a per job type concurrency limit in the
+// format "<jobType>:<limit>", e.g. "send_job_status:2".
+func ParseJobTypeConcurrency(s string) (string, int, error) {
+	jobType, v, err := parseJobTypeInt(s, "concurrency limit")
+	if err != nil {
+		return "", 0, err
+	}
+	if v < 1 {
+		return "", 0, fmt.Errorf("invalid job concurrency limit %q, expected limit >= 1", s)
+	}
+	return jobType, v, nil
+}
+
+func parseJobTypeInt(s, name string) (string, int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid job %s %q, expected <jobType>:<value>", name, s)
+	}
+
+	jobType := strings.TrimSpace(parts[0])
+	if jobType == "" {
+		return "", 0, fmt.Errorf("invalid job %s %q, empty job type", name, s)
+	}
+
+	v, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid job %s %q: %w", name, s, err)
+	}
+
+	return jobType, v, nil
+}
@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+)
+
+// Job archive modes, see WithJobArchive.
+const (
+	JobArchiveNone  = "none"  // Pruned jobs are deleted
+	JobArchiveTable = "table" // Pruned jobs are moved to the `jobs_archive` table
+	JobArchiveFile  = "file"  // Pruned jobs are appended to an NDJSON file
+)
+
+const (
+	defaultJobPruneInterval  = 1 * time.Hour
+	defaultJobPruneBatchSize = 500
+)
+
+// RetentionPolicy defines how long jobs of a type in a terminal state are
+// kept. An empty JobType or State matches all job types or terminal states.
+type RetentionPolicy struct {
+	JobType string
+	State   State
+	MaxAge  time.Duration
+}
+
+// ParseRetentionPolicy parses a job retention policy in the format
+// "<jobType>:<state>:<maxAge>", e.g. "send_job_status:COMPLETE:24h". Job type
+// and state can be "*" to match all job types or terminal states. Only
+// terminal states (COMPLETE, FAILED, CANCELLED) can be pruned.
+func ParseRetentionPolicy(s string) (RetentionPolicy, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return RetentionPolicy{}, fmt.Errorf("invalid job retention policy %q, expected <jobType>:<state>:<maxAge>", s)
+	}
+
+	var p RetentionPolicy
+
+	if jobType := strings.TrimSpace(parts[0]); jobType != "*" {
+		if jobType == "" {
+			return RetentionPolicy{}, fmt.Errorf("invalid job retention policy %q, empty job type", s)
+		}
+		p.JobType = jobType
+	}
+
+	if state := strings.ToUpper(strings.TrimSpace(parts[1])); state != "*" {
+		p.State = State(state)
+		if !p.State.Terminal() {
+			return RetentionPolicy{}, fmt.Errorf("invalid job retention policy %q, expected one of COMPLETE, FAILED, CANCELLED or *", s)
+		}
+	}
+
+	maxAge, err := time.ParseDuration(strings.TrimSpace(parts[2]))
+	if err != nil || maxAge <= 0 {
+		return RetentionPolicy{}, fmt.Errorf("invalid job retention policy %q, expected a positive max age", s)
+	}
+	p.MaxAge = maxAge
+
+	return p, nil
+}
+
+// covers returns true if policy p applies to everything policy q applies to.
+func (p RetentionPolicy) covers(q RetentionPolicy) bool {
+	return (p.JobType == "" || p.JobType == q.JobType) && (p.State == "" || p.State == q.State)
+}
+
+// PruneFilter selects jobs to prune.
+type PruneFilter struct {
+	JobType       string // Empty matches all job types
+	States        []State
+	Exclude       []RetentionPolicy // Jobs matching a more specific policy
+	UpdatedBefore time.Time
+}
+
+// pruneFilters resolves retention policies to prune filters. A job is
+// pruned according to the most specific policy matching it: a policy for a
+// job type overrides a policy for all job types and a policy for a state
+// overrides a policy for all states.
+func pruneFilters(policies []RetentionPolicy, now time.Time) []PruneFilter {
+	filters := make([]PruneFilter, 0, len(policies))
+
+	for _, p := range policies {
+		f := PruneFilter{
+			JobType:       p.JobType,
+			States:        []State{p.State},
+			UpdatedBefore: now.Add(-p.MaxAge),
+		}
+
+		if p.State == "" {
+			f.States = []State{Complete, Failed, Cancelled}
+		}
+
+		for _, q := range policies {
+			if q != p && p.covers(q) && !q.covers(p) {
+				f.Exclude = append(f.Exclude, q)
+			}
+		}
+
+		filters = append(filters, f)
+	}
+
+	return filters
+}
+
+// ArchivedJob is a pruned job in the `jobs_archive` table or the archive file.
+type ArchivedJob struct {
+	ID            uuid.UUID      `gorm:"column:id;primary_key;type:uuid;" json:"jobId"`
+	Type          string         `gorm:"column:type;index" json:"type"`
+	State         State          `gorm:"column:state" json:"state"`
+	Error         string         `gorm:"column:error" json:"error"`
+	Errors        pq.StringArray `gorm:"column:errors;type:text[]" json:"errors"`
+	Result        string         `gorm:"column:result" json:"result"`
+	TransactionID string         `gorm:"column:transaction_id;index" json:"transactionId"`
+	ExecCount     int            `gorm:"column:exec_count" json:"execCount"`
+	Attributes    datatypes.JSON `gorm:"column:attributes" json:"attributes"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at" json:"updatedAt"`
+	ArchivedAt    time.Time      `gorm:"column:archived_at;index" json:"archivedAt"`
+}
+
+func (ArchivedJob) TableName() string {
+	return "jobs_archive"
+}
+
+// NewArchivedJob returns the archived copy of job j.
+func NewArchivedJob(j Job, archivedAt time.Time) ArchivedJob {
+	return ArchivedJob{
+		ID:            j.ID,
+		Type:          j.Type,
+		State:         j.State,
+		Error:         j.Error,
+		Errors:        j.Errors,
+		Result:        j.Result,
+		TransactionID: j.TransactionID,
+		ExecCount:     j.ExecCount,
+		Attributes:    j.Attributes,
+		CreatedAt:     j.CreatedAt,
+		UpdatedAt:     j.UpdatedAt,
+		ArchivedAt:    archivedAt,
+	}
+}
+
+// appendArchiveFile appends jobs to the NDJSON archive file at path.
+func appendArchiveFile(path string, jj []Job) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error while opening job archive file: %w", err)
+	}
+
+	now := time.Now()
+	enc := json.NewEncoder(f)
+
+	for _, j := range jj {
+		if err := enc.Encode(NewArchivedJob(j, now)); err != nil {
+			f.Close()
+			return fmt.Errorf("error while writing job archive file: %w", err)
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("error while writing job archive file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// Prune removes jobs that have outlived their retention policy, archiving
+// them according to the archive mode. Returns the number of pruned jobs.
+func (wp *WorkerPoolImpl) Prune() (int, error) {
+	total := 0
+
+	for _, f := range pruneFilters(wp.retentionPolicies, time.Now()) {
+		for {
+			select {
+			case <-wp.stopChan:
+				return total, nil
+			default:
+			}
+
+			var (
+				n   int
+				err error
+			)
+
+			switch wp.archiveMode {
+			case JobArchiveTable:
+				n, err = wp.store.ArchiveJobs(f, wp.pruneBatchSize)
+			case JobArchiveFile:
+				path := wp.archiveFile
+				n, err = wp.store.DeleteJobs(f, wp.pruneBatchSize, func(jj []Job) error {
+					return appendArchiveFile(path, jj)
+				})
+			default:
+				n, err = wp.store.DeleteJobs(f, wp.pruneBatchSize, nil)
+			}
+
+			total += n
+
+			if err != nil {
+				return total, err
+			}
+
+			if n < wp.pruneBatchSize {
+				break
+			}
+		}
+	}
+
+	return total, nil
+}
+
+func (wp *WorkerPoolImpl) startPruner() {
+	if len(wp.retentionPolicies) == 0 {
+		return
+	}
+
+	go func() {
+		for {
+			select {
+			case <-time.After(wp.pruneInterval):
+			case <-wp.stopChan:
+				return
+			}
+
+			begin := time.Now()
+
+			n, err := wp.Prune()
+
+			entry := wp.logger.WithFields(log.Fields{
+				"package":  "jobs",
+				"function": "WorkerPool.startPruner.goroutine",
+				"pruned":   n,
+				"elapsed":  time.Since(begin),
+			})
+
+			if err != nil {
+				entry.
+					WithFields(log.Fields{"error": err}).
+					Warn("Could not prune jobs")
+				continue
+			}
+
+			if n > 0 {
+				entry.Info("Pruned jobs")
+			}
+		}
+	}()
+}
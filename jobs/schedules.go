@@ -0,0 +1,345 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	wallet_errors "github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Key of the schedule id in the attributes of jobs created by a schedule.
+const scheduleIDAttribute = "scheduleId"
+
+// ErrScheduleAlreadyRun is returned when a due run of a job schedule has
+// already been materialized, possibly by another instance.
+var ErrScheduleAlreadyRun = errors.New("job schedule already run")
+
+// JobSchedule is a recurring job definition. A job of type JobType with the
+// given attributes is created every time the cron expression matches.
+type JobSchedule struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Name       string         `gorm:"column:name"`
+	Cron       string         `gorm:"column:cron"`
+	Timezone   string         `gorm:"column:timezone"`
+	JobType    string         `gorm:"column:job_type"`
+	Attributes datatypes.JSON `gorm:"column:attributes"`
+	Enabled    bool           `gorm:"column:enabled;index:idx_job_schedules_enabled_next_run_at"`
+	NextRunAt  time.Time      `gorm:"column:next_run_at;index:idx_job_schedules_enabled_next_run_at"`
+	RunCount   int            `gorm:"column:run_count;default:0"` // Identifies a run, see Store.MaterializeJobSchedule
+	LastRunAt  *time.Time     `gorm:"column:last_run_at"`
+	LastJobID  *uuid.UUID     `gorm:"column:last_job_id;type:uuid"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+func (s *JobSchedule) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// CronSchedule parses the cron expression and timezone of the schedule.
+func (s JobSchedule) CronSchedule() (CronSchedule, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return CronSchedule{}, fmt.Errorf("invalid timezone %q", s.Timezone)
+	}
+	return ParseCronSchedule(s.Cron, loc)
+}
+
+// Job schedule HTTP request
+type JobScheduleJSONRequest struct {
+	Name       string          `json:"name"`
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone"`
+	JobType    string          `json:"jobType"`
+	Attributes json.RawMessage `json:"attributes"`
+	Enabled    *bool           `json:"enabled"`
+}
+
+// Job schedule HTTP response
+type JobScheduleJSONResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	Name       string          `json:"name"`
+	Cron       string          `json:"cron"`
+	Timezone   string          `json:"timezone"`
+	JobType    string          `json:"jobType"`
+	Attributes json.RawMessage `json:"attributes"`
+	Enabled    bool            `json:"enabled"`
+	NextRunAt  *time.Time      `json:"nextRunAt"` // Null if disabled
+	LastRunAt  *time.Time      `json:"lastRunAt"`
+	LastJobID  *uuid.UUID      `json:"lastJobId"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+func (s JobSchedule) ToJSONResponse() JobScheduleJSONResponse {
+	res := JobScheduleJSONResponse{
+		ID:         s.ID,
+		Name:       s.Name,
+		Cron:       s.Cron,
+		Timezone:   s.Timezone,
+		JobType:    s.JobType,
+		Attributes: json.RawMessage(s.Attributes),
+		Enabled:    s.Enabled,
+		LastRunAt:  s.LastRunAt,
+		LastJobID:  s.LastJobID,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+
+	if s.Enabled && !s.NextRunAt.IsZero() {
+		t := s.NextRunAt
+		res.NextRunAt = &t
+	}
+
+	return res
+}
+
+type ScheduleService interface {
+	List() ([]JobSchedule, error)
+	Create(req JobScheduleJSONRequest) (*JobSchedule, error)
+	Details(id string) (*JobSchedule, error)
+	Update(id string, req JobScheduleJSONRequest) (*JobSchedule, error)
+	Delete(id string) error
+}
+
+// ScheduleServiceImpl defines the API for job schedule HTTP handlers.
+type ScheduleServiceImpl struct {
+	store Store
+	wp    WorkerPool
+}
+
+// NewScheduleService initiates a new job schedule service.
+func NewScheduleService(store Store, wp WorkerPool) ScheduleService {
+	return &ScheduleServiceImpl{store, wp}
+}
+
+// List returns all job schedules.
+func (s *ScheduleServiceImpl) List() ([]JobSchedule, error) {
+	log.Trace("List job schedules")
+	return s.store.JobSchedules()
+}
+
+// Create adds a new job schedule. The first job is created at the next time
+// matching the cron expression.
+func (s *ScheduleServiceImpl) Create(req JobScheduleJSONRequest) (*JobSchedule, error) {
+	log.WithFields(log.Fields{"cron": req.Cron, "jobType": req.JobType}).Trace("Create job schedule")
+
+	sch := JobSchedule{Enabled: true}
+
+	if err := s.apply(&sch, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.InsertJobSchedule(&sch); err != nil {
+		return nil, err
+	}
+
+	return &sch, nil
+}
+
+// Details returns a specific job schedule.
+func (s *ScheduleServiceImpl) Details(id string) (*JobSchedule, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Job schedule details")
+
+	scheduleID, err := uuid.Parse(id)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &wallet_errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid schedule id"),
+		}
+		return nil, err
+	}
+
+	sch, err := s.store.JobSchedule(scheduleID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &wallet_errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("schedule not found"),
+			}
+		}
+		return nil, err
+	}
+
+	return &sch, nil
+}
+
+// Update replaces a job schedule. The next run is calculated from the
+// current time.
+func (s *ScheduleServiceImpl) Update(id string, req JobScheduleJSONRequest) (*JobSchedule, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Update job schedule")
+
+	sch, err := s.Details(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(sch, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateJobSchedule(sch); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+// Delete removes a job schedule. Jobs already created by it are not
+// affected.
+func (s *ScheduleServiceImpl) Delete(id string) error {
+	log.WithFields(log.Fields{"id": id}).Trace("Delete job schedule")
+
+	sch, err := s.Details(id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteJobSchedule(sch.ID)
+}
+
+func (s *ScheduleServiceImpl) apply(sch *JobSchedule, req JobScheduleJSONRequest) error {
+	badRequest := func(err error) error {
+		return &wallet_errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	jobType := strings.TrimSpace(req.JobType)
+	if jobType == "" {
+		return badRequest(fmt.Errorf("jobType is required"))
+	}
+
+	if !s.wp.HasExecutor(jobType) {
+		return badRequest(fmt.Errorf("invalid job type: %s", jobType))
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	var attributes datatypes.JSON
+	if len(req.Attributes) > 0 && string(req.Attributes) != "null" {
+		// Attributes must be an object as the schedule id is added to them
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(req.Attributes, &obj); err != nil {
+			return badRequest(fmt.Errorf("invalid attributes, expected a JSON object"))
+		}
+		attributes = datatypes.JSON(req.Attributes)
+	}
+
+	sch.Name = req.Name
+	sch.Cron = strings.TrimSpace(req.Cron)
+	sch.Timezone = timezone
+	sch.JobType = jobType
+	sch.Attributes = attributes
+
+	if req.Enabled != nil {
+		sch.Enabled = *req.Enabled
+	}
+
+	cron, err := sch.CronSchedule()
+	if err != nil {
+		return badRequest(err)
+	}
+
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		return badRequest(fmt.Errorf("cron expression %q never matches", sch.Cron))
+	}
+
+	sch.NextRunAt = next
+
+	return nil
+}
+
+// WithScheduleID stores the id of the schedule that created the job in the
+// job's attributes.
+func WithScheduleID(id uuid.UUID) JobOption {
+	return func(job *Job) {
+		setAttribute(job, scheduleIDAttribute, id)
+	}
+}
+
+// materializeSchedules creates a job for each job schedule that is due. A
+// due run is materialized exactly once, even with multiple instances polling
+// the same database. Runs missed while no instance was running are
+// collapsed into a single run.
+func (wp *WorkerPoolImpl) materializeSchedules() {
+	entry := wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.materializeSchedules",
+	})
+
+	now := time.Now()
+
+	schedules, err := wp.store.DueJobSchedules(now)
+	if err != nil {
+		entry.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not fetch due job schedules from DB")
+		return
+	}
+
+	for i := range schedules {
+		sch := &schedules[i]
+
+		schEntry := entry.WithFields(log.Fields{"scheduleID": sch.ID, "jobType": sch.JobType})
+
+		var next time.Time
+		if cron, err := sch.CronSchedule(); err != nil {
+			// Should have been validated, disable the schedule by never running it again
+			schEntry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Invalid job schedule")
+		} else {
+			next = cron.Next(now)
+		}
+
+		job := &Job{
+			State: Init,
+			Type:  sch.JobType,
+		}
+
+		WithAttributes(sch.Attributes)(job)
+		WithScheduleID(sch.ID)(job)
+
+		if err := wp.store.MaterializeJobSchedule(sch, next, job); err != nil {
+			if errors.Is(err, ErrScheduleAlreadyRun) {
+				continue
+			}
+			schEntry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Could not create job for job schedule")
+			continue
+		}
+
+		wp.recordTransition(job, "", "")
+
+		schEntry.WithFields(log.Fields{"jobID": job.ID}).Debug("Created job for job schedule")
+
+		if err := wp.Schedule(job); err != nil {
+			schEntry.
+				WithFields(log.Fields{"error": err, "jobID": job.ID}).
+				Warn("Could not schedule job created for job schedule")
+		}
+	}
+}
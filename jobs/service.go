@@ -1,8 +1,10 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
 	"github.com/flow-hydraulics/flow-wallet-api/errors"
@@ -11,32 +13,70 @@ import (
 )
 
 type Service interface {
-	List(limit, offset int) (*[]Job, error)
+	List(f Filter, limit, offset int) (*[]Job, string, error)
 	Details(jobID string) (*Job, error)
+	Cancel(jobID string) (*Job, error)
+	Retry(jobID string) (*Job, error)
+	RetryFailed(f RetryFilter) (*[]Job, error)
+	History(jobID string) (*[]Event, error)
+	Wait(ctx context.Context, jobID string, wait time.Duration) (*Job, error)
+	EventsAfter(afterID uint64, limit int) ([]Event, error)
+	EventsByID(ids []uint64) ([]Event, error)
+	LatestEventID() (uint64, error)
+	Transitions() <-chan struct{}
 }
 
+// MaxJobWait is the maximum duration Service.Wait blocks.
+const MaxJobWait = 60 * time.Second
+
+// Interval for re-reading a job while waiting, covers transitions made by
+// other instances.
+const jobWaitPollInterval = time.Second
+
 // ServiceImpl defines the API for job HTTP handlers.
 type ServiceImpl struct {
 	store Store
+	wp    WorkerPool
 }
 
 // NewService initiates a new job service.
-func NewService(store Store) Service {
-	return &ServiceImpl{store}
+func NewService(store Store, wp WorkerPool) Service {
+	return &ServiceImpl{store, wp}
 }
 
-// List returns all jobs in the datastore.
-func (s *ServiceImpl) List(limit, offset int) (*[]Job, error) {
-	log.WithFields(log.Fields{"limit": limit, "offset": offset}).Trace("List jobs")
+// List returns jobs in the datastore matching the filter, newest first.
+// A cursor for fetching the next page is returned if there are possibly
+// more matching jobs. A cursor can not be combined with an offset.
+func (s *ServiceImpl) List(f Filter, limit, offset int) (*[]Job, string, error) {
+	log.WithFields(log.Fields{"filter": f, "limit": limit, "offset": offset}).Trace("List jobs")
+
+	if f.Cursor != "" && offset > 0 {
+		return nil, "", &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("cursor can not be combined with offset"),
+		}
+	}
 
 	o := datastore.ParseListOptions(limit, offset)
 
-	jobs, err := s.store.Jobs(o)
+	jobs, err := s.store.Jobs(f, o)
 	if err != nil {
-		return nil, err
+		if err == ErrInvalidCursor {
+			// Convert error to a 400 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        err,
+			}
+		}
+		return nil, "", err
 	}
 
-	return &jobs, nil
+	var next string
+	if o.Limit > 0 && len(jobs) == o.Limit {
+		next = NewCursor(jobs[len(jobs)-1]).Encode()
+	}
+
+	return &jobs, next, nil
 }
 
 // Details returns a specific job.
@@ -66,3 +106,173 @@ func (s *ServiceImpl) Details(jobID string) (*Job, error) {
 
 	return &job, nil
 }
+
+// Cancel cancels a specific job.
+func (s *ServiceImpl) Cancel(jobID string) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID}).Trace("Cancel job")
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid job id"),
+		}
+		return nil, err
+	}
+
+	job, err := s.wp.Cancel(id)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("job not found"),
+			}
+		} else if err == ErrJobNotCancellable {
+			// Convert error to a 409 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        err,
+			}
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Retry retries a specific FAILED job.
+func (s *ServiceImpl) Retry(jobID string) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID}).Trace("Retry job")
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid job id"),
+		}
+		return nil, err
+	}
+
+	job, err := s.wp.Retry(id)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("job not found"),
+			}
+		} else if err == ErrJobNotRetryable {
+			// Convert error to a 409 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        err,
+			}
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// RetryFailed retries all FAILED jobs matching the filter.
+func (s *ServiceImpl) RetryFailed(f RetryFilter) (*[]Job, error) {
+	log.WithFields(log.Fields{"filter": f}).Trace("Retry failed jobs")
+
+	if f.FailedAfter != nil && f.FailedBefore != nil && !f.FailedAfter.Before(*f.FailedBefore) {
+		// Convert error to a 400 RequestError
+		err := &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("failedAfter must be before failedBefore"),
+		}
+		return nil, err
+	}
+
+	jobs, err := s.wp.RetryFailed(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobs, nil
+}
+
+// History returns the state transition history of a specific job.
+func (s *ServiceImpl) History(jobID string) (*[]Event, error) {
+	log.WithFields(log.Fields{"jobID": jobID}).Trace("Job history")
+
+	job, err := s.Details(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.store.Events(job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// Wait returns a specific job as soon as it reaches a terminal state
+// (COMPLETE, FAILED or CANCELLED) or the wait duration, capped at MaxJobWait,
+// has passed.
+func (s *ServiceImpl) Wait(ctx context.Context, jobID string, wait time.Duration) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID, "wait": wait}).Trace("Wait for job")
+
+	if wait > MaxJobWait {
+		wait = MaxJobWait
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	ticker := time.NewTicker(jobWaitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Get the transition channel before reading the job to not miss
+		// transitions in between
+		transition := s.wp.Transitions()
+
+		job, err := s.Details(jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if job.State.Terminal() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, nil
+		case <-transition:
+		case <-ticker.C:
+		}
+	}
+}
+
+// EventsAfter returns job state transitions of all jobs recorded after the
+// event with id `afterID`, oldest first.
+func (s *ServiceImpl) EventsAfter(afterID uint64, limit int) ([]Event, error) {
+	return s.store.EventsAfter(afterID, limit)
+}
+
+// EventsByID returns the job state transitions with the given ids which have
+// been recorded, oldest first.
+func (s *ServiceImpl) EventsByID(ids []uint64) ([]Event, error) {
+	return s.store.EventsByID(ids)
+}
+
+// LatestEventID returns the id of the latest recorded job state transition.
+func (s *ServiceImpl) LatestEventID() (uint64, error) {
+	return s.store.LatestEventID()
+}
+
+// Transitions returns a channel that is closed on the next job state
+// transition made by this instance.
+func (s *ServiceImpl) Transitions() <-chan struct{} {
+	return s.wp.Transitions()
+}
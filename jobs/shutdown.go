@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Shutdown gracefully stops the workerpool. No new jobs are accepted and jobs
+// waiting in the queue are released back to the database right away, so that
+// other instances can pick them up. Jobs being executed are given until ctx
+// is done to finish, after which their executors are cancelled and the jobs
+// released as well. Returns ctx.Err() if executions had to be cancelled.
+func (wp *WorkerPoolImpl) Shutdown(ctx context.Context) error {
+	entry := wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.Shutdown",
+	})
+
+	wp.closeStopChan()
+
+	queued := wp.queue.drain()
+	for _, job := range queued {
+		wp.releaseQueued(job)
+	}
+
+	entry.WithFields(log.Fields{"released": len(queued)}).Info("Draining workerpool")
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		wp.cancelContext()
+		return nil
+	case <-ctx.Done():
+	}
+
+	entry.Warn("Workerpool drain period exceeded, interrupting running jobs")
+
+	wp.cancelContext()
+	<-done
+
+	return ctx.Err()
+}
+
+// releaseQueued makes a job that was queued but never executed schedulable
+// again. Jobs claimed from the database are released, jobs scheduled by this
+// instance would otherwise wait in INIT state for the accepted grace period.
+// Other jobs came from the database and are still schedulable.
+func (wp *WorkerPoolImpl) releaseQueued(job *Job) {
+	entry := job.logEntry(wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.releaseQueued",
+	}))
+
+	from := job.State
+
+	switch {
+	case job.claimed:
+		job.claimed = false
+		job.State = NoAvailableWorkers
+		job.NextRunAt = time.Now()
+		if err := wp.store.ReleaseJob(job, wp.instanceID); err != nil {
+			entry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Could not release queued job")
+			return
+		}
+	case job.State == Init:
+		job.State = NoAvailableWorkers
+		job.NextRunAt = time.Now()
+		if err := wp.store.UpdateJob(job); err != nil {
+			entry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Could not release queued job")
+			return
+		}
+	default:
+		return
+	}
+
+	wp.recordTransition(job, from, "")
+}
+
+// releaseInterrupted makes a job whose execution was cancelled by Shutdown
+// schedulable again right away. The interrupted attempt is not counted as
+// an error.
+func (wp *WorkerPoolImpl) releaseInterrupted(job *Job) error {
+	job.State = NoAvailableWorkers
+	job.NextRunAt = time.Now()
+
+	if err := wp.store.ReleaseJob(job, wp.instanceID); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			return nil
+		}
+		return err
+	}
+
+	wp.recordTransition(job, Accepted, ErrJobInterrupted.Error())
+
+	return nil
+}
@@ -9,13 +9,42 @@ import (
 
 // Store manages data regarding jobs.
 type Store interface {
-	Jobs(datastore.ListOptions) ([]Job, error)
+	Jobs(f Filter, o datastore.ListOptions) ([]Job, error)
 	Job(id uuid.UUID) (Job, error)
 	InsertJob(*Job) error
 	UpdateJob(*Job) error
-	AcceptJob(j *Job, acceptedGracePeriod time.Duration) error
-	SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error)
+	AcceptJob(j *Job, owner string, lease time.Duration) error
+	RenewLease(j *Job, owner string, lease time.Duration) error
+	ReleaseJob(j *Job, owner string) error
+	CancelJob(j *Job) error
+	RetryJob(j *Job) error
+	FailedJobs(f RetryFilter) ([]Job, error)
+	SchedulableJobs(acceptedGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error)
+	ClaimJobs(acceptedGracePeriod time.Duration, owner string, lease time.Duration, limit int) ([]Claim, error)
+	NextDueAt(t0 time.Time) (*time.Time, error)
 	Status() ([]StatusQuery, error)
+	InsertEvent(*Event) error
+	Events(jobID uuid.UUID) ([]Event, error)
+	EventsAfter(afterID uint64, limit int) ([]Event, error)
+	EventsByID(ids []uint64) ([]Event, error)
+	LatestEventID() (uint64, error)
+	WebhookSubscriptions() ([]WebhookSubscription, error)
+	WebhookSubscription(id uuid.UUID) (WebhookSubscription, error)
+	InsertWebhookSubscription(*WebhookSubscription) error
+	UpdateWebhookSubscription(*WebhookSubscription) error
+	DeleteWebhookSubscription(id uuid.UUID) error
+	InsertWebhookDelivery(*WebhookDelivery) error
+	WebhookDeliveries(f DeliveryFilter, o datastore.ListOptions) ([]WebhookDelivery, error)
+	WebhookDelivery(id uint64) (WebhookDelivery, error)
+	JobSchedules() ([]JobSchedule, error)
+	JobSchedule(id uuid.UUID) (JobSchedule, error)
+	InsertJobSchedule(*JobSchedule) error
+	UpdateJobSchedule(*JobSchedule) error
+	DeleteJobSchedule(id uuid.UUID) error
+	DueJobSchedules(now time.Time) ([]JobSchedule, error)
+	MaterializeJobSchedule(s *JobSchedule, next time.Time, j *Job) error
+	ArchiveJobs(f PruneFilter, limit int) (int, error)
+	DeleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error) (int, error)
 }
 
 type StatusQuery struct {
@@ -2,11 +2,14 @@ package jobs
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
 	"github.com/flow-hydraulics/flow-wallet-api/datastore/lib"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -19,9 +22,52 @@ func NewGormStore(db *gorm.DB) Store {
 	return &GormStore{db}
 }
 
-func (s *GormStore) Jobs(o datastore.ListOptions) (jj []Job, err error) {
-	err = s.db.
+func (s *GormStore) Jobs(f Filter, o datastore.ListOptions) (jj []Job, err error) {
+	q := s.db
+
+	if len(f.States) > 0 {
+		q = q.Where("state IN ?", f.States)
+	}
+
+	if len(f.Types) > 0 {
+		q = q.Where("type IN ?", f.Types)
+	}
+
+	if f.TransactionID != "" {
+		q = q.Where("transaction_id = ?", f.TransactionID)
+	}
+
+	if f.CreatedAfter != nil {
+		q = q.Where("created_at >= ?", *f.CreatedAfter)
+	}
+
+	if f.CreatedBefore != nil {
+		q = q.Where("created_at < ?", *f.CreatedBefore)
+	}
+
+	if f.UpdatedAfter != nil {
+		q = q.Where("updated_at >= ?", *f.UpdatedAfter)
+	}
+
+	if f.UpdatedBefore != nil {
+		q = q.Where("updated_at < ?", *f.UpdatedBefore)
+	}
+
+	for path, value := range f.Attributes {
+		q = q.Where(datatypes.JSONQuery("attributes").Equals(value, strings.Split(path, ".")...))
+	}
+
+	if f.Cursor != "" {
+		c, err := DecodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("created_at < ? OR (created_at = ? AND id < ?)", c.CreatedAt, c.CreatedAt, c.ID)
+	}
+
+	err = q.
 		Order("created_at desc").
+		Order("id desc").
 		Limit(o.Limit).
 		Offset(o.Offset).
 		Find(&jj).Error
@@ -34,26 +80,92 @@ func (s *GormStore) Job(id uuid.UUID) (j Job, err error) {
 }
 
 func (s *GormStore) InsertJob(j *Job) error {
-	return s.db.Create(j).Error
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		if err := tx.Create(j).Error; err != nil {
+			return err
+		}
+		return adjustStateCount(tx, j.State, 1)
+	})
 }
 
 func (s *GormStore) UpdateJob(j *Job) error {
-	return s.db.Save(j).Error
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var job Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("state").First(&job, "id = ?", j.ID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(j).Error; err != nil {
+			return err
+		}
+		return moveStateCount(tx, job.State, j.State)
+	})
+}
+
+// adjustStateCount adds delta to the number of jobs in state.
+func adjustStateCount(tx *gorm.DB, state State, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "state"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("job_state_counts.count + ?", delta)}),
+	}).Create(&JobStateCount{State: state, Count: delta}).Error
+}
+
+// applyStateCounts adds deltas to the number of jobs in each state. Counter
+// rows are locked in state order so concurrent transitions in opposite
+// directions do not deadlock.
+func applyStateCounts(tx *gorm.DB, deltas map[State]int) error {
+	states := make([]State, 0, len(deltas))
+	for state := range deltas {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+
+	for _, state := range states {
+		if err := adjustStateCount(tx, state, deltas[state]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveStateCount moves a job from state `from` to state `to` in the job
+// state counts.
+func moveStateCount(tx *gorm.DB, from, to State) error {
+	if from == to {
+		return nil
+	}
+	return applyStateCounts(tx, map[State]int{from: -1, to: 1})
 }
 
-func isAcceptable(j *Job, acceptedGracePeriod time.Duration) bool {
-	tAccepted := time.Now().Add(-1 * acceptedGracePeriod)
-	if j.State == Accepted && j.UpdatedAt.After(tAccepted) {
+func isAcceptable(j *Job) bool {
+	if j.State == Accepted && j.LeaseExpiresAt.After(time.Now()) {
+		// Another worker holds a valid lease
 		return false
 	}
-	if j.State == Complete || j.State == Failed {
+	if j.State == Complete || j.State == Failed || j.State == Cancelled {
 		return false
 	}
 	return true
 }
 
-func (s *GormStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
-	if !isAcceptable(j, acceptedGracePeriod) {
+func isCancellable(j *Job) bool {
+	switch j.State {
+	case Init, Scheduled, NoAvailableWorkers, Error:
+		return true
+	default:
+		return false
+	}
+}
+
+// AcceptJob moves a job to ACCEPTED state and gives `owner` a lease on it for
+// the duration of `lease`. A job held by another owner can only be accepted
+// after its lease has expired.
+func (s *GormStore) AcceptJob(j *Job, owner string, lease time.Duration) error {
+	if !isAcceptable(j) {
 		return fmt.Errorf("error job is not acceptable")
 	}
 	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
@@ -62,28 +174,141 @@ func (s *GormStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
 		if err != nil {
 			return err
 		}
-		if !isAcceptable(&job, acceptedGracePeriod) {
+		if !isAcceptable(&job) {
 			return fmt.Errorf("error job is not acceptable")
 		}
 		j.State = Accepted
 		j.ExecCount = job.ExecCount + 1
+		j.LeaseOwner = owner
+		j.LeaseExpiresAt = time.Now().Add(lease)
 		err = tx.Save(j).Error
 		if err != nil {
 			return err
 		}
-		return nil
+		return moveStateCount(tx, job.State, Accepted)
 	})
 }
 
-func (s *GormStore) SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) (jj []Job, err error) {
-	t0 := time.Now()
-	tAccepted := t0.Add(-1 * acceptedGracePeriod)
-	tReschedulable := t0.Add(-1 * reSchedulableGracePeriod)
+// RenewLease extends the lease `owner` holds on job j. Returns ErrLeaseLost if
+// the job has been accepted by someone else in the meantime.
+func (s *GormStore) RenewLease(j *Job, owner string, lease time.Duration) error {
+	expiresAt := time.Now().Add(lease)
 
-	err = s.db.
-		Where("state IN ? AND updated_at < ?", []string{string(Init), string(Accepted)}, tAccepted).
-		Or("state IN ? AND updated_at < ?", []string{string(Error), string(NoAvailableWorkers)}, tReschedulable).
+	res := s.db.
 		Model(&Job{}).
+		// The execution count identifies the acceptance
+		Where("id = ? AND state = ? AND lease_owner = ? AND exec_count = ?", j.ID, Accepted, owner, j.ExecCount).
+		UpdateColumn("lease_expires_at", expiresAt)
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	j.LeaseExpiresAt = expiresAt
+
+	return nil
+}
+
+// ReleaseJob stores the result of an execution of job j and releases the
+// lease `owner` holds on it. Returns ErrLeaseLost, without storing anything,
+// if the job has been accepted by someone else in the meantime.
+func (s *GormStore) ReleaseJob(j *Job, owner string) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var job Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", j.ID).Error
+		if err != nil {
+			return err
+		}
+		if job.State != Accepted || job.LeaseOwner != owner || job.ExecCount != j.ExecCount {
+			return ErrLeaseLost
+		}
+		j.LeaseOwner = ""
+		j.LeaseExpiresAt = time.Time{}
+		if err := tx.Save(j).Error; err != nil {
+			return err
+		}
+		return moveStateCount(tx, Accepted, j.State)
+	})
+}
+
+func (s *GormStore) CancelJob(j *Job) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var job Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", j.ID).Error
+		if err != nil {
+			return err
+		}
+		if !isCancellable(&job) {
+			return ErrJobNotCancellable
+		}
+		from := job.State
+		job.State = Cancelled
+		err = tx.Save(&job).Error
+		if err != nil {
+			return err
+		}
+		*j = job
+		return moveStateCount(tx, from, Cancelled)
+	})
+}
+
+func (s *GormStore) RetryJob(j *Job) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var job Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", j.ID).Error
+		if err != nil {
+			return err
+		}
+		if job.State != Failed {
+			return ErrJobNotRetryable
+		}
+		// Keep the error history in job.Errors intact
+		job.State = Init
+		job.ExecCount = 0
+		job.NextRunAt = time.Now()
+		err = tx.Save(&job).Error
+		if err != nil {
+			return err
+		}
+		*j = job
+		return moveStateCount(tx, Failed, Init)
+	})
+}
+
+func (s *GormStore) FailedJobs(f RetryFilter) (jj []Job, err error) {
+	q := s.db.Where("state = ?", Failed)
+
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+
+	if f.ErrorContains != "" {
+		q = q.Where("error LIKE ?", "%"+f.ErrorContains+"%")
+	}
+
+	if f.FailedAfter != nil {
+		q = q.Where("updated_at >= ?", *f.FailedAfter)
+	}
+
+	if f.FailedBefore != nil {
+		q = q.Where("updated_at < ?", *f.FailedBefore)
+	}
+
+	err = q.Order("created_at asc").Find(&jj).Error
+	return
+}
+
+// SchedulableJobs returns jobs that should be (re)scheduled. Terminal states
+// (COMPLETE, FAILED and CANCELLED) are never included. Jobs in SCHEDULED,
+// ERROR or NO_AVAILABLE_WORKERS state are included once their next_run_at has
+// passed, jobs in ACCEPTED state once their lease has expired and jobs in
+// INIT state once they have not been updated for acceptedGracePeriod.
+func (s *GormStore) SchedulableJobs(acceptedGracePeriod time.Duration, o datastore.ListOptions) (jj []Job, err error) {
+	err = schedulableQuery(s.db, acceptedGracePeriod, time.Now()).
 		Order("created_at desc").
 		Limit(o.Limit).
 		Offset(o.Offset).
@@ -92,11 +317,418 @@ func (s *GormStore) SchedulableJobs(acceptedGracePeriod, reSchedulableGracePerio
 	return
 }
 
+func schedulableQuery(tx *gorm.DB, acceptedGracePeriod time.Duration, t0 time.Time) *gorm.DB {
+	tAccepted := t0.Add(-1 * acceptedGracePeriod)
+
+	return tx.
+		Where("state = ? AND updated_at < ?", Init, tAccepted).
+		Or("state = ? AND lease_expires_at < ?", Accepted, t0).
+		Or("state IN ? AND next_run_at <= ?", []string{string(Scheduled), string(Error), string(NoAvailableWorkers)}, t0).
+		Model(&Job{})
+}
+
+// NextDueAt returns the earliest time after t0 at which a job in SCHEDULED,
+// ERROR or NO_AVAILABLE_WORKERS state becomes due or the lease of an
+// ACCEPTED job expires, nil if there is no such job.
+func (s *GormStore) NextDueAt(t0 time.Time) (*time.Time, error) {
+	var next *time.Time
+	consider := func(t time.Time) {
+		if next == nil || t.Before(*next) {
+			next = &t
+		}
+	}
+
+	var j Job
+	res := s.db.
+		Where("state IN ? AND next_run_at > ?", []string{string(Scheduled), string(Error), string(NoAvailableWorkers)}, t0).
+		Order("next_run_at asc").
+		Limit(1).
+		Find(&j)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		consider(j.NextRunAt)
+	}
+
+	j = Job{}
+	res = s.db.
+		Where("state = ? AND lease_expires_at >= ?", Accepted, t0).
+		Order("lease_expires_at asc").
+		Limit(1).
+		Find(&j)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		consider(j.LeaseExpiresAt)
+	}
+
+	return next, nil
+}
+
+// ClaimJobs accepts up to `limit` schedulable jobs (see SchedulableJobs),
+// oldest first, for `owner` with a lease for the duration of `lease`. On
+// PostgreSQL rows locked by other instances are skipped
+// (SELECT ... FOR UPDATE SKIP LOCKED) instead of waited for. A job is only
+// claimed if it has not changed since it was selected, so concurrent claims
+// never return the same job.
+func (s *GormStore) ClaimJobs(acceptedGracePeriod time.Duration, owner string, lease time.Duration, limit int) ([]Claim, error) {
+	var claims []Claim
+
+	err := lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		claims = nil
+
+		locking := clause.Locking{Strength: "UPDATE"}
+		if tx.Dialector.Name() == "postgres" {
+			locking.Options = "SKIP LOCKED"
+		}
+
+		now := time.Now()
+
+		var jj []Job
+		err := schedulableQuery(tx.Clauses(locking), acceptedGracePeriod, now).
+			Order("created_at asc").
+			Limit(limit).
+			Find(&jj).Error
+		if err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(lease)
+		deltas := make(map[State]int)
+
+		for _, j := range jj {
+			res := tx.
+				Model(&Job{}).
+				Where("id = ? AND state = ? AND exec_count = ?", j.ID, j.State, j.ExecCount).
+				UpdateColumns(map[string]interface{}{
+					"state":            Accepted,
+					"exec_count":       j.ExecCount + 1,
+					"lease_owner":      owner,
+					"lease_expires_at": expiresAt,
+					"updated_at":       now,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				// Claimed by someone else in between
+				continue
+			}
+
+			deltas[j.State]--
+			deltas[Accepted]++
+
+			from := j.State
+			j.State = Accepted
+			j.ExecCount++
+			j.LeaseOwner = owner
+			j.LeaseExpiresAt = expiresAt
+			j.UpdatedAt = now
+
+			claims = append(claims, Claim{Job: j, From: from})
+		}
+
+		return applyStateCounts(tx, deltas)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Status returns the number of jobs in each state from the job state counts.
 func (s *GormStore) Status() ([]StatusQuery, error) {
 	var res []StatusQuery
-	err := s.db.Raw("SELECT state, COUNT(*) as count FROM jobs GROUP BY state").Scan(&res).Error
+	err := s.db.Model(&JobStateCount{}).Select("state, count").Scan(&res).Error
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
+
+func (s *GormStore) InsertEvent(e *Event) error {
+	return s.db.Create(e).Error
+}
+
+func (s *GormStore) Events(jobID uuid.UUID) (ee []Event, err error) {
+	err = s.db.
+		Where("job_id = ?", jobID).
+		Order("id asc").
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) EventsAfter(afterID uint64, limit int) (ee []Event, err error) {
+	err = s.db.
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) EventsByID(ids []uint64) (ee []Event, err error) {
+	err = s.db.
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) LatestEventID() (id uint64, err error) {
+	err = s.db.Model(&Event{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return
+}
+
+func (s *GormStore) WebhookSubscriptions() (ss []WebhookSubscription, err error) {
+	err = s.db.Order("created_at asc").Find(&ss).Error
+	return
+}
+
+func (s *GormStore) WebhookSubscription(id uuid.UUID) (sub WebhookSubscription, err error) {
+	err = s.db.First(&sub, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertWebhookSubscription(sub *WebhookSubscription) error {
+	return s.db.Create(sub).Error
+}
+
+func (s *GormStore) UpdateWebhookSubscription(sub *WebhookSubscription) error {
+	return s.db.Save(sub).Error
+}
+
+func (s *GormStore) DeleteWebhookSubscription(id uuid.UUID) error {
+	return s.db.Delete(&WebhookSubscription{}, "id = ?", id).Error
+}
+
+func (s *GormStore) InsertWebhookDelivery(d *WebhookDelivery) error {
+	return s.db.Create(d).Error
+}
+
+func (s *GormStore) WebhookDeliveries(f DeliveryFilter, o datastore.ListOptions) (dd []WebhookDelivery, err error) {
+	q := s.db.Model(&WebhookDelivery{})
+
+	if f.SubscriptionID != nil {
+		q = q.Where("subscription_id = ?", *f.SubscriptionID)
+	}
+
+	if f.ParentJobID != nil {
+		q = q.Where("parent_job_id = ?", *f.ParentJobID)
+	}
+
+	if f.FailedOnly {
+		q = q.Where("success = ?", false)
+	}
+
+	err = q.
+		Order("id desc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&dd).Error
+	return
+}
+
+func (s *GormStore) WebhookDelivery(id uint64) (d WebhookDelivery, err error) {
+	err = s.db.First(&d, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) JobSchedules() (ss []JobSchedule, err error) {
+	err = s.db.Order("created_at asc").Find(&ss).Error
+	return
+}
+
+func (s *GormStore) JobSchedule(id uuid.UUID) (sch JobSchedule, err error) {
+	err = s.db.First(&sch, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertJobSchedule(sch *JobSchedule) error {
+	return s.db.Create(sch).Error
+}
+
+// UpdateJobSchedule stores the definition of a job schedule. The run history
+// is maintained by MaterializeJobSchedule and is not overwritten.
+func (s *GormStore) UpdateJobSchedule(sch *JobSchedule) error {
+	return s.db.Omit("run_count", "last_run_at", "last_job_id").Save(sch).Error
+}
+
+func (s *GormStore) DeleteJobSchedule(id uuid.UUID) error {
+	return s.db.Delete(&JobSchedule{}, "id = ?", id).Error
+}
+
+// DueJobSchedules returns enabled job schedules whose next run is at or
+// before `now`.
+func (s *GormStore) DueJobSchedules(now time.Time) (ss []JobSchedule, err error) {
+	err = s.db.
+		Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at asc").
+		Find(&ss).Error
+	return
+}
+
+// MaterializeJobSchedule inserts job j for the due run of job schedule sch and
+// moves the schedule to its next run at `next`. A zero `next` disables the
+// schedule. Returns ErrScheduleAlreadyRun if the run has already been
+// materialized, e.g. by another instance.
+func (s *GormStore) MaterializeJobSchedule(sch *JobSchedule, next time.Time, j *Job) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		now := time.Now()
+
+		updates := map[string]interface{}{
+			"run_count":   sch.RunCount + 1,
+			"next_run_at": next,
+			"last_run_at": now,
+		}
+
+		if next.IsZero() {
+			updates["enabled"] = false
+		}
+
+		// The run count identifies the run, only one instance can claim it
+		res := tx.
+			Model(&JobSchedule{}).
+			Where("id = ? AND run_count = ?", sch.ID, sch.RunCount).
+			UpdateColumns(updates)
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return ErrScheduleAlreadyRun
+		}
+
+		if err := tx.Create(j).Error; err != nil {
+			return err
+		}
+
+		if err := adjustStateCount(tx, j.State, 1); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&JobSchedule{}).Where("id = ?", sch.ID).UpdateColumn("last_job_id", j.ID).Error; err != nil {
+			return err
+		}
+
+		sch.RunCount++
+		sch.NextRunAt = next
+		sch.LastRunAt = &now
+		sch.LastJobID = &j.ID
+		if next.IsZero() {
+			sch.Enabled = false
+		}
+
+		return nil
+	})
+}
+
+func pruneQuery(tx *gorm.DB, f PruneFilter, limit int) *gorm.DB {
+	q := tx.
+		Where("state IN ?", f.States).
+		Where("updated_at < ?", f.UpdatedBefore)
+
+	if f.JobType != "" {
+		q = q.Where("type = ?", f.JobType)
+	}
+
+	for _, p := range f.Exclude {
+		switch {
+		case p.JobType != "" && p.State != "":
+			q = q.Not("type = ? AND state = ?", p.JobType, p.State)
+		case p.JobType != "":
+			q = q.Not("type = ?", p.JobType)
+		case p.State != "":
+			q = q.Not("state = ?", p.State)
+		}
+	}
+
+	return q.Order("updated_at asc").Limit(limit)
+}
+
+// ArchiveJobs moves up to `limit` jobs matching f to the `jobs_archive`
+// table. Returns the number of archived jobs.
+func (s *GormStore) ArchiveJobs(f PruneFilter, limit int) (int, error) {
+	return s.deleteJobs(f, limit, nil, func(tx *gorm.DB, jj []Job) error {
+		now := time.Now()
+		archived := make([]ArchivedJob, len(jj))
+		for i := range jj {
+			archived[i] = NewArchivedJob(jj[i], now)
+		}
+		return tx.Create(&archived).Error
+	})
+}
+
+// DeleteJobs permanently deletes up to `limit` jobs matching f, along with
+// their state transition history. beforeDelete, if not nil, is called with
+// the jobs before they are deleted; returning an error aborts the deletion.
+// Returns the number of deleted jobs.
+func (s *GormStore) DeleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error) (int, error) {
+	return s.deleteJobs(f, limit, beforeDelete, nil)
+}
+
+func (s *GormStore) deleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error, archive func(*gorm.DB, []Job) error) (int, error) {
+	var n int
+
+	err := lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var jj []Job
+		err := pruneQuery(tx.Clauses(clause.Locking{Strength: "UPDATE"}), f, limit).Find(&jj).Error
+		if err != nil {
+			return err
+		}
+
+		if len(jj) == 0 {
+			return nil
+		}
+
+		if beforeDelete != nil {
+			if err := beforeDelete(jj); err != nil {
+				return err
+			}
+		}
+
+		if archive != nil {
+			if err := archive(tx, jj); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]uuid.UUID, len(jj))
+		counts := make(map[State]int)
+		for i, j := range jj {
+			ids[i] = j.ID
+			counts[j.State]++
+		}
+
+		if err := tx.Where("job_id IN ?", ids).Delete(&Event{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&Job{}).Error; err != nil {
+			return err
+		}
+
+		for state := range counts {
+			counts[state] = -counts[state]
+		}
+
+		if err := applyStateCounts(tx, counts); err != nil {
+			return err
+		}
+
+		n = len(jj)
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
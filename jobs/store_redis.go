@@ -0,0 +1,1795 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultRedisKeyPrefix is the default prefix of the keys used by RedisStore.
+const DefaultRedisKeyPrefix = "flow-wallet-api:jobs:"
+
+const (
+	redisConsumerGroup = "workers"
+	redisBatchSize     = 500
+	redisWatchRetries  = 10
+	redisPromoteLimit  = 1000
+	// Stream entries delivered to a consumer that did not acknowledge them
+	// within this time (e.g. because the instance died) are claimed by others
+	redisClaimMinIdleTime = 1 * time.Minute
+)
+
+// errRedisConflict is returned when a Redis transaction keeps getting aborted
+// by concurrent updates.
+var errRedisConflict = errors.New("too many concurrent updates")
+
+var redisJobStates = []State{Init, Scheduled, Accepted, NoAvailableWorkers, Error, Complete, Failed, Cancelled}
+
+// promoteScript adds schedulable jobs that are not in the job stream yet to
+// the stream.
+//
+// KEYS: queued set, stream, INIT, ACCEPTED, SCHEDULED, ERROR and
+// NO_AVAILABLE_WORKERS state sets
+// ARGV: max INIT score, max ACCEPTED score, max score of the other states, limit
+var promoteScript = redis.NewScript(7, `
+local limit = tonumber(ARGV[4])
+local n = 0
+local function promote(key, max)
+	for _, id in ipairs(redis.call('ZRANGEBYSCORE', key, '-inf', max)) do
+		if n >= limit then
+			return
+		end
+		if redis.call('SADD', KEYS[1], id) == 1 then
+			redis.call('XADD', KEYS[2], '*', 'job', id)
+			n = n + 1
+		end
+	end
+end
+promote(KEYS[3], ARGV[1])
+promote(KEYS[4], ARGV[2])
+promote(KEYS[5], ARGV[3])
+promote(KEYS[6], ARGV[3])
+promote(KEYS[7], ARGV[3])
+return n
+`)
+
+// enqueueScript adds a job to the job stream unless it is already queued
+// there. Checking the queued set in the script keeps it consistent with
+// concurrent claims removing the job from the set.
+//
+// KEYS: queued set, stream
+// ARGV: job id
+var enqueueScript = redis.NewScript(2, `
+if redis.call('SADD', KEYS[1], ARGV[1]) == 1 then
+	redis.call('XADD', KEYS[2], '*', 'job', ARGV[1])
+end
+return 0
+`)
+
+// RedisStore is a Store keeping jobs and related data in Redis.
+//
+// Each job is stored as JSON and indexed in a sorted set per state, scored
+// by the time the job becomes schedulable (INIT and terminal states by
+// updated_at, ACCEPTED by lease_expires_at and the others by next_run_at).
+// Jobs that become schedulable are appended to a Redis Stream which is
+// consumed by a consumer group of all instances (see ClaimJobs), so each
+// instance gets its own share of the jobs without locking. Conditional
+// updates use optimistic transactions (WATCH/MULTI/EXEC).
+type RedisStore struct {
+	pool       *redis.Pool
+	prefix     string
+	groupReady int32
+}
+
+// NewRedisStore returns a Store using the Redis connections from pool. All
+// keys are prefixed with keyPrefix, see DefaultRedisKeyPrefix.
+func NewRedisStore(pool *redis.Pool, keyPrefix string) Store {
+	return &RedisStore{pool: pool, prefix: keyPrefix}
+}
+
+func (s *RedisStore) key(parts ...string) string {
+	return s.prefix + strings.Join(parts, ":")
+}
+
+func (s *RedisStore) jobKey(id uuid.UUID) string {
+	return s.key("job", id.String())
+}
+
+func (s *RedisStore) stateKey(state State) string {
+	return s.key("state", string(state))
+}
+
+// redisJobStreamKey is the key of the stream schedulable jobs are added to.
+func redisJobStreamKey(keyPrefix string) string {
+	return keyPrefix + "stream"
+}
+
+// redisScore returns t as a sorted set score in microseconds.
+func redisScore(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
+
+// jobScore returns the score of job j in the sorted set of its state.
+func jobScore(j *Job) string {
+	switch j.State {
+	case Accepted:
+		return redisScore(j.LeaseExpiresAt)
+	case Scheduled, Error, NoAvailableWorkers:
+		return redisScore(j.NextRunAt)
+	default:
+		return redisScore(j.UpdatedAt)
+	}
+}
+
+// isSchedulable matches the jobs returned by Store.SchedulableJobs.
+func isSchedulable(j *Job, acceptedGracePeriod time.Duration, now time.Time) bool {
+	switch j.State {
+	case Init:
+		return j.UpdatedAt.Before(now.Add(-1 * acceptedGracePeriod))
+	case Accepted:
+		return j.LeaseExpiresAt.Before(now)
+	case Scheduled, Error, NoAvailableWorkers:
+		return !j.NextRunAt.After(now)
+	default:
+		return false
+	}
+}
+
+// isDue returns true if job j should be pushed to the job stream right away.
+func isDue(j *Job, now time.Time) bool {
+	switch j.State {
+	case Scheduled, Error, NoAvailableWorkers:
+		return !j.NextRunAt.After(now)
+	default:
+		return false
+	}
+}
+
+func encodeJob(j Job) ([]byte, error) {
+	j.ShouldSendNotification = false
+	j.claimed = false
+	return json.Marshal(j)
+}
+
+func decodeJob(b []byte) (Job, error) {
+	var j Job
+	if err := json.Unmarshal(b, &j); err != nil {
+		return Job{}, err
+	}
+	if string(j.Attributes) == "null" {
+		j.Attributes = nil
+	}
+	return j, nil
+}
+
+// page applies list options o to n items and returns the resulting bounds.
+func page(n int, o datastore.ListOptions) (int, int) {
+	lo := o.Offset
+	if lo > n {
+		lo = n
+	}
+	hi := n
+	if o.Limit >= 0 && lo+o.Limit < hi {
+		hi = lo + o.Limit
+	}
+	return lo, hi
+}
+
+func (s *RedisStore) job(c redis.Conn, id uuid.UUID) (Job, error) {
+	b, err := redis.Bytes(c.Do("GET", s.jobKey(id)))
+	if err == redis.ErrNil {
+		return Job{}, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	return decodeJob(b)
+}
+
+// jobsByID returns the jobs with the given ids, skipping deleted jobs.
+func (s *RedisStore) jobsByID(c redis.Conn, ids []string) ([]Job, error) {
+	jj := make([]Job, 0, len(ids))
+
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > redisBatchSize {
+			n = redisBatchSize
+		}
+
+		args := make([]interface{}, n)
+		for i, id := range ids[:n] {
+			args[i] = s.key("job", id)
+		}
+		ids = ids[n:]
+
+		values, err := redis.ByteSlices(c.Do("MGET", args...))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range values {
+			if b == nil {
+				continue
+			}
+			j, err := decodeJob(b)
+			if err != nil {
+				return nil, err
+			}
+			jj = append(jj, j)
+		}
+	}
+
+	return jj, nil
+}
+
+// scanJobs returns jobs matching `match` from the sorted set at key in
+// descending score order, starting at score max.
+func (s *RedisStore) scanJobs(c redis.Conn, key, max string, o datastore.ListOptions, match func(*Job) bool) ([]Job, error) {
+	var jj []Job
+
+	skip := o.Offset
+
+	for offset := 0; ; offset += redisBatchSize {
+		ids, err := redis.Strings(c.Do("ZREVRANGEBYSCORE", key, max, "-inf", "LIMIT", offset, redisBatchSize))
+		if err != nil {
+			return nil, err
+		}
+
+		batch, err := s.jobsByID(c, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range batch {
+			if !match(&batch[i]) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			jj = append(jj, batch[i])
+			if o.Limit >= 0 && len(jj) >= o.Limit {
+				return jj, nil
+			}
+		}
+
+		if len(ids) < redisBatchSize {
+			return jj, nil
+		}
+	}
+}
+
+// sendSaveJob queues the commands storing job j, previously stored as `old`
+// (nil for a new job), on c. A job that is due is added to the job stream
+// unless it is already queued there.
+func (s *RedisStore) sendSaveJob(c redis.Conn, old, j *Job) error {
+	b, err := encodeJob(*j)
+	if err != nil {
+		return err
+	}
+
+	id := j.ID.String()
+
+	if err := c.Send("SET", s.jobKey(j.ID), b); err != nil {
+		return err
+	}
+
+	if old == nil {
+		if err := c.Send("ZADD", s.key("jobs"), redisScore(j.CreatedAt), id); err != nil {
+			return err
+		}
+	} else if old.State != j.State {
+		if err := c.Send("ZREM", s.stateKey(old.State), id); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Send("ZADD", s.stateKey(j.State), jobScore(j), id); err != nil {
+		return err
+	}
+
+	if isDue(j, time.Now()) {
+		if err := enqueueScript.Send(c, s.key("queued"), redisJobStreamKey(s.prefix), id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareInsert sets the fields the database would set when inserting job j.
+func prepareInsert(j *Job) {
+	now := time.Now()
+	j.ID = uuid.New()
+	if j.State == "" {
+		j.State = Init
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	if j.UpdatedAt.IsZero() {
+		j.UpdatedAt = now
+	}
+	// Listings are ordered by the sorted set score
+	j.CreatedAt = j.CreatedAt.Truncate(time.Microsecond)
+}
+
+// updateJob stores the job with the given id as modified by fn. The job is
+// re-read and fn called again if it was changed concurrently. Commands queued
+// by `also` are executed in the same transaction.
+func (s *RedisStore) updateJob(c redis.Conn, id uuid.UUID, fn func(*Job) error, also func(redis.Conn) error) (Job, error) {
+	key := s.jobKey(id)
+
+	for i := 0; i < redisWatchRetries; i++ {
+		if _, err := c.Do("WATCH", key); err != nil {
+			return Job{}, err
+		}
+
+		old, err := s.job(c, id)
+		if err != nil {
+			_, _ = c.Do("UNWATCH")
+			return Job{}, err
+		}
+
+		j := old
+		if err := fn(&j); err != nil {
+			_, _ = c.Do("UNWATCH")
+			return Job{}, err
+		}
+
+		if err := c.Send("MULTI"); err != nil {
+			return Job{}, err
+		}
+
+		if err := s.sendSaveJob(c, &old, &j); err != nil {
+			_, _ = c.Do("DISCARD")
+			return Job{}, err
+		}
+
+		if also != nil {
+			if err := also(c); err != nil {
+				_, _ = c.Do("DISCARD")
+				return Job{}, err
+			}
+		}
+
+		_, err = redis.Values(c.Do("EXEC"))
+		if err == redis.ErrNil {
+			// Changed concurrently
+			continue
+		}
+		if err != nil {
+			return Job{}, err
+		}
+
+		return j, nil
+	}
+
+	return Job{}, errRedisConflict
+}
+
+// Jobs returns jobs matching f, newest first. The filter is applied to jobs
+// as they are read, in batches.
+func (s *RedisStore) Jobs(f Filter, o datastore.ListOptions) ([]Job, error) {
+	var cursor *Cursor
+	if f.Cursor != "" {
+		c, err := DecodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+	}
+
+	max := "+inf"
+	if cursor != nil {
+		max = redisScore(cursor.CreatedAt)
+	}
+
+	match := func(j *Job) bool {
+		if len(f.States) > 0 && !containsState(f.States, j.State) {
+			return false
+		}
+		if len(f.Types) > 0 && !containsString(f.Types, j.Type) {
+			return false
+		}
+		if f.TransactionID != "" && j.TransactionID != f.TransactionID {
+			return false
+		}
+		if f.CreatedAfter != nil && j.CreatedAt.Before(*f.CreatedAfter) {
+			return false
+		}
+		if f.CreatedBefore != nil && !j.CreatedAt.Before(*f.CreatedBefore) {
+			return false
+		}
+		if f.UpdatedAfter != nil && j.UpdatedAt.Before(*f.UpdatedAfter) {
+			return false
+		}
+		if f.UpdatedBefore != nil && !j.UpdatedAt.Before(*f.UpdatedBefore) {
+			return false
+		}
+		for path, value := range f.Attributes {
+			if !attributeEquals(j.Attributes, path, value) {
+				return false
+			}
+		}
+		if cursor != nil {
+			if j.CreatedAt.After(cursor.CreatedAt) {
+				return false
+			}
+			if j.CreatedAt.Equal(cursor.CreatedAt) && j.ID.String() >= cursor.ID.String() {
+				return false
+			}
+		}
+		return true
+	}
+
+	c := s.pool.Get()
+	defer c.Close()
+
+	return s.scanJobs(c, s.key("jobs"), max, o, match)
+}
+
+func containsState(ss []State, s State) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeEquals returns true if the value at the dot separated key path in
+// attributes equals value.
+func attributeEquals(attributes []byte, path, value string) bool {
+	var v interface{}
+	if err := json.Unmarshal(attributes, &v); err != nil {
+		return false
+	}
+
+	for _, k := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if v, ok = m[k]; !ok {
+			return false
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v == value
+	default:
+		b, err := json.Marshal(v)
+		return err == nil && string(b) == value
+	}
+}
+
+func (s *RedisStore) Job(id uuid.UUID) (Job, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	return s.job(c, id)
+}
+
+func (s *RedisStore) InsertJob(j *Job) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	prepareInsert(j)
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+
+	if err := s.sendSaveJob(c, nil, j); err != nil {
+		_, _ = c.Do("DISCARD")
+		return err
+	}
+
+	_, err := c.Do("EXEC")
+	return err
+}
+
+func (s *RedisStore) UpdateJob(j *Job) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	job, err := s.updateJob(c, j.ID, func(job *Job) error {
+		*job = *j
+		job.UpdatedAt = time.Now()
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	j.UpdatedAt = job.UpdatedAt
+
+	return nil
+}
+
+// AcceptJob moves a job to ACCEPTED state and gives `owner` a lease on it for
+// the duration of `lease`. A job held by another owner can only be accepted
+// after its lease has expired.
+func (s *RedisStore) AcceptJob(j *Job, owner string, lease time.Duration) error {
+	if !isAcceptable(j) {
+		return fmt.Errorf("error job is not acceptable")
+	}
+
+	c := s.pool.Get()
+	defer c.Close()
+
+	job, err := s.updateJob(c, j.ID, func(job *Job) error {
+		if !isAcceptable(job) {
+			return fmt.Errorf("error job is not acceptable")
+		}
+		execCount := job.ExecCount
+		*job = *j
+		job.State = Accepted
+		job.ExecCount = execCount + 1
+		job.LeaseOwner = owner
+		job.LeaseExpiresAt = time.Now().Add(lease)
+		job.UpdatedAt = time.Now()
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	j.State = job.State
+	j.ExecCount = job.ExecCount
+	j.LeaseOwner = job.LeaseOwner
+	j.LeaseExpiresAt = job.LeaseExpiresAt
+	j.UpdatedAt = job.UpdatedAt
+
+	return nil
+}
+
+// RenewLease extends the lease `owner` holds on job j. Returns ErrLeaseLost if
+// the job has been accepted by someone else in the meantime.
+func (s *RedisStore) RenewLease(j *Job, owner string, lease time.Duration) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	expiresAt := time.Now().Add(lease)
+
+	_, err := s.updateJob(c, j.ID, func(job *Job) error {
+		// The execution count identifies the acceptance
+		if job.State != Accepted || job.LeaseOwner != owner || job.ExecCount != j.ExecCount {
+			return ErrLeaseLost
+		}
+		job.LeaseExpiresAt = expiresAt
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	j.LeaseExpiresAt = expiresAt
+
+	return nil
+}
+
+// ReleaseJob stores the result of an execution of job j and releases the
+// lease `owner` holds on it. Returns ErrLeaseLost, without storing anything,
+// if the job has been accepted by someone else in the meantime.
+func (s *RedisStore) ReleaseJob(j *Job, owner string) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	job, err := s.updateJob(c, j.ID, func(job *Job) error {
+		if job.State != Accepted || job.LeaseOwner != owner || job.ExecCount != j.ExecCount {
+			return ErrLeaseLost
+		}
+		*job = *j
+		job.LeaseOwner = ""
+		job.LeaseExpiresAt = time.Time{}
+		job.UpdatedAt = time.Now()
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	j.LeaseOwner = job.LeaseOwner
+	j.LeaseExpiresAt = job.LeaseExpiresAt
+	j.UpdatedAt = job.UpdatedAt
+
+	return nil
+}
+
+func (s *RedisStore) CancelJob(j *Job) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	job, err := s.updateJob(c, j.ID, func(job *Job) error {
+		if !isCancellable(job) {
+			return ErrJobNotCancellable
+		}
+		job.State = Cancelled
+		job.UpdatedAt = time.Now()
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	*j = job
+
+	return nil
+}
+
+func (s *RedisStore) RetryJob(j *Job) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	job, err := s.updateJob(c, j.ID, func(job *Job) error {
+		if job.State != Failed {
+			return ErrJobNotRetryable
+		}
+		// Keep the error history in job.Errors intact
+		job.State = Init
+		job.ExecCount = 0
+		job.NextRunAt = time.Now()
+		job.UpdatedAt = time.Now()
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	*j = job
+
+	return nil
+}
+
+func (s *RedisStore) FailedJobs(f RetryFilter) ([]Job, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	ids, err := redis.Strings(c.Do("ZRANGE", s.stateKey(Failed), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := s.jobsByID(c, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	jj := make([]Job, 0, len(all))
+	for _, j := range all {
+		if j.State != Failed {
+			continue
+		}
+		if f.Type != "" && j.Type != f.Type {
+			continue
+		}
+		if f.ErrorContains != "" && !strings.Contains(j.Error, f.ErrorContains) {
+			continue
+		}
+		if f.FailedAfter != nil && j.UpdatedAt.Before(*f.FailedAfter) {
+			continue
+		}
+		if f.FailedBefore != nil && !j.UpdatedAt.Before(*f.FailedBefore) {
+			continue
+		}
+		jj = append(jj, j)
+	}
+
+	sort.SliceStable(jj, func(a, b int) bool {
+		return jj[a].CreatedAt.Before(jj[b].CreatedAt)
+	})
+
+	return jj, nil
+}
+
+// NextDueAt returns the earliest time after t0 at which a job becomes due or
+// the lease of an ACCEPTED job expires, see GormStore.NextDueAt.
+func (s *RedisStore) NextDueAt(t0 time.Time) (*time.Time, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	ranges := []struct {
+		state State
+		min   string
+	}{
+		{Accepted, redisScore(t0)},
+		{Scheduled, "(" + redisScore(t0)},
+		{Error, "(" + redisScore(t0)},
+		{NoAvailableWorkers, "(" + redisScore(t0)},
+	}
+
+	var next *time.Time
+	for _, r := range ranges {
+		values, err := redis.Strings(c.Do("ZRANGEBYSCORE", s.stateKey(r.state), r.min, "+inf", "WITHSCORES", "LIMIT", 0, 1))
+		if err != nil {
+			return nil, err
+		}
+		if len(values) < 2 {
+			continue
+		}
+		score, err := strconv.ParseInt(values[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		t := time.UnixMicro(score)
+		if next == nil || t.Before(*next) {
+			next = &t
+		}
+	}
+
+	return next, nil
+}
+
+// SchedulableJobs returns jobs that should be (re)scheduled, see
+// GormStore.SchedulableJobs.
+func (s *RedisStore) SchedulableJobs(acceptedGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	now := time.Now()
+
+	ranges := []struct {
+		state State
+		max   string
+	}{
+		{Init, "(" + redisScore(now.Add(-1*acceptedGracePeriod))},
+		{Accepted, "(" + redisScore(now)},
+		{Scheduled, redisScore(now)},
+		{Error, redisScore(now)},
+		{NoAvailableWorkers, redisScore(now)},
+	}
+
+	var ids []string
+	for _, r := range ranges {
+		stateIDs, err := redis.Strings(c.Do("ZRANGEBYSCORE", s.stateKey(r.state), "-inf", r.max))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, stateIDs...)
+	}
+
+	all, err := s.jobsByID(c, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	jj := make([]Job, 0, len(all))
+	for _, j := range all {
+		if isSchedulable(&j, acceptedGracePeriod, now) {
+			jj = append(jj, j)
+		}
+	}
+
+	sort.SliceStable(jj, func(a, b int) bool {
+		return jj[a].CreatedAt.After(jj[b].CreatedAt)
+	})
+
+	lo, hi := page(len(jj), o)
+
+	return jj[lo:hi], nil
+}
+
+// ClaimJobs accepts up to `limit` schedulable jobs for `owner` with a lease
+// for the duration of `lease`.
+//
+// Schedulable jobs are added to the job stream once, either when they are
+// stored in a due state or by ClaimJobs when their time has come, and read
+// from the stream as `owner` of a consumer group. Redis delivers each stream
+// entry to a single consumer, so instances claim different jobs. Entries
+// left unacknowledged by a consumer are taken over after
+// redisClaimMinIdleTime. A job is only claimed if it is still schedulable,
+// stale entries are dropped.
+func (s *RedisStore) ClaimJobs(acceptedGracePeriod time.Duration, owner string, lease time.Duration, limit int) ([]Claim, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	if err := s.ensureConsumerGroup(c); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	_, err := promoteScript.Do(c,
+		s.key("queued"),
+		redisJobStreamKey(s.prefix),
+		s.stateKey(Init),
+		s.stateKey(Accepted),
+		s.stateKey(Scheduled),
+		s.stateKey(Error),
+		s.stateKey(NoAvailableWorkers),
+		"("+redisScore(now.Add(-1*acceptedGracePeriod)),
+		"("+redisScore(now),
+		redisScore(now),
+		redisPromoteLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.autoClaimEntries(c, owner, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims []Claim
+
+	for {
+		for _, e := range entries {
+			claim, ok, err := s.claimEntry(c, e, acceptedGracePeriod, owner, lease, now)
+			if err != nil {
+				return claims, err
+			}
+			if ok {
+				claims = append(claims, claim)
+			}
+		}
+
+		if len(claims) >= limit {
+			break
+		}
+
+		entries, err = s.readEntries(c, owner, limit-len(claims))
+		if err != nil {
+			return claims, err
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+	}
+
+	return claims, nil
+}
+
+type redisStreamEntry struct {
+	ID    string
+	JobID string
+}
+
+func (s *RedisStore) ensureConsumerGroup(c redis.Conn) error {
+	if atomic.LoadInt32(&s.groupReady) == 1 {
+		return nil
+	}
+
+	_, err := c.Do("XGROUP", "CREATE", redisJobStreamKey(s.prefix), redisConsumerGroup, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	atomic.StoreInt32(&s.groupReady, 1)
+
+	return nil
+}
+
+// checkConsumerGroup makes the consumer group to be re-created in case the
+// stream has been removed.
+func (s *RedisStore) checkConsumerGroup(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		atomic.StoreInt32(&s.groupReady, 0)
+	}
+	return err
+}
+
+// readEntries reads up to `count` new entries from the job stream for
+// consumer `owner`.
+func (s *RedisStore) readEntries(c redis.Conn, owner string, count int) ([]redisStreamEntry, error) {
+	reply, err := redis.Values(c.Do("XREADGROUP", "GROUP", redisConsumerGroup, owner, "COUNT", count, "STREAMS", redisJobStreamKey(s.prefix), ">"))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, s.checkConsumerGroup(err)
+	}
+
+	var entries []redisStreamEntry
+
+	for _, stream := range reply {
+		values, err := redis.Values(stream, nil)
+		if err != nil || len(values) != 2 {
+			return nil, fmt.Errorf("unexpected XREADGROUP reply")
+		}
+		streamEntries, err := parseStreamEntries(values[1])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, streamEntries...)
+	}
+
+	return entries, nil
+}
+
+// autoClaimEntries takes over up to `count` entries from the job stream that
+// have been delivered to another consumer but not acknowledged in time.
+func (s *RedisStore) autoClaimEntries(c redis.Conn, owner string, count int) ([]redisStreamEntry, error) {
+	reply, err := redis.Values(c.Do("XAUTOCLAIM", redisJobStreamKey(s.prefix), redisConsumerGroup, owner, redisClaimMinIdleTime.Milliseconds(), "0-0", "COUNT", count))
+	if err != nil {
+		return nil, s.checkConsumerGroup(err)
+	}
+
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply")
+	}
+
+	return parseStreamEntries(reply[1])
+}
+
+func parseStreamEntries(reply interface{}) ([]redisStreamEntry, error) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]redisStreamEntry, 0, len(values))
+
+	for _, v := range values {
+		entry, err := redis.Values(v, nil)
+		if err != nil || len(entry) != 2 {
+			// Entries deleted while pending are returned as nil
+			continue
+		}
+
+		id, err := redis.String(entry[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := redis.StringMap(entry[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, redisStreamEntry{ID: id, JobID: fields["job"]})
+	}
+
+	return entries, nil
+}
+
+// claimEntry accepts the job of stream entry e for `owner` if it is
+// schedulable and acknowledges the entry.
+func (s *RedisStore) claimEntry(c redis.Conn, e redisStreamEntry, acceptedGracePeriod time.Duration, owner string, lease time.Duration, now time.Time) (Claim, bool, error) {
+	stream := redisJobStreamKey(s.prefix)
+
+	ack := func(c redis.Conn) error {
+		if err := c.Send("SREM", s.key("queued"), e.JobID); err != nil {
+			return err
+		}
+		if err := c.Send("XACK", stream, redisConsumerGroup, e.ID); err != nil {
+			return err
+		}
+		return c.Send("XDEL", stream, e.ID)
+	}
+
+	errNotSchedulable := errors.New("job is not schedulable")
+
+	id, err := uuid.Parse(e.JobID)
+	if err != nil {
+		err = errNotSchedulable
+	}
+
+	var from State
+
+	var job Job
+	if err == nil {
+		job, err = s.updateJob(c, id, func(j *Job) error {
+			if !isSchedulable(j, acceptedGracePeriod, now) {
+				return errNotSchedulable
+			}
+			from = j.State
+			j.State = Accepted
+			j.ExecCount++
+			j.LeaseOwner = owner
+			j.LeaseExpiresAt = now.Add(lease)
+			j.UpdatedAt = now
+			return nil
+		}, ack)
+	}
+
+	if err == nil {
+		return Claim{Job: job, From: from}, true, nil
+	}
+
+	if err != errNotSchedulable && err != gorm.ErrRecordNotFound {
+		return Claim{}, false, err
+	}
+
+	// Stale entry
+	if err := c.Send("MULTI"); err != nil {
+		return Claim{}, false, err
+	}
+	if err := ack(c); err != nil {
+		_, _ = c.Do("DISCARD")
+		return Claim{}, false, err
+	}
+	if _, err := c.Do("EXEC"); err != nil {
+		return Claim{}, false, err
+	}
+
+	return Claim{}, false, nil
+}
+
+// Status returns the number of jobs in each state.
+func (s *RedisStore) Status() ([]StatusQuery, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	for _, state := range redisJobStates {
+		if err := c.Send("ZCARD", s.stateKey(state)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+
+	res := make([]StatusQuery, len(redisJobStates))
+	for i, state := range redisJobStates {
+		count, err := redis.Int(c.Receive())
+		if err != nil {
+			return nil, err
+		}
+		res[i] = StatusQuery{State: state, Count: count}
+	}
+
+	return res, nil
+}
+
+func (s *RedisStore) InsertEvent(e *Event) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	id, err := redis.Uint64(c.Do("INCR", s.key("event_id")))
+	if err != nil {
+		return err
+	}
+
+	e.ID = id
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := c.Send("ZADD", s.key("events"), e.ID, b); err != nil {
+		return err
+	}
+	if err := c.Send("RPUSH", s.key("job_events", e.JobID.String()), b); err != nil {
+		return err
+	}
+
+	_, err = c.Do("EXEC")
+	return err
+}
+
+func decodeEvents(values [][]byte) ([]Event, error) {
+	ee := make([]Event, len(values))
+	for i, b := range values {
+		if err := json.Unmarshal(b, &ee[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ee, nil
+}
+
+func (s *RedisStore) Events(jobID uuid.UUID) ([]Event, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	values, err := redis.ByteSlices(c.Do("LRANGE", s.key("job_events", jobID.String()), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEvents(values)
+}
+
+func (s *RedisStore) EventsAfter(afterID uint64, limit int) ([]Event, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	args := []interface{}{s.key("events"), fmt.Sprintf("(%d", afterID), "+inf"}
+	if limit >= 0 {
+		args = append(args, "LIMIT", 0, limit)
+	}
+
+	values, err := redis.ByteSlices(c.Do("ZRANGEBYSCORE", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEvents(values)
+}
+
+func (s *RedisStore) EventsByID(ids []uint64) ([]Event, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	sorted := append([]uint64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, id := range sorted {
+		if err := c.Send("ZRANGEBYSCORE", s.key("events"), id, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+
+	var ee []Event
+	for range sorted {
+		values, err := redis.ByteSlices(c.Receive())
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeEvents(values)
+		if err != nil {
+			return nil, err
+		}
+		ee = append(ee, e...)
+	}
+
+	return ee, nil
+}
+
+func (s *RedisStore) LatestEventID() (uint64, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	id, err := redis.Uint64(c.Do("GET", s.key("event_id")))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+
+	return id, err
+}
+
+func (s *RedisStore) WebhookSubscriptions() ([]WebhookSubscription, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	values, err := redis.ByteSlices(c.Do("HVALS", s.key("webhook_subscriptions")))
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]WebhookSubscription, len(values))
+	for i, b := range values {
+		if err := json.Unmarshal(b, &ss[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(ss, func(a, b int) bool {
+		return ss[a].CreatedAt.Before(ss[b].CreatedAt)
+	})
+
+	return ss, nil
+}
+
+func (s *RedisStore) WebhookSubscription(id uuid.UUID) (WebhookSubscription, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	var sub WebhookSubscription
+
+	b, err := redis.Bytes(c.Do("HGET", s.key("webhook_subscriptions"), id.String()))
+	if err == redis.ErrNil {
+		return sub, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return sub, err
+	}
+
+	err = json.Unmarshal(b, &sub)
+
+	return sub, err
+}
+
+func (s *RedisStore) saveWebhookSubscription(sub *WebhookSubscription) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	b, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do("HSET", s.key("webhook_subscriptions"), sub.ID.String(), b)
+	return err
+}
+
+func (s *RedisStore) InsertWebhookSubscription(sub *WebhookSubscription) error {
+	now := time.Now()
+	sub.ID = uuid.New()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	return s.saveWebhookSubscription(sub)
+}
+
+func (s *RedisStore) UpdateWebhookSubscription(sub *WebhookSubscription) error {
+	sub.UpdatedAt = time.Now()
+	return s.saveWebhookSubscription(sub)
+}
+
+func (s *RedisStore) DeleteWebhookSubscription(id uuid.UUID) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	_, err := c.Do("HDEL", s.key("webhook_subscriptions"), id.String())
+	return err
+}
+
+func (s *RedisStore) InsertWebhookDelivery(d *WebhookDelivery) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	id, err := redis.Uint64(c.Do("INCR", s.key("webhook_delivery_id")))
+	if err != nil {
+		return err
+	}
+
+	d.ID = id
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do("ZADD", s.key("webhook_deliveries"), d.ID, b)
+	return err
+}
+
+func (s *RedisStore) WebhookDeliveries(f DeliveryFilter, o datastore.ListOptions) ([]WebhookDelivery, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	var dd []WebhookDelivery
+
+	skip := o.Offset
+
+	for offset := 0; ; offset += redisBatchSize {
+		values, err := redis.ByteSlices(c.Do("ZREVRANGE", s.key("webhook_deliveries"), offset, offset+redisBatchSize-1))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range values {
+			var d WebhookDelivery
+			if err := json.Unmarshal(b, &d); err != nil {
+				return nil, err
+			}
+			if f.SubscriptionID != nil && (d.SubscriptionID == nil || *d.SubscriptionID != *f.SubscriptionID) {
+				continue
+			}
+			if f.ParentJobID != nil && d.ParentJobID != *f.ParentJobID {
+				continue
+			}
+			if f.FailedOnly && d.Success {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			dd = append(dd, d)
+			if o.Limit >= 0 && len(dd) >= o.Limit {
+				return dd, nil
+			}
+		}
+
+		if len(values) < redisBatchSize {
+			return dd, nil
+		}
+	}
+}
+
+func (s *RedisStore) WebhookDelivery(id uint64) (WebhookDelivery, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	var d WebhookDelivery
+
+	values, err := redis.ByteSlices(c.Do("ZRANGEBYSCORE", s.key("webhook_deliveries"), id, id))
+	if err != nil {
+		return d, err
+	}
+
+	if len(values) == 0 {
+		return d, gorm.ErrRecordNotFound
+	}
+
+	err = json.Unmarshal(values[0], &d)
+
+	return d, err
+}
+
+func (s *RedisStore) jobSchedule(c redis.Conn, id uuid.UUID) (JobSchedule, error) {
+	var sch JobSchedule
+
+	b, err := redis.Bytes(c.Do("HGET", s.key("job_schedules"), id.String()))
+	if err == redis.ErrNil {
+		return sch, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return sch, err
+	}
+
+	err = json.Unmarshal(b, &sch)
+
+	return sch, err
+}
+
+// sendSaveJobSchedule queues the commands storing job schedule sch on c.
+func (s *RedisStore) sendSaveJobSchedule(c redis.Conn, sch *JobSchedule) error {
+	b, err := json.Marshal(sch)
+	if err != nil {
+		return err
+	}
+
+	id := sch.ID.String()
+
+	if err := c.Send("HSET", s.key("job_schedules"), id, b); err != nil {
+		return err
+	}
+
+	if sch.Enabled {
+		return c.Send("ZADD", s.key("job_schedules_due"), redisScore(sch.NextRunAt), id)
+	}
+
+	return c.Send("ZREM", s.key("job_schedules_due"), id)
+}
+
+func (s *RedisStore) JobSchedules() ([]JobSchedule, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	values, err := redis.ByteSlices(c.Do("HVALS", s.key("job_schedules")))
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]JobSchedule, len(values))
+	for i, b := range values {
+		if err := json.Unmarshal(b, &ss[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(ss, func(a, b int) bool {
+		return ss[a].CreatedAt.Before(ss[b].CreatedAt)
+	})
+
+	return ss, nil
+}
+
+func (s *RedisStore) JobSchedule(id uuid.UUID) (JobSchedule, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	return s.jobSchedule(c, id)
+}
+
+func (s *RedisStore) InsertJobSchedule(sch *JobSchedule) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	now := time.Now()
+	sch.ID = uuid.New()
+	sch.CreatedAt = now
+	sch.UpdatedAt = now
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+
+	if err := s.sendSaveJobSchedule(c, sch); err != nil {
+		_, _ = c.Do("DISCARD")
+		return err
+	}
+
+	_, err := c.Do("EXEC")
+	return err
+}
+
+// UpdateJobSchedule stores the definition of a job schedule. The run history
+// is maintained by MaterializeJobSchedule and is not overwritten.
+func (s *RedisStore) UpdateJobSchedule(sch *JobSchedule) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	sch.UpdatedAt = time.Now()
+
+	for i := 0; i < redisWatchRetries; i++ {
+		if _, err := c.Do("WATCH", s.key("job_schedules")); err != nil {
+			return err
+		}
+
+		stored, err := s.jobSchedule(c, sch.ID)
+		if err != nil {
+			_, _ = c.Do("UNWATCH")
+			return err
+		}
+
+		updated := *sch
+		updated.RunCount = stored.RunCount
+		updated.LastRunAt = stored.LastRunAt
+		updated.LastJobID = stored.LastJobID
+
+		if err := c.Send("MULTI"); err != nil {
+			return err
+		}
+
+		if err := s.sendSaveJobSchedule(c, &updated); err != nil {
+			_, _ = c.Do("DISCARD")
+			return err
+		}
+
+		_, err = redis.Values(c.Do("EXEC"))
+		if err == redis.ErrNil {
+			continue
+		}
+
+		return err
+	}
+
+	return errRedisConflict
+}
+
+func (s *RedisStore) DeleteJobSchedule(id uuid.UUID) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := c.Send("HDEL", s.key("job_schedules"), id.String()); err != nil {
+		return err
+	}
+	if err := c.Send("ZREM", s.key("job_schedules_due"), id.String()); err != nil {
+		return err
+	}
+
+	_, err := c.Do("EXEC")
+	return err
+}
+
+// DueJobSchedules returns enabled job schedules whose next run is at or
+// before `now`.
+func (s *RedisStore) DueJobSchedules(now time.Time) ([]JobSchedule, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	ids, err := redis.Strings(c.Do("ZRANGEBYSCORE", s.key("job_schedules_due"), "-inf", redisScore(now)))
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]JobSchedule, 0, len(ids))
+	for _, id := range ids {
+		schID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		sch, err := s.jobSchedule(c, schID)
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sch.Enabled && !sch.NextRunAt.After(now) {
+			ss = append(ss, sch)
+		}
+	}
+
+	return ss, nil
+}
+
+// MaterializeJobSchedule inserts job j for the due run of job schedule sch and
+// moves the schedule to its next run at `next`. A zero `next` disables the
+// schedule. Returns ErrScheduleAlreadyRun if the run has already been
+// materialized, e.g. by another instance.
+func (s *RedisStore) MaterializeJobSchedule(sch *JobSchedule, next time.Time, j *Job) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	for i := 0; i < redisWatchRetries; i++ {
+		if _, err := c.Do("WATCH", s.key("job_schedules")); err != nil {
+			return err
+		}
+
+		stored, err := s.jobSchedule(c, sch.ID)
+		if err == gorm.ErrRecordNotFound {
+			_, _ = c.Do("UNWATCH")
+			return ErrScheduleAlreadyRun
+		}
+		if err != nil {
+			_, _ = c.Do("UNWATCH")
+			return err
+		}
+
+		// The run count identifies the run, only one instance can claim it
+		if stored.RunCount != sch.RunCount {
+			_, _ = c.Do("UNWATCH")
+			return ErrScheduleAlreadyRun
+		}
+
+		now := time.Now()
+
+		prepareInsert(j)
+
+		stored.RunCount++
+		stored.NextRunAt = next
+		stored.LastRunAt = &now
+		stored.LastJobID = &j.ID
+		if next.IsZero() {
+			stored.Enabled = false
+		}
+
+		if err := c.Send("MULTI"); err != nil {
+			return err
+		}
+
+		if err := s.sendSaveJobSchedule(c, &stored); err != nil {
+			_, _ = c.Do("DISCARD")
+			return err
+		}
+
+		if err := s.sendSaveJob(c, nil, j); err != nil {
+			_, _ = c.Do("DISCARD")
+			return err
+		}
+
+		_, err = redis.Values(c.Do("EXEC"))
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		sch.RunCount = stored.RunCount
+		sch.NextRunAt = stored.NextRunAt
+		sch.LastRunAt = stored.LastRunAt
+		sch.LastJobID = stored.LastJobID
+		sch.Enabled = stored.Enabled
+
+		return nil
+	}
+
+	return errRedisConflict
+}
+
+// matchesPruneFilter matches the jobs selected by pruneQuery.
+func matchesPruneFilter(j *Job, f PruneFilter) bool {
+	if !containsState(f.States, j.State) || !j.UpdatedAt.Before(f.UpdatedBefore) {
+		return false
+	}
+
+	if f.JobType != "" && j.Type != f.JobType {
+		return false
+	}
+
+	for _, p := range f.Exclude {
+		if p.covers(RetentionPolicy{JobType: j.Type, State: j.State}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pruneCandidates returns up to `limit` jobs matching f, least recently
+// updated first.
+func (s *RedisStore) pruneCandidates(c redis.Conn, f PruneFilter, limit int) ([]Job, error) {
+	var jj []Job
+
+	for _, state := range f.States {
+		n := 0
+
+		for offset := 0; n < limit; offset += redisBatchSize {
+			ids, err := redis.Strings(c.Do("ZRANGEBYSCORE", s.stateKey(state), "-inf", "("+redisScore(f.UpdatedBefore), "LIMIT", offset, redisBatchSize))
+			if err != nil {
+				return nil, err
+			}
+
+			batch, err := s.jobsByID(c, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range batch {
+				if n < limit && matchesPruneFilter(&batch[i], f) {
+					jj = append(jj, batch[i])
+					n++
+				}
+			}
+
+			if len(ids) < redisBatchSize {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(jj, func(a, b int) bool {
+		return jj[a].UpdatedAt.Before(jj[b].UpdatedAt)
+	})
+
+	if len(jj) > limit {
+		jj = jj[:limit]
+	}
+
+	return jj, nil
+}
+
+// ArchiveJobs moves up to `limit` jobs matching f to the `jobs_archive` hash.
+// Returns the number of archived jobs.
+func (s *RedisStore) ArchiveJobs(f PruneFilter, limit int) (int, error) {
+	return s.deleteJobs(f, limit, nil, true)
+}
+
+// DeleteJobs permanently deletes up to `limit` jobs matching f, along with
+// their state transition history. beforeDelete, if not nil, is called with
+// the jobs before they are deleted; returning an error aborts the deletion.
+// Returns the number of deleted jobs.
+func (s *RedisStore) DeleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error) (int, error) {
+	return s.deleteJobs(f, limit, beforeDelete, false)
+}
+
+func (s *RedisStore) deleteJobs(f PruneFilter, limit int, beforeDelete func([]Job) error, archive bool) (int, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	for i := 0; i < redisWatchRetries; i++ {
+		candidates, err := s.pruneCandidates(c, f, limit)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(candidates) == 0 {
+			return 0, nil
+		}
+
+		keys := make([]interface{}, len(candidates))
+		ids := make([]string, len(candidates))
+		for i, j := range candidates {
+			keys[i] = s.jobKey(j.ID)
+			ids[i] = j.ID.String()
+		}
+
+		if _, err := c.Do("WATCH", keys...); err != nil {
+			return 0, err
+		}
+
+		// Re-check the jobs now that they are watched
+		current, err := s.jobsByID(c, ids)
+		if err != nil {
+			_, _ = c.Do("UNWATCH")
+			return 0, err
+		}
+
+		jj := current[:0]
+		for _, j := range current {
+			if matchesPruneFilter(&j, f) {
+				jj = append(jj, j)
+			}
+		}
+
+		if len(jj) == 0 {
+			_, _ = c.Do("UNWATCH")
+			continue
+		}
+
+		events := make([][][]byte, len(jj))
+		for i, j := range jj {
+			events[i], err = redis.ByteSlices(c.Do("LRANGE", s.key("job_events", j.ID.String()), 0, -1))
+			if err != nil {
+				_, _ = c.Do("UNWATCH")
+				return 0, err
+			}
+		}
+
+		if beforeDelete != nil {
+			if err := beforeDelete(jj); err != nil {
+				_, _ = c.Do("UNWATCH")
+				return 0, err
+			}
+		}
+
+		if err := s.sendDeleteJobs(c, jj, events, archive); err != nil {
+			_, _ = c.Do("DISCARD")
+			return 0, err
+		}
+
+		_, err = redis.Values(c.Do("EXEC"))
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		return len(jj), nil
+	}
+
+	return 0, errRedisConflict
+}
+
+func (s *RedisStore) sendDeleteJobs(c redis.Conn, jj []Job, events [][][]byte, archive bool) error {
+	if err := c.Send("MULTI"); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for i, j := range jj {
+		id := j.ID.String()
+
+		if archive {
+			b, err := json.Marshal(NewArchivedJob(j, now))
+			if err != nil {
+				return err
+			}
+			if err := c.Send("HSET", s.key("jobs_archive"), id, b); err != nil {
+				return err
+			}
+		}
+
+		for _, e := range events[i] {
+			if err := c.Send("ZREM", s.key("events"), e); err != nil {
+				return err
+			}
+		}
+
+		commands := [][]interface{}{
+			{"DEL", s.jobKey(j.ID), s.key("job_events", id)},
+			{"ZREM", s.key("jobs"), id},
+			{"ZREM", s.stateKey(j.State), id},
+			{"SREM", s.key("queued"), id},
+		}
+
+		for _, cmd := range commands {
+			if err := c.Send(cmd[0].(string), cmd[1:]...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
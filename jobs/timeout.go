@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default time a job executor is given before its context is cancelled and
+// the execution is recorded as timed out.
+const defaultJobExecutionTimeout = 5 * time.Minute
+
+// ExecutorOption configures a job executor registered with RegisterExecutor.
+type ExecutorOption func(*executorConfig)
+
+type executorConfig struct {
+	timeout time.Duration
+	notify  bool
+}
+
+// WithExecutorTimeout sets the time an executor is given to execute a job.
+// It can be overridden per job type with WithJobTypeExecutionTimeouts.
+func WithExecutorTimeout(d time.Duration) ExecutorOption {
+	return func(c *executorConfig) {
+		c.timeout = d
+	}
+}
+
+// ParseJobTypeExecutionTimeout parses a per job type execution timeout in the
+// format "<jobType>:<timeout>", e.g. "transaction:10m".
+func ParseJobTypeExecutionTimeout(s string) (string, time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid job execution timeout %q, expected <jobType>:<timeout>", s)
+	}
+
+	jobType := strings.TrimSpace(parts[0])
+	if jobType == "" {
+		return "", 0, fmt.Errorf("invalid job execution timeout %q, empty job type", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid job execution timeout %q: %w", s, err)
+	}
+
+	if d <= 0 {
+		return "", 0, fmt.Errorf("invalid job execution timeout %q, expected a positive timeout", s)
+	}
+
+	return jobType, d, nil
+}
+
+// executionTimeout returns the time an executor is given to execute a job
+// of type jobType: the configured timeout of the job type, the timeout the
+// executor was registered with or the default timeout, in that order.
+func (wp *WorkerPoolImpl) executionTimeout(jobType string) time.Duration {
+	if d, ok := wp.jobTypeTimeouts[jobType]; ok {
+		return d
+	}
+
+	if d, ok := wp.executorTimeouts[jobType]; ok && d > 0 {
+		return d
+	}
+
+	if wp.defaultExecutionTimeout > 0 {
+		return wp.defaultExecutionTimeout
+	}
+
+	return defaultJobExecutionTimeout
+}
@@ -0,0 +1,438 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is an endpoint receiving job status updates.
+type WebhookSubscription struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	URL       string         `gorm:"column:url"`
+	Secret    string         `gorm:"column:secret"`
+	JobTypes  pq.StringArray `gorm:"column:job_types;type:text[]"` // Empty matches all job types
+	States    pq.StringArray `gorm:"column:states;type:text[]"`    // Empty matches all terminal states
+	Enabled   bool           `gorm:"column:enabled"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// Matches returns true if the status of job j should be delivered to the
+// subscription.
+func (s WebhookSubscription) Matches(j *Job) bool {
+	if !s.Enabled {
+		return false
+	}
+	return matchesAny(s.JobTypes, j.Type) && matchesAny(s.States, string(j.State))
+}
+
+func matchesAny(filter []string, v string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == v {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a single attempt to deliver a job status.
+type WebhookDelivery struct {
+	ID             uint64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	JobID          uuid.UUID  `gorm:"column:job_id;type:uuid;index" json:"jobId"` // The send_job_status job
+	ParentJobID    uuid.UUID  `gorm:"column:parent_job_id;type:uuid;index" json:"parentJobId"`
+	SubscriptionID *uuid.UUID `gorm:"column:subscription_id;type:uuid;index" json:"subscriptionId"`
+	URL            string     `gorm:"column:url" json:"url"`
+	Attempt        int        `gorm:"column:attempt" json:"attempt"`
+	StatusCode     int        `gorm:"column:status_code" json:"statusCode"`
+	Success        bool       `gorm:"column:success" json:"success"`
+	Error          string     `gorm:"column:error" json:"error"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// DeliveryFilter narrows down a webhook delivery listing.
+type DeliveryFilter struct {
+	SubscriptionID *uuid.UUID
+	ParentJobID    *uuid.UUID
+	FailedOnly     bool
+}
+
+// Webhook subscription HTTP request
+type WebhookSubscriptionJSONRequest struct {
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret"`
+	JobTypes []string `json:"jobTypes"`
+	States   []State  `json:"states"`
+	Enabled  *bool    `json:"enabled"`
+}
+
+// Webhook subscription HTTP response
+type WebhookSubscriptionJSONResponse struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when creating a subscription
+	JobTypes  []string  `json:"jobTypes"`
+	States    []string  `json:"states"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s WebhookSubscription) ToJSONResponse() WebhookSubscriptionJSONResponse {
+	return WebhookSubscriptionJSONResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		JobTypes:  []string(s.JobTypes),
+		States:    []string(s.States),
+		Enabled:   s.Enabled,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+type WebhookService interface {
+	List() ([]WebhookSubscription, error)
+	Create(req WebhookSubscriptionJSONRequest) (*WebhookSubscription, error)
+	Details(id string) (*WebhookSubscription, error)
+	Update(id string, req WebhookSubscriptionJSONRequest) (*WebhookSubscription, error)
+	Delete(id string) error
+	Deliveries(f DeliveryFilter, limit, offset int) ([]WebhookDelivery, error)
+	Redeliver(deliveryID string) (*Job, error)
+}
+
+// WebhookServiceImpl defines the API for webhook subscription HTTP handlers.
+type WebhookServiceImpl struct {
+	store Store
+	wp    WorkerPool
+}
+
+// NewWebhookService initiates a new webhook subscription service.
+func NewWebhookService(store Store, wp WorkerPool) WebhookService {
+	return &WebhookServiceImpl{store, wp}
+}
+
+// List returns all webhook subscriptions.
+func (s *WebhookServiceImpl) List() ([]WebhookSubscription, error) {
+	log.Trace("List webhook subscriptions")
+	return s.store.WebhookSubscriptions()
+}
+
+// Create adds a new webhook subscription. A secret is generated if one is
+// not given.
+func (s *WebhookServiceImpl) Create(req WebhookSubscriptionJSONRequest) (*WebhookSubscription, error) {
+	log.WithFields(log.Fields{"url": req.URL}).Trace("Create webhook subscription")
+
+	sub := WebhookSubscription{Enabled: true}
+
+	if err := applyWebhookSubscriptionRequest(&sub, req); err != nil {
+		return nil, err
+	}
+
+	if sub.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		sub.Secret = secret
+	}
+
+	if err := s.store.InsertWebhookSubscription(&sub); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// Details returns a specific webhook subscription.
+func (s *WebhookServiceImpl) Details(id string) (*WebhookSubscription, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Webhook subscription details")
+
+	subID, err := uuid.Parse(id)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid webhook subscription id"),
+		}
+		return nil, err
+	}
+
+	sub, err := s.store.WebhookSubscription(subID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("webhook subscription not found"),
+			}
+		}
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// Update replaces the url, filters and enabled status of a webhook
+// subscription. The secret is only replaced if a new one is given.
+func (s *WebhookServiceImpl) Update(id string, req WebhookSubscriptionJSONRequest) (*WebhookSubscription, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Update webhook subscription")
+
+	sub, err := s.Details(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyWebhookSubscriptionRequest(sub, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateWebhookSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Delete removes a webhook subscription. Pending deliveries to it are
+// dropped.
+func (s *WebhookServiceImpl) Delete(id string) error {
+	log.WithFields(log.Fields{"id": id}).Trace("Delete webhook subscription")
+
+	sub, err := s.Details(id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteWebhookSubscription(sub.ID)
+}
+
+// Deliveries returns webhook delivery attempts, newest first.
+func (s *WebhookServiceImpl) Deliveries(f DeliveryFilter, limit, offset int) ([]WebhookDelivery, error) {
+	log.WithFields(log.Fields{"filter": f, "limit": limit, "offset": offset}).Trace("List webhook deliveries")
+
+	o := datastore.ParseListOptions(limit, offset)
+
+	return s.store.WebhookDeliveries(f, o)
+}
+
+// Redeliver schedules a new delivery of the same payload to the same target
+// as the given delivery attempt.
+func (s *WebhookServiceImpl) Redeliver(deliveryID string) (*Job, error) {
+	log.WithFields(log.Fields{"deliveryID": deliveryID}).Trace("Redeliver webhook")
+
+	id, err := strconv.ParseUint(deliveryID, 10, 64)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid delivery id"),
+		}
+		return nil, err
+	}
+
+	delivery, err := s.store.WebhookDelivery(id)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("delivery not found"),
+			}
+		}
+		return nil, err
+	}
+
+	original, err := s.store.Job(delivery.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.wp.CreateJob(SendJobStatusJobType, "", WithAttributes(original.Attributes))
+	if err != nil {
+		return nil, err
+	}
+
+	// Deliver the same content as originally
+	job.Result = original.Result
+
+	if err := s.store.UpdateJob(job); err != nil {
+		return nil, err
+	}
+
+	if err := s.wp.Schedule(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func applyWebhookSubscriptionRequest(sub *WebhookSubscription, req WebhookSubscriptionJSONRequest) error {
+	u, err := url.ParseRequestURI(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid webhook url"),
+		}
+	}
+
+	states := make(pq.StringArray, len(req.States))
+	for i, state := range req.States {
+		// Notifications are only sent for jobs in a terminal state
+		if !state.Terminal() {
+			return &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid job state: %s, expected one of COMPLETE, FAILED, CANCELLED", state),
+			}
+		}
+		states[i] = string(state)
+	}
+
+	sub.URL = u.String()
+	sub.JobTypes = pq.StringArray(req.JobTypes)
+	sub.States = states
+
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// notificationTargets returns the delivery targets for the status of job j:
+// the global job status webhook, the callback url of the job and matching
+// webhook subscriptions.
+func (wp *WorkerPoolImpl) notificationTargets(j *Job) ([]notificationTarget, error) {
+	var targets []notificationTarget
+
+	callbackURL := j.CallbackURL()
+
+	if wp.notificationConfig.ShouldSendJobStatus() && !(callbackURL != "" && wp.notificationConfig.callbackReplacesWebhook()) {
+		targets = append(targets, notificationTarget{ParentJobID: j.ID})
+	}
+
+	if callbackURL != "" {
+		targets = append(targets, notificationTarget{ParentJobID: j.ID, CallbackURL: callbackURL})
+	}
+
+	subs, err := wp.store.WebhookSubscriptions()
+	if err != nil {
+		return targets, err
+	}
+
+	for i := range subs {
+		if subs[i].Matches(j) {
+			id := subs[i].ID
+			targets = append(targets, notificationTarget{ParentJobID: j.ID, SubscriptionID: &id})
+		}
+	}
+
+	return targets, nil
+}
+
+// deliverJobStatus delivers the content of a send_job_status job to its
+// target and records the delivery attempt.
+func (wp *WorkerPoolImpl) deliverJobStatus(ctx context.Context, j *Job) error {
+	var target notificationTarget
+	if len(j.Attributes) > 0 {
+		if err := json.Unmarshal(j.Attributes, &target); err != nil {
+			return PermanentFailure(fmt.Errorf("invalid notification target: %w", err))
+		}
+	}
+
+	delivery := &WebhookDelivery{
+		JobID:          j.ID,
+		ParentJobID:    target.ParentJobID,
+		SubscriptionID: target.SubscriptionID,
+		Attempt:        j.ExecCount,
+	}
+
+	var statusCode int
+	var err error
+
+	if target.SubscriptionID != nil {
+		sub, subErr := wp.store.WebhookSubscription(*target.SubscriptionID)
+		if subErr != nil {
+			if subErr.Error() == "record not found" {
+				return PermanentFailure(fmt.Errorf("webhook subscription %s not found", *target.SubscriptionID))
+			}
+			return subErr
+		}
+
+		if !sub.Enabled {
+			return PermanentFailure(fmt.Errorf("webhook subscription %s disabled", sub.ID))
+		}
+
+		delivery.URL = sub.URL
+		statusCode, err = sendWebhook(ctx, sub.URL, wp.notificationConfig.timeout(), sub.Secret, j.Result)
+	} else if target.CallbackURL != "" {
+		// Callbacks are signed with the secret of the global webhook
+		delivery.URL = target.CallbackURL
+		statusCode, err = sendWebhook(ctx, target.CallbackURL, wp.notificationConfig.timeout(), wp.notificationConfig.secret(), j.Result)
+	} else {
+		if !wp.notificationConfig.ShouldSendJobStatus() {
+			// Global webhook no longer configured
+			return nil
+		}
+
+		delivery.URL = wp.notificationConfig.jobStatusWebhookUrl.String()
+		statusCode, err = wp.notificationConfig.sendJobStatusWebhook(ctx, j.Result)
+	}
+
+	delivery.StatusCode = statusCode
+	delivery.Success = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if storeErr := wp.store.InsertWebhookDelivery(delivery); storeErr != nil {
+		j.logEntry(wp.logger.WithFields(log.Fields{
+			"package":  "jobs",
+			"function": "WorkerPool.deliverJobStatus",
+			"error":    storeErr,
+		})).Warn("Could not record webhook delivery")
+	}
+
+	return err
+}
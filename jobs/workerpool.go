@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -14,12 +15,34 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
 	wallet_errors "github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/system"
+	"github.com/google/uuid"
 )
 
 var (
 	ErrInvalidJobType   = errors.New("invalid job type")
 	ErrPermanentFailure = errors.New("permanent failure")
 
+	// ErrJobNotCancellable is returned when trying to cancel a job that is
+	// not in INIT, SCHEDULED, NO_AVAILABLE_WORKERS or ERROR state.
+	ErrJobNotCancellable = errors.New("job is not cancellable")
+
+	// ErrJobNotRetryable is returned when trying to retry a job that is not
+	// in FAILED state.
+	ErrJobNotRetryable = errors.New("job is not retryable")
+
+	// ErrLeaseLost is returned when a job has been accepted by another
+	// worker after the lease of the current worker expired.
+	ErrLeaseLost = errors.New("job lease lost")
+
+	// ErrJobTimeout is recorded when an executor does not finish within the
+	// execution timeout of its job type. Timed out jobs are retried.
+	ErrJobTimeout = errors.New("job execution timed out")
+
+	// ErrJobInterrupted is recorded when an execution is cancelled because
+	// the workerpool was shut down. Interrupted jobs are re-scheduled
+	// immediately.
+	ErrJobInterrupted = errors.New("job execution interrupted by shutdown")
+
 	// maxJobErrorCount is the maximum number of times a Job can be tried to
 	// execute before considering it completely failed.
 	defaultMaxJobErrorCount = 10
@@ -27,26 +50,35 @@ var (
 	// Poll DB for new schedulable jobs every 30s.
 	defaultDBJobPollInterval = 30 * time.Second
 
-	// Grace time period before re-scheduling jobs that are in state INIT or
-	// ACCEPTED. These are jobs where the executor processing has been
-	// unexpectedly disrupted (such as bug, dead node, disconnected
-	// networking etc.).
+	// Grace time period before re-scheduling jobs that are in state INIT.
+	// These are jobs that were created but never scheduled due to an
+	// unexpected disruption (such as bug, dead node, disconnected networking
+	// etc.). ACCEPTED jobs are re-scheduled once their lease expires.
 	defaultAcceptedGracePeriod = 3 * time.Minute
 
 	// Grace time period before re-scheduling jobs that are up for immediate
-	// restart (such as NO_AVAILABLE_WORKERS or ERROR).
+	// restart (NO_AVAILABLE_WORKERS). Jobs in ERROR state are re-scheduled
+	// according to their retry backoff.
 	defaultReSchedulableGracePeriod = 1 * time.Minute
 )
 
 type ExecutorFunc func(ctx context.Context, j *Job) error
 
 type WorkerPool interface {
-	RegisterExecutor(jobType string, executorF ExecutorFunc)
+	RegisterExecutor(jobType string, executorF ExecutorFunc, opts ...ExecutorOption)
 	CreateJob(jobType, txID string, opts ...JobOption) (*Job, error)
+	HasExecutor(jobType string) bool
 	Schedule(j *Job) error
+	Cancel(id uuid.UUID) (*Job, error)
+	Transitions() <-chan struct{}
+	Retry(id uuid.UUID) (*Job, error)
+	RetryFailed(f RetryFilter) ([]Job, error)
+	Prune() (int, error)
 	Status() (WorkerPoolStatus, error)
+	CheckBackpressure() error
 	Start()
 	Stop(wait bool)
+	Shutdown(ctx context.Context) error
 	Capacity() uint
 	QueueSize() uint
 }
@@ -54,8 +86,9 @@ type WorkerPool interface {
 type WorkerPoolImpl struct {
 	started       bool
 	wg            *sync.WaitGroup
-	jobChan       chan *Job
+	queue         *jobQueue
 	stopChan      chan struct{}
+	stopOnce      sync.Once
 	context       context.Context
 	cancelContext context.CancelFunc
 	executors     map[string]ExecutorFunc
@@ -64,20 +97,48 @@ type WorkerPoolImpl struct {
 	store       Store
 	capacity    uint
 	workerCount uint
+	instanceID  string
 
 	maxJobErrorCount         int
 	dbJobPollInterval        time.Duration
 	acceptedGracePeriod      time.Duration
 	reSchedulableGracePeriod time.Duration
+	leaseDuration            time.Duration
+	retryBackoff             RetryBackoff
+	jobTypeRetryBackoffs     map[string]RetryBackoff
+	jobTypePriorities        map[string]int
+	jobTypeConcurrency       map[string]int
+	jobTypeTimeouts          map[string]time.Duration
+	executorTimeouts         map[string]time.Duration
+	notifyingJobTypes        map[string]bool
+	defaultExecutionTimeout  time.Duration
+	retentionPolicies        []RetentionPolicy
+	archiveMode              string
+	archiveFile              string
+	pruneInterval            time.Duration
+	pruneBatchSize           int
+	queueHighWaterMark       int
+	backlogHighWaterMark     int
+	backpressureRetryAfter   time.Duration
+	backlog                  backlogCounter
 
 	notificationConfig *NotificationConfig
 	systemService      system.Service
+
+	// If set, schedulable jobs are claimed from the DB as soon as the
+	// listener announces them instead of on the next poll
+	jobListener  JobListener
+	wake         chan struct{}
+	claimBacklog int32 // 1 if there may be more jobs to claim once a worker is done
+
+	transitions broadcaster
 }
 
 type WorkerPoolStatus struct {
 	JobQueueStatus
-	Capacity    int `json:"poolCapacity"`
-	WorkerCount int `json:"workerCount"`
+	Capacity    int                           `json:"poolCapacity"`
+	WorkerCount int                           `json:"workerCount"`
+	JobTypes    map[string]JobTypeQueueStatus `json:"jobTypes"` // In-memory queue depth per job type
 }
 
 func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPoolOption) WorkerPool {
@@ -85,8 +146,8 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 
 	pool := &WorkerPoolImpl{
 		wg:            &sync.WaitGroup{},
-		jobChan:       make(chan *Job, capacity),
 		stopChan:      make(chan struct{}),
+		wake:          make(chan struct{}, 1),
 		context:       ctx,
 		cancelContext: cancel,
 		executors:     make(map[string]ExecutorFunc),
@@ -100,6 +161,23 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		dbJobPollInterval:        defaultDBJobPollInterval,
 		acceptedGracePeriod:      defaultAcceptedGracePeriod,
 		reSchedulableGracePeriod: defaultReSchedulableGracePeriod,
+		leaseDuration:            defaultJobLeaseDuration,
+		retryBackoff: RetryBackoff{
+			Min:    defaultRetryBackoffMin,
+			Max:    defaultRetryBackoffMax,
+			Factor: defaultRetryBackoffFactor,
+		},
+		jobTypeRetryBackoffs:    make(map[string]RetryBackoff),
+		jobTypePriorities:       make(map[string]int),
+		jobTypeConcurrency:      make(map[string]int),
+		jobTypeTimeouts:         make(map[string]time.Duration),
+		executorTimeouts:        make(map[string]time.Duration),
+		notifyingJobTypes:       make(map[string]bool),
+		defaultExecutionTimeout: defaultJobExecutionTimeout,
+		archiveMode:             JobArchiveNone,
+		pruneInterval:           defaultJobPruneInterval,
+		pruneBatchSize:          defaultJobPruneBatchSize,
+		backpressureRetryAfter:  defaultBackpressureRetryAfter,
 
 		notificationConfig: &NotificationConfig{},
 	}
@@ -109,6 +187,12 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		opt(pool)
 	}
 
+	if pool.instanceID == "" {
+		pool.instanceID = defaultInstanceID()
+	}
+
+	pool.queue = newJobQueue(capacity, pool.jobTypePriorities, pool.jobTypeConcurrency)
+
 	// Register asynchronous job executor.
 	pool.RegisterExecutor(SendJobStatusJobType, pool.executeSendJobStatus)
 
@@ -129,6 +213,8 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 		switch r.State {
 		case Init:
 			status.JobsInit = r.Count
+		case Scheduled:
+			status.JobsScheduled = r.Count
 		case NoAvailableWorkers:
 			status.JobsNotAccepted = r.Count
 		case Accepted:
@@ -139,6 +225,8 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 			status.JobsFailed = r.Count
 		case Complete:
 			status.JobsCompleted = r.Count
+		case Cancelled:
+			status.JobsCancelled = r.Count
 		default:
 			continue
 		}
@@ -146,11 +234,13 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 
 	status.Capacity = int(wp.capacity)
 	status.WorkerCount = int(wp.workerCount)
+	status.JobTypes = wp.queue.status()
 
 	return status, nil
 }
 
 // CreateJob constructs a new Job for type `jobType` ready for scheduling.
+// Use WithRunAt to defer the execution of the job.
 func (wp *WorkerPoolImpl) CreateJob(jobType, txID string, opts ...JobOption) (*Job, error) {
 	// Init job
 	job := &Job{
@@ -169,11 +259,56 @@ func (wp *WorkerPoolImpl) CreateJob(jobType, txID string, opts ...JobOption) (*J
 		return nil, err
 	}
 
+	wp.recordTransition(job, "", "")
+
 	return job, nil
 }
 
-func (wp *WorkerPoolImpl) RegisterExecutor(jobType string, executorF ExecutorFunc) {
+// RegisterExecutor registers the executor for jobs of type jobType. Use
+// WithExecutorTimeout to give the executor more or less time than the default
+// execution timeout.
+func (wp *WorkerPoolImpl) RegisterExecutor(jobType string, executorF ExecutorFunc, opts ...ExecutorOption) {
+	var c executorConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if wp.executorTimeouts == nil {
+		wp.executorTimeouts = make(map[string]time.Duration)
+	}
+
+	if wp.notifyingJobTypes == nil {
+		wp.notifyingJobTypes = make(map[string]bool)
+	}
+
 	wp.executors[jobType] = executorF
+	wp.executorTimeouts[jobType] = c.timeout
+	wp.notifyingJobTypes[jobType] = c.notify
+}
+
+// WithStatusNotifications makes jobs of the executor's type send a status
+// notification when they are completed, failed or cancelled, like executors
+// setting Job.ShouldSendNotification. Only the option is known when a job is
+// cancelled before it has been executed.
+func WithStatusNotifications() ExecutorOption {
+	return func(c *executorConfig) {
+		c.notify = true
+	}
+}
+
+// shouldSendNotification tells whether a status notification is sent for
+// job once it reaches a terminal state.
+func (wp *WorkerPoolImpl) shouldSendNotification(job *Job) bool {
+	if job.Type == SendJobStatusJobType {
+		return false
+	}
+	return job.ShouldSendNotification || wp.notifyingJobTypes[job.Type]
+}
+
+// HasExecutor returns true if an executor has been registered for jobType.
+func (wp *WorkerPoolImpl) HasExecutor(jobType string) bool {
+	_, ok := wp.executors[jobType]
+	return ok
 }
 
 // Schedule will try to immediately schedule the run of a job
@@ -185,6 +320,12 @@ func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 
 	entry.Debug("Scheduling job")
 
+	if j.State == Scheduled && j.NextRunAt.After(time.Now()) {
+		// Not due yet; let dbScheduler handle this job
+		entry.WithFields(log.Fields{"runAt": j.NextRunAt}).Debug("Job scheduled to run later")
+		return nil
+	}
+
 	if halted, err := wp.systemHalted(); err != nil {
 		return fmt.Errorf("error while getting system settings: %w", err)
 	} else if halted {
@@ -194,11 +335,18 @@ func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 	}
 
 	if !wp.tryEnqueue(j, false) {
+		from := j.State
 		j.State = NoAvailableWorkers
+		j.NextRunAt = time.Now().Add(wp.reSchedulableGracePeriod)
+		if wp.jobListener != nil {
+			// Up for grabs for other instances right away
+			j.NextRunAt = time.Now()
+		}
 		entry.Debug("No available workers, deferring")
 		if err := wp.store.UpdateJob(j); err != nil {
 			return err
 		}
+		wp.recordTransition(j, from, "")
 	} else {
 		entry.Debug("Successfully scheduled job")
 	}
@@ -206,31 +354,127 @@ func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 	return nil
 }
 
+// Cancel moves a job that has not yet been successfully executed into
+// CANCELLED state, preventing any further execution attempts.
+func (wp *WorkerPoolImpl) Cancel(id uuid.UUID) (*Job, error) {
+	job, err := wp.store.Job(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := job.logEntry(wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.Cancel",
+	}))
+
+	from := job.State
+
+	if err := wp.store.CancelJob(&job); err != nil {
+		return nil, err
+	}
+
+	wp.recordTransition(&job, from, "")
+
+	entry.Debug("Cancelled job")
+
+	if wp.shouldSendNotification(&job) {
+		if err := wp.scheduleJobStatusNotification(&job); err != nil {
+			entry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Could not schedule a status update notification for job")
+		}
+	}
+
+	return &job, nil
+}
+
+// Retry moves a FAILED job back to INIT state, resets its execution count and
+// schedules it. The job's error history is kept.
+func (wp *WorkerPoolImpl) Retry(id uuid.UUID) (*Job, error) {
+	job, err := wp.store.Job(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wp.retry(&job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// RetryFailed retries all FAILED jobs matching the given filter.
+func (wp *WorkerPoolImpl) RetryFailed(f RetryFilter) ([]Job, error) {
+	jobs, err := wp.store.FailedJobs(f)
+	if err != nil {
+		return nil, err
+	}
+
+	retried := make([]Job, 0, len(jobs))
+
+	for i := range jobs {
+		if err := wp.retry(&jobs[i]); err != nil {
+			if errors.Is(err, ErrJobNotRetryable) {
+				// State changed in between, skip
+				continue
+			}
+			return retried, err
+		}
+		retried = append(retried, jobs[i])
+	}
+
+	return retried, nil
+}
+
+func (wp *WorkerPoolImpl) retry(job *Job) error {
+	entry := job.logEntry(wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.retry",
+	}))
+
+	if err := wp.store.RetryJob(job); err != nil {
+		return err
+	}
+
+	wp.recordTransition(job, Failed, "")
+
+	entry.Debug("Retrying failed job")
+
+	return wp.Schedule(job)
+}
+
 func (wp *WorkerPoolImpl) Start() {
 	if !wp.started {
 		wp.started = true
 		wp.startWorkers()
 		wp.startDBJobScheduler()
+		wp.startPruner()
 	}
 }
 
 func (wp *WorkerPoolImpl) Stop(wait bool) {
-	close(wp.stopChan)
-	// Give time for the stop channel to signal before closing job channel
+	wp.closeStopChan()
+	// Give time for the stop channel to signal before closing job queue
 	time.Sleep(time.Millisecond * 100)
-	close(wp.jobChan)
+	wp.queue.close()
 	if wait {
 		wp.cancelContext()
 		wp.wg.Wait()
 	}
 }
 
+// closeStopChan signals the workerpool goroutines to stop. Both Stop and
+// Shutdown may be called, in any order.
+func (wp *WorkerPoolImpl) closeStopChan() {
+	wp.stopOnce.Do(func() { close(wp.stopChan) })
+}
+
 func (wp *WorkerPoolImpl) Capacity() uint {
 	return wp.capacity
 }
 
 func (wp *WorkerPoolImpl) QueueSize() uint {
-	return uint(len(wp.jobChan))
+	return uint(wp.queue.len())
 }
 
 func (wp *WorkerPoolImpl) accept(job *Job) bool {
@@ -239,13 +483,17 @@ func (wp *WorkerPoolImpl) accept(job *Job) bool {
 		"function": "WorkerPool.accept",
 	}))
 
-	if err := wp.store.AcceptJob(job, wp.acceptedGracePeriod); err != nil {
+	from := job.State
+
+	if err := wp.store.AcceptJob(job, wp.instanceID, wp.lease()); err != nil {
 		entry.
 			WithFields(log.Fields{"error": err}).
 			Warn("Failed to accept job")
 		return false
 	}
 
+	wp.recordTransition(job, from, "")
+
 	return true
 }
 
@@ -257,32 +505,64 @@ func (wp *WorkerPoolImpl) systemHalted() (bool, error) {
 }
 
 func (wp *WorkerPoolImpl) startDBJobScheduler() {
+	var notifications <-chan struct{}
+	if wp.jobListener != nil {
+		notifications = wp.jobListener.Notifications()
+	}
+
 	go func() {
-		var restTime time.Duration
+		nextPoll := time.Now()
+		var nextDue *time.Time
 
 	jobPoolLoop:
 		for {
+			wakeAt := nextPoll
+			if nextDue != nil && nextDue.Before(wakeAt) {
+				wakeAt = *nextDue
+			}
+
 			select {
-			case <-time.After(restTime):
+			case <-time.After(time.Until(wakeAt)):
+			case <-notifications:
+			case <-wp.wake:
 			case <-wp.stopChan:
 				break jobPoolLoop
 			}
 
+			begin := time.Now()
+			polling := !begin.Before(nextPoll)
+			if polling {
+				nextPoll = begin.Add(wp.dbJobPollInterval)
+			}
+
 			if halted, err := wp.systemHalted(); err != nil {
 				wp.logger.
 					WithFields(log.Fields{"error": err}).
 					Warn("Could not get system settings from DB")
-				restTime = wp.dbJobPollInterval
 				continue
 			} else if halted {
-				restTime = wp.dbJobPollInterval
 				continue
 			}
 
-			begin := time.Now()
+			if polling {
+				wp.materializeSchedules()
+			}
+
+			if wp.jobListener != nil {
+				// Push based scheduling. Jobs becoming due later (retry
+				// backoff, run time, lease expiry) are not announced, wake
+				// up when the next one is due.
+				wp.claimJobs()
+				nextDue = wp.nextDueAt()
+				continue
+			}
+
+			if !polling {
+				continue
+			}
 
 			o := datastore.ParseListOptions(0, 0)
-			jobs, err := wp.store.SchedulableJobs(wp.acceptedGracePeriod, wp.reSchedulableGracePeriod, o)
+			jobs, err := wp.store.SchedulableJobs(wp.acceptedGracePeriod, o)
 			if err != nil {
 				wp.logger.
 					WithFields(log.Fields{"error": err}).
@@ -290,12 +570,12 @@ func (wp *WorkerPoolImpl) startDBJobScheduler() {
 				continue
 			}
 
+			// Enqueue higher priority jobs first in case the queue fills up
+			wp.queue.sortByPriority(jobs)
+
 			for i := range jobs {
 				wp.tryEnqueue(&jobs[i], true)
 			}
-
-			elapsed := time.Since(begin)
-			restTime = wp.dbJobPollInterval - elapsed
 		}
 	}()
 }
@@ -305,12 +585,21 @@ func (wp *WorkerPoolImpl) startWorkers() {
 		wp.wg.Add(1)
 		go func() {
 			defer wp.wg.Done()
-			for job := range wp.jobChan {
+			for {
+				job := wp.queue.pop()
 				if job == nil {
 					break
 				}
 
-				if err := wp.process(job); err != nil {
+				err := wp.process(job)
+				wp.queue.done(job)
+
+				if atomic.CompareAndSwapInt32(&wp.claimBacklog, 1, 0) || job.State == Error {
+					// A retry is due later, the scheduler then learns when
+					wp.wakeScheduler()
+				}
+
+				if err != nil {
 					// Handle critical processing errors
 
 					entry := job.logEntry(wp.logger.WithFields(log.Fields{
@@ -342,22 +631,11 @@ func (wp *WorkerPoolImpl) startWorkers() {
 }
 
 func (wp *WorkerPoolImpl) tryEnqueue(job *Job, block bool) bool {
-	if block {
-		select {
-		case <-wp.stopChan:
-			return false
-		case wp.jobChan <- job:
-			return true
-		}
-	} else {
-		select {
-		case <-wp.stopChan:
-			return false
-		case wp.jobChan <- job:
-			return true
-		default:
-			return false
-		}
+	select {
+	case <-wp.stopChan:
+		return false
+	default:
+		return wp.queue.push(job, block)
 	}
 }
 
@@ -367,7 +645,17 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 		"function": "WorkerPool.process",
 	}))
 
-	if !wp.accept(job) {
+	if job.claimed {
+		// Already accepted by ClaimJobs, make sure the job has not been taken
+		// over while it was queued
+		job.claimed = false
+		if err := wp.store.RenewLease(job, wp.instanceID, wp.lease()); err != nil {
+			entry.
+				WithFields(log.Fields{"error": err}).
+				Info("Failed to renew lease on claimed job")
+			return nil
+		}
+	} else if !wp.accept(job) {
 		entry.Info("Failed to accept job")
 		return nil
 	}
@@ -377,15 +665,53 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 		entry.Warn("Could not process job, no registered executor for type")
 
 		job.State = NoAvailableWorkers
+		job.NextRunAt = time.Now().Add(wp.reSchedulableGracePeriod)
 
-		if err := wp.store.UpdateJob(job); err != nil {
+		if err := wp.store.ReleaseJob(job, wp.instanceID); err != nil {
+			if errors.Is(err, ErrLeaseLost) {
+				entry.Warn("Lost lease on job, dropping the result")
+				return nil
+			}
 			return fmt.Errorf("error while updating database entry: %w", err)
 		}
 
+		wp.recordTransition(job, Accepted, "")
+
+		return nil
+	}
+
+	timeout := wp.executionTimeout(job.Type)
+
+	leaseCtx, stopLease := wp.keepLease(job)
+	ctx, cancel := context.WithTimeout(leaseCtx, timeout)
+	err := executor(ctx, job)
+	timedOut := err != nil && ctx.Err() == context.DeadlineExceeded
+	interrupted := err != nil && wp.context.Err() != nil
+	cancel()
+	if stopLease() {
+		// Another worker has taken over the job
+		entry.Warn("Lost lease on job during execution, dropping the result")
+		return nil
+	}
+
+	if interrupted {
+		entry.WithFields(log.Fields{"error": err}).Info("Job execution interrupted by shutdown, releasing job")
+		if err := wp.releaseInterrupted(job); err != nil {
+			return fmt.Errorf("error while updating database entry: %w", err)
+		}
 		return nil
 	}
 
-	if err := executor(wp.context, job); err != nil {
+	if timedOut {
+		// Retried like any other error, even if the executor returned a
+		// chain connection error or a permanent failure due to the deadline
+		entry.
+			WithFields(log.Fields{"error": err, "timeout": timeout}).
+			Warn("Job execution timed out")
+		err = fmt.Errorf("%w after %s: %s", ErrJobTimeout, timeout, err.Error())
+	}
+
+	if err != nil {
 		// Check for chain connection errors
 		if wallet_errors.IsChainConnectionError(err) {
 			// Stop processing this job any further, returning it to the pool.
@@ -396,6 +722,7 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 			job.State = Failed
 		} else {
 			job.State = Error
+			job.NextRunAt = time.Now().Add(wp.retryBackoffFor(job.Type).Duration(job.ExecCount))
 		}
 
 		job.Error = err.Error()
@@ -410,11 +737,17 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 		job.Error = "" // Clear the error message for the final & successful execution
 	}
 
-	if err := wp.store.UpdateJob(job); err != nil {
+	if err := wp.store.ReleaseJob(job, wp.instanceID); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			entry.Warn("Lost lease on job, dropping the result")
+			return nil
+		}
 		return fmt.Errorf("error while updating database entry: %w", err)
 	}
 
-	if (job.State == Failed || job.State == Complete) && job.ShouldSendNotification && wp.notificationConfig.ShouldSendJobStatus() {
+	wp.recordTransition(job, Accepted, job.Error)
+
+	if (job.State == Failed || job.State == Complete) && wp.shouldSendNotification(job) {
 		if err := wp.scheduleJobStatusNotification(job); err != nil {
 			entry.
 				WithFields(log.Fields{"error": err}).
@@ -432,7 +765,7 @@ func (wp *WorkerPoolImpl) executeSendJobStatus(ctx context.Context, j *Job) erro
 
 	j.ShouldSendNotification = false
 
-	return wp.notificationConfig.SendJobStatus(ctx, j.Result)
+	return wp.deliverJobStatus(ctx, j)
 }
 
 func PermanentFailure(err error) error {
@@ -445,24 +778,47 @@ func (wp *WorkerPoolImpl) scheduleJobStatusNotification(parent *Job) error {
 		"function": "ScheduleJobStatusNotification",
 	}))
 
-	entry.Debug("Scheduling job status notification")
-
-	job, err := wp.CreateJob(SendJobStatusJobType, "")
+	targets, err := wp.notificationTargets(parent)
 	if err != nil {
-		return err
+		entry.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not list webhook subscriptions")
 	}
 
+	if len(targets) == 0 {
+		return nil
+	}
+
+	entry.WithFields(log.Fields{"targets": len(targets)}).Debug("Scheduling job status notification")
+
 	b, err := json.Marshal(parent.ToJSONResponse())
 	if err != nil {
 		return err
 	}
 
-	// Store the notification content of the parent job in Result of the new job
-	job.Result = string(b)
+	// Each target gets a job of its own so that they are retried independently
+	for _, target := range targets {
+		attributes, err := json.Marshal(target)
+		if err != nil {
+			return err
+		}
+
+		job, err := wp.CreateJob(SendJobStatusJobType, "", WithAttributes(attributes))
+		if err != nil {
+			return err
+		}
 
-	if err := wp.store.UpdateJob(job); err != nil {
-		return err
+		// Store the notification content of the parent job in Result of the new job
+		job.Result = string(b)
+
+		if err := wp.store.UpdateJob(job); err != nil {
+			return err
+		}
+
+		if err := wp.Schedule(job); err != nil {
+			return err
+		}
 	}
 
-	return wp.Schedule(job)
+	return nil
 }
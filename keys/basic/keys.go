@@ -220,6 +220,44 @@ func (s *KeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, err
 	}, nil
 }
 
+func (s *KeyManager) ProposalKeyAuthorizer(ctx context.Context, address flow.Address, keyIndex int) (keys.Authorizer, error) {
+	var k keys.Private
+
+	if address == flow.HexToAddress(s.cfg.AdminAddress) {
+		// Admin proposal keys are copies of the admin key
+		k = s.adminAccountKey
+	} else {
+		sk, err := s.store.AccountKeyByIndex(flow_helpers.FormatAddress(address), keyIndex)
+		if err != nil {
+			return keys.Authorizer{}, err
+		}
+		k, err = s.Load(sk)
+		if err != nil {
+			return keys.Authorizer{}, err
+		}
+	}
+
+	acc, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	if keyIndex < 0 || keyIndex >= len(acc.Keys) {
+		return keys.Authorizer{}, fmt.Errorf("account %s has no key with index %d", address, keyIndex)
+	}
+
+	sig, err := signerForKey(ctx, address, k)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	return keys.Authorizer{
+		Address: address,
+		Key:     acc.Keys[keyIndex],
+		Signer:  sig,
+	}, nil
+}
+
 func signerForKey(ctx context.Context, address flow.Address, k keys.Private) (crypto.Signer, error) {
 	var (
 		sig crypto.Signer
@@ -19,6 +19,9 @@ const (
 
 var ErrAdminProposalKeyCountMismatch = errors.New("admin-proposal-key count mismatch")
 
+// ErrAccountKeyNotFound is returned when there are no keys stored for an account.
+var ErrAccountKeyNotFound = errors.New("account key not found")
+
 // Manager provides the functions needed for key management.
 type Manager interface {
 	// Generate generates a new Key using provided key index and weight.
@@ -40,6 +43,9 @@ type Manager interface {
 	InitAdminProposalKeys(ctx context.Context) (uint16, error)
 	// AdminProposalKey returns Authorizer to be used as proposer.
 	AdminProposalKey(ctx context.Context) (Authorizer, error)
+	// ProposalKeyAuthorizer returns an Authorizer for the key with the given
+	// index of address, e.g. to propose a transaction again with the same key.
+	ProposalKeyAuthorizer(ctx context.Context, address flow.Address, keyIndex int) (Authorizer, error)
 }
 
 // Storable struct represents a storable account private key.
@@ -3,6 +3,7 @@ package keys
 // Store is the interface required by key manager for data storage.
 type Store interface {
 	AccountKey(address string) (Storable, error)
+	AccountKeyByIndex(address string, index int) (Storable, error)
 	ProposalKeyIndex(limitKeyCount int) (int, error)
 	ProposalKeyCount() (int64, error)
 	InsertProposalKey(proposalKey ProposalKey) error
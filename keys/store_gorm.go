@@ -36,6 +36,10 @@ func (s *GormStore) AccountKey(address string) (Storable, error) {
 			return err
 		}
 
+		if k.ID == 0 {
+			return ErrAccountKeyNotFound
+		}
+
 		if err := tx.Model(&k).Update("updated_at", time.Now()).Error; err != nil {
 			return err
 		}
@@ -46,6 +50,23 @@ func (s *GormStore) AccountKey(address string) (Storable, error) {
 	return k, err
 }
 
+func (s *GormStore) AccountKeyByIndex(address string, index int) (Storable, error) {
+	k := Storable{}
+
+	err := s.db.
+		Where(map[string]interface{}{"account_address": address, "index": index}).
+		Limit(1).Find(&k).Error
+	if err != nil {
+		return k, err
+	}
+
+	if k.ID == 0 {
+		return k, ErrAccountKeyNotFound
+	}
+
+	return k, nil
+}
+
 func (s *GormStore) ProposalKeyIndex(limitKeyCount int) (int, error) {
 	s.proposalKeyMutex.Lock()
 	defer s.proposalKeyMutex.Unlock()
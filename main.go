@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata" // Job schedule timezones, the release image has no zoneinfo
 
 	"github.com/flow-hydraulics/flow-wallet-api/accounts"
 	"github.com/flow-hydraulics/flow-wallet-api/chain_events"
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/datastore/gorm"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/flow-hydraulics/flow-wallet-api/handlers"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
@@ -23,7 +26,6 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
 	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/mux"
-	"github.com/onflow/flow-go-sdk/client"
 	log "github.com/sirupsen/logrus"
 	"go.uber.org/ratelimit"
 	"google.golang.org/grpc"
@@ -62,19 +64,24 @@ func main() {
 		panic(err)
 	}
 
-	runServer(cfg)
+	typeGasLimits, err := transactions.ParseTypeGasLimits(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	runServer(cfg, typeGasLimits)
 
 	os.Exit(0)
 }
 
-func runServer(cfg *configs.Config) {
+func runServer(cfg *configs.Config, typeGasLimits map[transactions.Type]uint64) {
 	configs.ConfigureLogger(cfg.LogLevel)
 
 	log.Info("Starting server")
 
 	// Flow client
 	// TODO: WithInsecure()?
-	fc, err := client.New(
+	fc, err := flow_helpers.NewClient(
 		cfg.AccessAPIHost,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.GrpcMaxCallRecvMsgSize)),
@@ -101,21 +108,83 @@ func runServer(cfg *configs.Config) {
 		system.WithPauseDuration(cfg.PauseDuration),
 	)
 
+	var (
+		jobStore    jobs.Store
+		jobListener jobs.JobListener
+	)
+
+	switch cfg.JobStoreType {
+	// Shared SQL/Gorm store (same as for main app)
+	case "shared":
+		jobStore = jobs.NewGormStore(db)
+
+		// Push based job scheduling, other databases only poll
+		if cfg.DatabaseType == "psql" {
+			jobListener, err = jobs.NewPostgresJobListener(cfg.DatabaseDSN)
+			if err != nil {
+				log.
+					WithFields(log.Fields{"error": err}).
+					Warn("Could not listen for job notifications, falling back to polling")
+			} else {
+				defer jobListener.Close()
+			}
+		}
+	// Redis, jobs are distributed with a Redis Streams consumer group
+	case "redis":
+		if cfg.JobStoreRedisURL == "" {
+			log.Fatal("job store set to redis but Redis URL is empty")
+		}
+
+		pool := &redis.Pool{
+			MaxIdle:   80,
+			MaxActive: 12000,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(cfg.JobStoreRedisURL)
+			},
+		}
+		defer pool.Close()
+
+		jobStore = jobs.NewRedisStore(pool, cfg.JobStoreRedisKeyPrefix)
+		jobListener = jobs.NewRedisJobListener(pool, cfg.JobStoreRedisKeyPrefix)
+		defer jobListener.Close()
+	default:
+		log.Fatalf("unknown job store type %q", cfg.JobStoreType)
+	}
+
 	// Create a worker pool
 	wp := jobs.NewWorkerPool(
-		jobs.NewGormStore(db),
+		jobStore,
 		cfg.WorkerQueueCapacity,
 		cfg.WorkerCount,
 		jobs.WithJobStatusWebhook(cfg.JobStatusWebhookUrl, cfg.JobStatusWebhookTimeout),
+		jobs.WithJobStatusWebhookSecret(cfg.JobStatusWebhookSecret),
+		jobs.WithCallbackReplacesJobStatusWebhook(cfg.CallbackReplacesJobStatusWebhook),
 		jobs.WithSystemService(systemService),
+		jobs.WithInstanceID(cfg.InstanceID),
 		jobs.WithMaxJobErrorCount(cfg.MaxJobErrorCount),
 		jobs.WithDbJobPollInterval(cfg.DBJobPollInterval),
 		jobs.WithAcceptedGracePeriod(cfg.AcceptedGracePeriod),
 		jobs.WithReSchedulableGracePeriod(cfg.ReSchedulableGracePeriod),
+		jobs.WithJobLeaseDuration(cfg.JobLeaseDuration),
+		jobs.WithJobRetryBackoff(cfg.JobRetryBackoffMin, cfg.JobRetryBackoffMax, cfg.JobRetryBackoffFactor),
+		jobs.WithJobTypeRetryBackoffs(cfg.JobTypeRetryBackoffs),
+		jobs.WithJobTypePriorities(cfg.JobTypePriorities),
+		jobs.WithJobTypeConcurrencyLimits(cfg.JobTypeConcurrencyLimits),
+		jobs.WithJobExecutionTimeout(cfg.JobExecutionTimeout),
+		jobs.WithJobTypeExecutionTimeouts(cfg.JobTypeExecutionTimeouts),
+		jobs.WithJobRetentionPolicies(cfg.JobRetentionPolicies),
+		jobs.WithJobArchive(cfg.JobArchiveMode, cfg.JobArchiveFile),
+		jobs.WithJobPruning(cfg.JobPruneInterval, cfg.JobPruneBatchSize),
+		jobs.WithJobListener(jobListener),
+		jobs.WithJobBackpressure(cfg.JobQueueHighWaterMark, cfg.JobBacklogHighWaterMark, cfg.JobBackpressureRetryAfter),
 	)
 
 	defer func() {
-		wp.Stop(true)
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.WorkerDrainTimeout)
+		defer cancel()
+		if err := wp.Shutdown(ctx); err != nil {
+			log.Warnf("Workerpool drain timeout exceeded: %s", err)
+		}
 		log.Info("Stopped workerpool")
 	}()
 
@@ -126,8 +195,14 @@ func runServer(cfg *configs.Config) {
 
 	// Services
 	templateService := templates.NewService(cfg, templates.NewGormStore(db))
-	jobsService := jobs.NewService(jobs.NewGormStore(db))
-	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp, transactions.WithTxRatelimiter(txRatelimiter))
+	jobsService := jobs.NewService(jobStore, wp)
+	webhookService := jobs.NewWebhookService(jobStore, wp)
+	scheduleService := jobs.NewScheduleService(jobStore, wp)
+	transactionService := transactions.NewService(
+		cfg, transactions.NewGormStore(db), km, fc, wp,
+		transactions.WithTxRatelimiter(txRatelimiter),
+		transactions.WithTypeGasLimits(typeGasLimits),
+	)
 	accountService := accounts.NewService(cfg, accounts.NewGormStore(db), km, fc, wp, transactionService, accounts.WithTxRatelimiter(txRatelimiter))
 	tokenService := tokens.NewService(cfg, tokens.NewGormStore(db), km, fc, wp, transactionService, templateService, accountService)
 
@@ -149,15 +224,25 @@ func runServer(cfg *configs.Config) {
 	systemHandler := handlers.NewSystem(systemService)
 	templateHandler := handlers.NewTemplates(templateService)
 	jobsHandler := handlers.NewJobs(jobsService)
+	webhookHandler := handlers.NewWebhooks(webhookService)
+	scheduleHandler := handlers.NewSchedules(scheduleService)
 	accountHandler := handlers.NewAccounts(accountService)
 	transactionHandler := handlers.NewTransactions(transactionService)
 	tokenHandler := handlers.NewTokens(tokenService)
+	codeTemplateHandler := handlers.NewCodeTemplates(templateService, transactionService)
 
 	r := mux.NewRouter()
 
 	// Catch the api version
 	rv := r.PathPrefix("/{apiVersion}").Subrouter()
 
+	// Operator endpoints, served on their own listener
+	ro := mux.NewRouter()
+	rov := ro.PathPrefix("/{apiVersion}").Subrouter()
+
+	// Asynchronous endpoints creating jobs, rejected under backpressure
+	backpressureRoutes := make(handlers.BackpressureRoutes)
+
 	// Debug
 	rv.Handle("/debug", handlers.Debug("https://github.com/flow-hydraulics/flow-wallet-api", sha1ver, buildTime)).Methods(http.MethodGet)
 
@@ -174,8 +259,28 @@ func runServer(cfg *configs.Config) {
 	rv.Handle("/system/sync-account-key-count", accountHandler.SyncAccountKeyCount()).Methods(http.MethodPost)
 
 	// Jobs
-	rv.Handle("/jobs", jobsHandler.List()).Methods(http.MethodGet)            // list
-	rv.Handle("/jobs/{jobId}", jobsHandler.Details()).Methods(http.MethodGet) // details
+	rv.Handle("/jobs", jobsHandler.List()).Methods(http.MethodGet)                    // list
+	rv.Handle("/jobs/retry", jobsHandler.RetryFailed()).Methods(http.MethodPost)      // bulk retry
+	rv.Handle("/jobs/{jobId}", jobsHandler.Details()).Methods(http.MethodGet)         // details
+	rv.Handle("/jobs/{jobId}", jobsHandler.Cancel()).Methods(http.MethodDelete)       // cancel
+	rv.Handle("/jobs/{jobId}/retry", jobsHandler.Retry()).Methods(http.MethodPost)    // retry
+	rv.Handle("/jobs/{jobId}/history", jobsHandler.History()).Methods(http.MethodGet) // history
+
+	// Job status webhook subscriptions
+	rv.Handle("/webhooks", webhookHandler.List()).Methods(http.MethodGet)                                         // list
+	rv.Handle("/webhooks", webhookHandler.Create()).Methods(http.MethodPost)                                      // create
+	rv.Handle("/webhooks/deliveries", webhookHandler.Deliveries()).Methods(http.MethodGet)                        // delivery log
+	rv.Handle("/webhooks/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver()).Methods(http.MethodPost) // redeliver
+	rv.Handle("/webhooks/{id}", webhookHandler.Details()).Methods(http.MethodGet)                                 // details
+	rv.Handle("/webhooks/{id}", webhookHandler.Update()).Methods(http.MethodPut)                                  // update
+	rv.Handle("/webhooks/{id}", webhookHandler.Delete()).Methods(http.MethodDelete)                               // delete
+
+	// Recurring job schedules
+	rv.Handle("/schedules", scheduleHandler.List()).Methods(http.MethodGet)           // list
+	rv.Handle("/schedules", scheduleHandler.Create()).Methods(http.MethodPost)        // create
+	rv.Handle("/schedules/{id}", scheduleHandler.Details()).Methods(http.MethodGet)   // details
+	rv.Handle("/schedules/{id}", scheduleHandler.Update()).Methods(http.MethodPut)    // update
+	rv.Handle("/schedules/{id}", scheduleHandler.Delete()).Methods(http.MethodDelete) // delete
 
 	// Token templates
 	rv.Handle("/tokens", templateHandler.ListTokens(templates.NotSpecified)).Methods(http.MethodGet) // list
@@ -183,6 +288,11 @@ func runServer(cfg *configs.Config) {
 	rv.Handle("/tokens/{id_or_name}", templateHandler.GetToken()).Methods(http.MethodGet)            // details
 	rv.Handle("/tokens/{id}", templateHandler.RemoveToken()).Methods(http.MethodDelete)              // delete
 
+	// Transaction and script templates
+	rv.Handle("/templates", codeTemplateHandler.List()).Methods(http.MethodGet)                   // list
+	rv.Handle("/templates/{templateName}", codeTemplateHandler.Details()).Methods(http.MethodGet) // details
+	rov.Handle("/templates", codeTemplateHandler.Add()).Methods(http.MethodPost)                  // add version
+
 	// List enabled tokens by type
 	rv.Handle("/fungible-tokens", templateHandler.ListTokens(templates.FT)).Methods(http.MethodGet)      // list
 	rv.Handle("/non-fungible-tokens", templateHandler.ListTokens(templates.NFT)).Methods(http.MethodGet) // list
@@ -191,35 +301,52 @@ func runServer(cfg *configs.Config) {
 	rv.Handle("/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
 	rv.Handle("/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
 
+	// Code allowlist for raw transactions
+	rv.Handle("/code-allowlist", transactionHandler.AllowedCode()).Methods(http.MethodGet)                // list
+	rv.Handle("/code-allowlist/audit", transactionHandler.CodeAuditLog()).Methods(http.MethodGet)         // rejected requests
+	rov.Handle("/code-allowlist", transactionHandler.AddAllowedCode()).Methods(http.MethodPost)           // add
+	rov.Handle("/code-allowlist/{id}", transactionHandler.RemoveAllowedCode()).Methods(http.MethodDelete) // remove
+
 	// Account
-	rv.Handle("/accounts", accountHandler.List()).Methods(http.MethodGet)              // list
-	rv.Handle("/accounts", accountHandler.Create()).Methods(http.MethodPost)           // create
-	rv.Handle("/accounts/{address}", accountHandler.Details()).Methods(http.MethodGet) // details
+	rv.Handle("/accounts", accountHandler.List()).Methods(http.MethodGet)                            // list
+	backpressureRoutes.Add(rv.Handle("/accounts", accountHandler.Create()).Methods(http.MethodPost)) // create
+	rv.Handle("/accounts/{address}", accountHandler.Details()).Methods(http.MethodGet)               // details
 
 	// Account raw transactions
 	if !cfg.DisableRawTransactions {
-		rv.Handle("/accounts/{address}/sign", transactionHandler.Sign()).Methods(http.MethodPost)                           // sign
-		rv.Handle("/accounts/{address}/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
-		rv.Handle("/accounts/{address}/transactions", transactionHandler.Create()).Methods(http.MethodPost)                 // create
-		rv.Handle("/accounts/{address}/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
+		rv.Handle("/accounts/{address}/sign", transactionHandler.Sign()).Methods(http.MethodPost)                                                     // sign
+		rv.Handle("/accounts/{address}/transactions", transactionHandler.List()).Methods(http.MethodGet)                                              // list
+		backpressureRoutes.Add(rv.Handle("/accounts/{address}/transactions", transactionHandler.Create()).Methods(http.MethodPost))                   // create
+		rv.Handle("/accounts/{address}/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet)                           // details
+		backpressureRoutes.Add(rv.Handle("/transactions/submit", transactionHandler.Submit()).Methods(http.MethodPost))                               // submit
+		backpressureRoutes.Add(rv.Handle("/cosigning-sessions", transactionHandler.CreateCoSigningSession()).Methods(http.MethodPost))                // create co-signing session
+		rv.Handle("/cosigning-sessions/{id}", transactionHandler.CoSigningSessionDetails()).Methods(http.MethodGet)                                   // co-signing session details
+		backpressureRoutes.Add(rv.Handle("/cosigning-sessions/{id}/signatures", transactionHandler.AddCoSigningSignature()).Methods(http.MethodPost)) // add co-signing signature
+		if cfg.EnableCodeAllowlist {
+			log.Info("raw transactions limited to the code allowlist")
+		}
 	} else {
 		log.Info("raw transactions disabled")
 	}
 
+	// Account transactions from templates, available when raw transactions are disabled
+	backpressureRoutes.Add(rv.Handle("/accounts/{address}/transactions/{templateName}", codeTemplateHandler.CreateTransaction()).Methods(http.MethodPost)) // create
+
 	// Non-custodial watchlist accounts
 	rv.Handle("/watchlist/accounts", accountHandler.AddNonCustodialAccount()).Methods(http.MethodPost)                // add
 	rv.Handle("/watchlist/accounts/{address}", accountHandler.DeleteNonCustodialAccount()).Methods(http.MethodDelete) // delete
 
 	// Scripts
-	rv.Handle("/scripts", transactionHandler.ExecuteScript()).Methods(http.MethodPost) // execute
+	rv.Handle("/scripts", transactionHandler.ExecuteScript()).Methods(http.MethodPost)                 // execute
+	rv.Handle("/scripts/{templateName}", codeTemplateHandler.ExecuteScript()).Methods(http.MethodPost) // execute template
 
 	// Fungible tokens
 	if !cfg.DisableFungibleTokens {
 		rv.Handle("/accounts/{address}/fungible-tokens", tokenHandler.AccountTokens(templates.FT)).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", tokenHandler.Details()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost)
+		backpressureRoutes.Add(rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost))
 		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", tokenHandler.ListWithdrawals()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost)
+		backpressureRoutes.Add(rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost))
 		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals/{transactionId}", tokenHandler.GetWithdrawal()).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits", tokenHandler.ListDeposits()).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits/{transactionId}", tokenHandler.GetDeposit()).Methods(http.MethodGet)
@@ -231,9 +358,9 @@ func runServer(cfg *configs.Config) {
 	if !cfg.DisableNonFungibleTokens {
 		rv.Handle("/accounts/{address}/non-fungible-tokens", tokenHandler.AccountTokens(templates.NFT)).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", tokenHandler.Details()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost)
+		backpressureRoutes.Add(rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost))
 		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", tokenHandler.ListWithdrawals()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost)
+		backpressureRoutes.Add(rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost))
 		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals/{transactionId}", tokenHandler.GetWithdrawal()).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits", tokenHandler.ListDeposits()).Methods(http.MethodGet)
 		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits/{transactionId}", tokenHandler.GetDeposit()).Methods(http.MethodGet)
@@ -241,7 +368,13 @@ func runServer(cfg *configs.Config) {
 		log.Info("non-fungible tokens disabled")
 	}
 
-	h := http.TimeoutHandler(r, cfg.ServerRequestTimeout, "request timed out")
+	// Streaming endpoints are long-lived and bypass the request timeout,
+	// everything else falls through to the main router
+	root := mux.NewRouter()
+	root.Handle("/{apiVersion}/jobs/stream", jobsHandler.Stream()).Methods(http.MethodGet)
+	root.NotFoundHandler = http.TimeoutHandler(r, cfg.ServerRequestTimeout, "request timed out")
+
+	var h http.Handler = root
 	h = handlers.UseCors(h)
 	h = handlers.UseLogging(h)
 	h = handlers.UseCompress(h)
@@ -291,6 +424,10 @@ func runServer(cfg *configs.Config) {
 		}, is)
 	}
 
+	// Outside of the idempotency middleware, rejected requests can be retried
+	// with the same Idempotency-Key
+	h = handlers.UseBackpressure(h, wp, r, backpressureRoutes)
+
 	// Server boilerplate
 	srv := &http.Server{
 		Handler:      h,
@@ -312,6 +449,33 @@ func runServer(cfg *configs.Config) {
 		}
 	}()
 
+	// Operator server, kept apart so that clients can not register or allow code
+	var operatorSrv *http.Server
+	if cfg.OperatorPort != 0 {
+		var oh http.Handler = http.TimeoutHandler(ro, cfg.ServerRequestTimeout, "request timed out")
+		oh = handlers.UseLogging(oh)
+		oh = handlers.UseCompress(oh)
+
+		operatorSrv = &http.Server{
+			Handler: oh,
+			Addr:    fmt.Sprintf("%s:%d", cfg.OperatorHost, cfg.OperatorPort),
+		}
+
+		go func() {
+			log.
+				WithFields(log.Fields{
+					"host": cfg.OperatorHost,
+					"port": cfg.OperatorPort,
+				}).
+				Info("Operator server listening")
+			if err := operatorSrv.ListenAndServe(); err != nil {
+				log.Warn(err)
+			}
+		}()
+	} else {
+		log.Info("operator endpoints disabled")
+	}
+
 	// Chain event listener
 	if !cfg.DisableChainEvents {
 		store := chain_events.NewGormStore(db)
@@ -361,9 +525,11 @@ func runServer(cfg *configs.Config) {
 
 	// Trap interupt or sigterm and gracefully shutdown the server
 	c := make(chan os.Signal, 1)
-	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
-	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
-	signal.Notify(c, os.Interrupt)
+	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C) or
+	// SIGTERM (e.g. Kubernetes). SIGKILL or SIGQUIT (Ctrl+/) will not be caught.
+	// The deferred calls then stop the chain events listener and drain the
+	// workerpool.
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	// Block until we receive our signal.
 	sig := <-c
@@ -376,4 +542,9 @@ func runServer(cfg *configs.Config) {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Warnf("Error in server shutdown: %s", err)
 	}
+	if operatorSrv != nil {
+		if err := operatorSrv.Shutdown(ctx); err != nil {
+			log.Warnf("Error in operator server shutdown: %s", err)
+		}
+	}
 }
@@ -614,6 +614,7 @@ func TestTransactionHandlers(t *testing.T) {
 		context.Background(),
 		true,
 		cfg.AdminAddress,
+		nil,
 		"transaction() { prepare(signer: AuthAccount){} execute { log(\"Hello World!\") }}",
 		nil,
 		transactions.General,
@@ -624,6 +625,7 @@ func TestTransactionHandlers(t *testing.T) {
 		context.Background(),
 		true,
 		cfg.AdminAddress,
+		nil,
 		transferFlow,
 		[]transactions.Argument{
 			cadence.UFix64(1.0),
@@ -1094,7 +1096,7 @@ func TestTokenHandlers(t *testing.T) {
 	// Mint ExampleNFTs for account 0
 	mintCode := templates.TokenCode(cfg.ChainID, &exampleNft, string(mintBytes))
 	for i := 0; i < 3; i++ {
-		_, _, err := transactionSvc.Create(context.Background(), true, cfg.AdminAddress, mintCode,
+		_, _, err := transactionSvc.Create(context.Background(), true, cfg.AdminAddress, nil, mintCode,
 			[]transactions.Argument{cadence.NewAddress(flow.HexToAddress(testAccounts[0].Address))},
 			transactions.General)
 		fatal(t, err)
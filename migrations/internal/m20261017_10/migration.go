@@ -0,0 +1,48 @@
+// m20261017_10 handles adding the `cosigning_sessions` table
+package m20261017_10
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_10"
+
+type CoSigningSession struct {
+	ID              uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	State           string         `gorm:"column:state;default:open;index"`
+	ProposerAddress string         `gorm:"column:proposer_address;index"`
+	PayerAddress    string         `gorm:"column:payer_address"`
+	PendingSigners  pq.StringArray `gorm:"column:pending_signers;type:text[]"`
+	FlowTransaction []byte         `gorm:"column:flow_transaction;type:bytes"`
+	TransactionId   string         `gorm:"column:transaction_id;index"`
+	JobId           *uuid.UUID     `gorm:"column:job_id;type:uuid"`
+	ExpiresAtHeight uint64         `gorm:"column:expires_at_height"`
+	Version         int            `gorm:"column:version;default:0"`
+	CreatedAt       time.Time      `gorm:"column:created_at"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (CoSigningSession) TableName() string {
+	return "cosigning_sessions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&CoSigningSession{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&CoSigningSession{}); err != nil {
+		return err
+	}
+
+	return nil
+}
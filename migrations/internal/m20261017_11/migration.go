@@ -0,0 +1,41 @@
+// m20261017_11 handles adding the `code_templates` table
+package m20261017_11
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_11"
+
+type CodeTemplate struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex:idx_code_templates_name_version;not null"`
+	Version   int    `gorm:"uniqueIndex:idx_code_templates_name_version;not null"`
+	Kind      string `gorm:"not null"`
+	Token     string
+	Code      string
+	Arguments []byte `gorm:"type:bytes"`
+	CreatedAt time.Time
+}
+
+func (CodeTemplate) TableName() string {
+	return "code_templates"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&CodeTemplate{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&CodeTemplate{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// m20261017_12 handles adding the `code_allowlist` and `code_audit_log` tables
+package m20261017_12
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_12"
+
+type AllowedCode struct {
+	ID             uint64 `gorm:"primaryKey"`
+	CodeHash       string `gorm:"index;not null"`
+	Description    string
+	AccountAddress string
+	APIKeyHash     string `gorm:"column:api_key_hash"`
+	CreatedAt      time.Time
+}
+
+func (AllowedCode) TableName() string {
+	return "code_allowlist"
+}
+
+type CodeAuditEntry struct {
+	ID              uint64 `gorm:"primaryKey"`
+	Operation       string
+	CodeHash        string `gorm:"index"`
+	ProposerAddress string
+	APIKeyHash      string `gorm:"column:api_key_hash"`
+	Reason          string
+	CreatedAt       time.Time `gorm:"index"`
+}
+
+func (CodeAuditEntry) TableName() string {
+	return "code_audit_log"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&AllowedCode{}, &CodeAuditEntry{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&AllowedCode{}, &CodeAuditEntry{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+// m20261017_13 handles adding the gas limit the transaction was built with
+// to Transaction
+package m20261017_13
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_13"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	OriginalTransactionId string         `gorm:"column:original_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:pending;index"`
+	Error                 string         `gorm:"column:error"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	BlockId               string         `gorm:"column:block_id"`
+	Events                []byte         `gorm:"column:events;type:bytes"`
+	Fees                  string         `gorm:"column:fees"`
+	GasLimit              uint64         `gorm:"column:gas_limit"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	// The gas limit of existing transactions is left unset, it can still be
+	// read from their Flow transaction
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Transaction{}, "gas_limit"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// m20261017_2 handles adding the `job_events` table
+package m20261017_2
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_2"
+
+// State is a type for Job state.
+type State string
+
+// Event is a recorded state transition of a Job.
+type Event struct {
+	ID         uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	JobID      uuid.UUID `gorm:"column:job_id;type:uuid;index"`
+	FromState  State     `gorm:"column:from_state"`
+	ToState    State     `gorm:"column:to_state"`
+	Attempt    int       `gorm:"column:attempt"`
+	InstanceID string    `gorm:"column:instance_id"`
+	Error      string    `gorm:"column:error"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+func (Event) TableName() string {
+	return "job_events"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Event{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Event{}); err != nil {
+		return err
+	}
+
+	return nil
+}
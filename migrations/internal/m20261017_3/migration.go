@@ -0,0 +1,62 @@
+// m20261017_3 handles adding the `webhook_subscriptions` and
+// `webhook_deliveries` tables
+package m20261017_3
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_3"
+
+type WebhookSubscription struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	URL       string         `gorm:"column:url"`
+	Secret    string         `gorm:"column:secret"`
+	JobTypes  pq.StringArray `gorm:"column:job_types;type:text[]"`
+	States    pq.StringArray `gorm:"column:states;type:text[]"`
+	Enabled   bool           `gorm:"column:enabled"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+type WebhookDelivery struct {
+	ID             uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	JobID          uuid.UUID  `gorm:"column:job_id;type:uuid;index"`
+	ParentJobID    uuid.UUID  `gorm:"column:parent_job_id;type:uuid;index"`
+	SubscriptionID *uuid.UUID `gorm:"column:subscription_id;type:uuid;index"`
+	URL            string     `gorm:"column:url"`
+	Attempt        int        `gorm:"column:attempt"`
+	StatusCode     int        `gorm:"column:status_code"`
+	Success        bool       `gorm:"column:success"`
+	Error          string     `gorm:"column:error"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&WebhookSubscription{}, &WebhookDelivery{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&WebhookDelivery{}, &WebhookSubscription{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+// m20261017_5 handles adding the `job_schedules` table
+package m20261017_5
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_5"
+
+type JobSchedule struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Name       string         `gorm:"column:name"`
+	Cron       string         `gorm:"column:cron"`
+	Timezone   string         `gorm:"column:timezone"`
+	JobType    string         `gorm:"column:job_type"`
+	Attributes datatypes.JSON `gorm:"column:attributes"`
+	Enabled    bool           `gorm:"column:enabled;index:idx_job_schedules_enabled_next_run_at"`
+	NextRunAt  time.Time      `gorm:"column:next_run_at;index:idx_job_schedules_enabled_next_run_at"`
+	RunCount   int            `gorm:"column:run_count;default:0"`
+	LastRunAt  *time.Time     `gorm:"column:last_run_at"`
+	LastJobID  *uuid.UUID     `gorm:"column:last_job_id;type:uuid"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&JobSchedule{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&JobSchedule{}); err != nil {
+		return err
+	}
+
+	return nil
+}
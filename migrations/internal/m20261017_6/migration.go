@@ -0,0 +1,73 @@
+// m20261017_6 handles adding the `job_state_counts` and `jobs_archive`
+// tables. The job state counts are initialized from the jobs in the `jobs`
+// table which have not been soft deleted.
+package m20261017_6
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_6"
+
+// State is a type for Job state.
+type State string
+
+type JobStateCount struct {
+	State State `gorm:"column:state;primaryKey"`
+	Count int   `gorm:"column:count"`
+}
+
+func (JobStateCount) TableName() string {
+	return "job_state_counts"
+}
+
+type ArchivedJob struct {
+	ID            uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Type          string         `gorm:"column:type;index"`
+	State         State          `gorm:"column:state"`
+	Error         string         `gorm:"column:error"`
+	Errors        pq.StringArray `gorm:"column:errors;type:text[]"`
+	Result        string         `gorm:"column:result"`
+	TransactionID string         `gorm:"column:transaction_id;index"`
+	ExecCount     int            `gorm:"column:exec_count"`
+	Attributes    datatypes.JSON `gorm:"column:attributes"`
+	CreatedAt     time.Time      `gorm:"column:created_at"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at"`
+	ArchivedAt    time.Time      `gorm:"column:archived_at;index"`
+}
+
+func (ArchivedJob) TableName() string {
+	return "jobs_archive"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&JobStateCount{}, &ArchivedJob{}); err != nil {
+		return err
+	}
+
+	var counts []JobStateCount
+	if err := tx.Raw("SELECT state, COUNT(*) as count FROM jobs WHERE deleted_at IS NULL GROUP BY state").Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	if len(counts) > 0 {
+		if err := tx.Create(&counts).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&ArchivedJob{}, &JobStateCount{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// m20261017_7 handles adding a trigger announcing schedulable jobs on the
+// `flow_wallet_jobs` notification channel. PostgreSQL only.
+package m20261017_7
+
+import (
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_7"
+
+const createFunction = `
+CREATE OR REPLACE FUNCTION flow_wallet_notify_schedulable_job() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('flow_wallet_jobs', NEW.id::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`
+
+// Jobs waiting for a worker (NO_AVAILABLE_WORKERS), for a retry or for their
+// run time. Jobs due later are announced as well, instances wake up when the
+// next job is due. next_run_at is not compared with now() as the clocks of the
+// database and the instances may differ.
+const createTrigger = `
+CREATE TRIGGER jobs_notify_schedulable
+	AFTER INSERT OR UPDATE ON jobs
+	FOR EACH ROW
+	WHEN (NEW.state IN ('NO_AVAILABLE_WORKERS', 'ERROR', 'SCHEDULED'))
+	EXECUTE PROCEDURE flow_wallet_notify_schedulable_job()`
+
+func Migrate(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if err := tx.Exec(createFunction).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DROP TRIGGER IF EXISTS jobs_notify_schedulable ON jobs").Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec(createTrigger).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if err := tx.Exec("DROP TRIGGER IF EXISTS jobs_notify_schedulable ON jobs").Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DROP FUNCTION IF EXISTS flow_wallet_notify_schedulable_job()").Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+// m20261017_8 handles adding the `OriginalTransactionId` field to Transaction
+package m20261017_8
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_8"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	OriginalTransactionId string         `gorm:"column:original_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&Transaction{}, "idx_transactions_original_transaction_id"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Transaction{}, "original_transaction_id"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+// m20261017_9 handles adding the status, result and fees of the transaction
+// on chain to Transaction
+package m20261017_9
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20261017_9"
+
+type Transaction struct {
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	OriginalTransactionId string         `gorm:"column:original_transaction_id;index"`
+	TransactionType       int            `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                string         `gorm:"column:status;default:pending;index"`
+	Error                 string         `gorm:"column:error"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	BlockId               string         `gorm:"column:block_id"`
+	Events                []byte         `gorm:"column:events;type:bytes"`
+	Fees                  string         `gorm:"column:fees"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	// Existing transactions are pending until their result is next fetched
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&Transaction{}, "idx_transactions_status"); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"status", "error", "block_height", "block_id", "events", "fees"} {
+		if err := tx.Migrator().DropColumn(&Transaction{}, column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
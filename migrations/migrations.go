@@ -11,6 +11,19 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20211221_1"
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20211221_2"
 	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20220212"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_1"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_10"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_11"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_12"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_13"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_2"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_3"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_4"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_5"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_6"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_7"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_8"
+	"github.com/flow-hydraulics/flow-wallet-api/migrations/internal/m20261017_9"
 	"github.com/go-gormigrate/gormigrate/v2"
 )
 
@@ -66,6 +79,71 @@ func List() []*gormigrate.Migration {
 			Migrate:  m20220212.Migrate,
 			Rollback: m20220212.Rollback,
 		},
+		{
+			ID:       m20261017_1.ID,
+			Migrate:  m20261017_1.Migrate,
+			Rollback: m20261017_1.Rollback,
+		},
+		{
+			ID:       m20261017_2.ID,
+			Migrate:  m20261017_2.Migrate,
+			Rollback: m20261017_2.Rollback,
+		},
+		{
+			ID:       m20261017_3.ID,
+			Migrate:  m20261017_3.Migrate,
+			Rollback: m20261017_3.Rollback,
+		},
+		{
+			ID:       m20261017_4.ID,
+			Migrate:  m20261017_4.Migrate,
+			Rollback: m20261017_4.Rollback,
+		},
+		{
+			ID:       m20261017_5.ID,
+			Migrate:  m20261017_5.Migrate,
+			Rollback: m20261017_5.Rollback,
+		},
+		{
+			ID:       m20261017_6.ID,
+			Migrate:  m20261017_6.Migrate,
+			Rollback: m20261017_6.Rollback,
+		},
+		{
+			ID:       m20261017_7.ID,
+			Migrate:  m20261017_7.Migrate,
+			Rollback: m20261017_7.Rollback,
+		},
+		{
+			ID:       m20261017_8.ID,
+			Migrate:  m20261017_8.Migrate,
+			Rollback: m20261017_8.Rollback,
+		},
+		{
+			ID:       m20261017_9.ID,
+			Migrate:  m20261017_9.Migrate,
+			Rollback: m20261017_9.Rollback,
+		},
+		{
+			ID:       m20261017_10.ID,
+			Migrate:  m20261017_10.Migrate,
+			Rollback: m20261017_10.Rollback,
+		},
+		{
+			ID:       m20261017_11.ID,
+			Migrate:  m20261017_11.Migrate,
+			Rollback: m20261017_11.Rollback,
+		},
+		{
+			ID:       m20261017_12.ID,
+			Migrate:  m20261017_12.Migrate,
+			Rollback: m20261017_12.Rollback,
+		},
+		{
+			ID:       m20261017_13.ID,
+			Migrate:  m20261017_13.Migrate,
+			Rollback: m20261017_13.Rollback,
+		},
 	}
 	return ms
 }
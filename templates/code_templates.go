@@ -0,0 +1,262 @@
+package templates
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// CodeTemplateKind tells whether a code template is a transaction or a script.
+type CodeTemplateKind string
+
+const (
+	TransactionTemplate CodeTemplateKind = "transaction"
+	ScriptTemplate      CodeTemplateKind = "script"
+)
+
+// Names are used in URLs
+var validCodeTemplateName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CodeTemplate is a named and versioned Cadence transaction or script.
+// Registering a template with an existing name adds a new version, existing
+// versions never change.
+type CodeTemplate struct {
+	ID        uint64           `json:"id" gorm:"primaryKey"`
+	Name      string           `json:"name" gorm:"uniqueIndex:idx_code_templates_name_version;not null"`
+	Version   int              `json:"version" gorm:"uniqueIndex:idx_code_templates_name_version;not null"`
+	Kind      CodeTemplateKind `json:"kind" gorm:"not null"`
+	Token     string           `json:"token,omitempty"` // Name of the token the code was rendered for, if any
+	Code      string           `json:"code"`            // Cadence code, placeholders replaced
+	Arguments ArgumentSchemas  `json:"arguments" gorm:"type:bytes"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func (CodeTemplate) TableName() string {
+	return "code_templates"
+}
+
+// ArgumentSchema declares an argument of a code template. Type is a
+// JSON-Cadence type, e.g. "UFix64", "Address" or "Array".
+type ArgumentSchema struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ArgumentSchemas are the arguments of a code template in the order of the
+// transaction or script parameters. They are stored as JSON.
+type ArgumentSchemas []ArgumentSchema
+
+func (a ArgumentSchemas) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *ArgumentSchemas) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*a = nil
+		return nil
+	default:
+		return fmt.Errorf("unsupported argument schemas value: %T", value)
+	}
+
+	if len(b) == 0 {
+		*a = nil
+		return nil
+	}
+
+	return json.Unmarshal(b, a)
+}
+
+// Code template JSON HTTP request
+type CodeTemplateJSONRequest struct {
+	Name      string           `json:"name"`
+	Kind      CodeTemplateKind `json:"kind"`
+	Token     string           `json:"token"` // Optional, replaces the TOKEN_* placeholders
+	Code      string           `json:"code"`
+	Arguments ArgumentSchemas  `json:"arguments"`
+}
+
+// Code template call JSON HTTP request
+type CodeTemplateCallJSONRequest struct {
+	Version     int                        `json:"version"`     // Defaults to the latest version
+	Arguments   map[string]json.RawMessage `json:"arguments"`   // By argument name
+	Authorizers []string                   `json:"authorizers"` // Transactions only, defaults to the proposer
+}
+
+// TemplateCode replaces references to the Cadence source files of known
+// contracts with their addresses on chainId, like TokenCode does for code
+// not related to a token.
+func TemplateCode(chainId flow.ChainID, tmplStr string) string {
+	knownAddressesReplacer := knownAddressesReplacers[chainId]
+
+	code := tmplStr
+	code = replaceCadenceFiles(code)
+	code = knownAddressesReplacer.Replace(code)
+
+	return code
+}
+
+// CadenceArguments converts arguments given by name to the Cadence values of
+// the declared arguments, in order. An argument is either a plain JSON value
+// (a string, number, boolean or null), which is given the declared type, or
+// a JSON-Cadence value of the declared type.
+func (t CodeTemplate) CadenceArguments(args map[string]json.RawMessage) ([]cadence.Value, error) {
+	badRequest := func(format string, a ...interface{}) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(format, a...),
+		}
+	}
+
+	for name := range args {
+		if !t.Arguments.has(name) {
+			return nil, badRequest(`unknown argument "%s"`, name)
+		}
+	}
+
+	values := make([]cadence.Value, 0, len(t.Arguments))
+
+	for _, a := range t.Arguments {
+		raw, ok := args[a.Name]
+		if !ok {
+			return nil, badRequest(`missing argument "%s"`, a.Name)
+		}
+
+		j, err := jsonCadenceArgument(a.Type, raw)
+		if err != nil {
+			return nil, badRequest(`invalid argument "%s": %s`, a.Name, err)
+		}
+
+		v, err := c_json.Decode(j)
+		if err != nil {
+			return nil, badRequest(`invalid argument "%s": %s`, a.Name, err)
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// jsonCadenceArgument returns raw as a JSON-Cadence value of type cadenceType.
+func jsonCadenceArgument(cadenceType string, raw json.RawMessage) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+
+	if len(raw) > 0 && raw[0] == '{' {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &typed); err != nil {
+			return nil, err
+		}
+		if typed.Type != cadenceType {
+			return nil, fmt.Errorf("expected a value of type %s, got %s", cadenceType, typed.Type)
+		}
+		return raw, nil
+	}
+
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		// JSON-Cadence numbers are strings
+		value = v.String()
+	case string, bool, nil:
+	default:
+		return nil, fmt.Errorf("expected a plain value or a JSON-Cadence value of type %s", cadenceType)
+	}
+
+	return json.Marshal(map[string]interface{}{"type": cadenceType, "value": value})
+}
+
+func (a ArgumentSchemas) has(name string) bool {
+	for _, s := range a {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a ArgumentSchemas) validate() error {
+	seen := make(map[string]bool, len(a))
+
+	for _, s := range a {
+		if s.Name == "" {
+			return fmt.Errorf("argument without a name")
+		}
+		if s.Type == "" {
+			return fmt.Errorf(`argument without a type: "%s"`, s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf(`duplicate argument: "%s"`, s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	return nil
+}
+
+// newCodeTemplate validates req and renders the code of the template.
+func newCodeTemplate(chainId flow.ChainID, req CodeTemplateJSONRequest, token *Token) (*CodeTemplate, error) {
+	badRequest := func(err error) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	if !validCodeTemplateName.MatchString(req.Name) {
+		return nil, badRequest(fmt.Errorf(`not a valid name: "%s"`, req.Name))
+	}
+
+	switch req.Kind {
+	case TransactionTemplate, ScriptTemplate:
+	default:
+		return nil, badRequest(fmt.Errorf(`not a valid kind: "%s", expected "%s" or "%s"`, req.Kind, TransactionTemplate, ScriptTemplate))
+	}
+
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, badRequest(fmt.Errorf("empty code"))
+	}
+
+	if err := req.Arguments.validate(); err != nil {
+		return nil, badRequest(err)
+	}
+
+	t := &CodeTemplate{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Arguments: req.Arguments,
+	}
+
+	if token != nil {
+		t.Token = token.Name
+		t.Code = TokenCode(chainId, token, req.Code)
+	} else {
+		t.Code = TemplateCode(chainId, req.Code)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,90 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestCodeTemplateCadenceArguments(t *testing.T) {
+	tmpl := CodeTemplate{
+		Name: "transfer",
+		Arguments: ArgumentSchemas{
+			{Name: "amount", Type: "UFix64"},
+			{Name: "recipient", Type: "Address"},
+			{Name: "id", Type: "UInt64"},
+			{Name: "force", Type: "Bool"},
+			{Name: "tags", Type: "Array"},
+		},
+	}
+
+	args := map[string]json.RawMessage{
+		"amount":    json.RawMessage(`"1.5"`),
+		"recipient": json.RawMessage(`"0xf8d6e0586b0a20c7"`),
+		"id":        json.RawMessage(`42`),
+		"force":     json.RawMessage(`true`),
+		"tags":      json.RawMessage(`{"type":"Array","value":[{"type":"String","value":"a"}]}`),
+	}
+
+	values, err := tmpl.CadenceArguments(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount, _ := cadence.NewUFix64("1.5")
+	expected := []cadence.Value{
+		amount,
+		cadence.NewAddress(flow.HexToAddress("f8d6e0586b0a20c7")),
+		cadence.NewUInt64(42),
+		cadence.NewBool(true),
+		cadence.NewArray([]cadence.Value{cadence.String("a")}),
+	}
+
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d arguments, got %d", len(expected), len(values))
+	}
+
+	for i, v := range values {
+		if v.String() != expected[i].String() || fmt.Sprintf("%T", v) != fmt.Sprintf("%T", expected[i]) {
+			t.Fatalf("expected argument %d to be %s, got %s", i, expected[i], v)
+		}
+	}
+
+	invalid := map[string]map[string]json.RawMessage{
+		"missing argument": {"amount": json.RawMessage(`"1.5"`)},
+		"unknown argument": {
+			"amount": args["amount"], "recipient": args["recipient"], "id": args["id"], "force": args["force"], "tags": args["tags"],
+			"other": json.RawMessage(`"x"`),
+		},
+		"mismatching type": {
+			"amount": json.RawMessage(`{"type":"String","value":"1.5"}`), "recipient": args["recipient"], "id": args["id"], "force": args["force"], "tags": args["tags"],
+		},
+		"invalid value": {
+			"amount": json.RawMessage(`"one"`), "recipient": args["recipient"], "id": args["id"], "force": args["force"], "tags": args["tags"],
+		},
+	}
+
+	for name, args := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tmpl.CadenceArguments(args); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestTemplateCode(t *testing.T) {
+	code := TemplateCode(flow.Testnet, `import FungibleToken from "../contracts/FungibleToken.cdc"`)
+
+	if code != "import FungibleToken from 0x9a0766d93b6608b7" {
+		t.Fatalf("expected the known contract address to be imported, got %q", code)
+	}
+
+	if strings.Contains(code, ".cdc") {
+		t.Error("expected all cadence file references to have been replaced")
+	}
+}
@@ -2,9 +2,11 @@ package templates
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
@@ -17,6 +19,9 @@ type Service interface {
 	GetTokenByName(name string) (*Token, error)
 	RemoveToken(id uint64) error
 	TokenFromEvent(e flow.Event) (*Token, error)
+	AddCodeTemplate(req CodeTemplateJSONRequest) (*CodeTemplate, error)
+	ListCodeTemplates() ([]CodeTemplate, error)
+	GetCodeTemplate(name string, version int) (*CodeTemplate, error)
 }
 
 type ServiceImpl struct {
@@ -137,3 +142,59 @@ func (s *ServiceImpl) TokenFromEvent(e flow.Event) (*Token, error) {
 
 	return token, nil
 }
+
+// AddCodeTemplate registers a new version of a named code template. The
+// placeholders of the code are replaced like in token templates, including
+// the TOKEN_* placeholders if a token is given.
+func (s *ServiceImpl) AddCodeTemplate(req CodeTemplateJSONRequest) (*CodeTemplate, error) {
+	var token *Token
+
+	if req.Token != "" {
+		t, err := s.store.GetByName(req.Token)
+		if err != nil {
+			if strings.Contains(err.Error(), "record not found") {
+				// Convert error to a 400 RequestError
+				err = &errors.RequestError{
+					StatusCode: http.StatusBadRequest,
+					Err:        fmt.Errorf(`token not found: "%s"`, req.Token),
+				}
+			}
+			return nil, err
+		}
+		token = t
+	}
+
+	t, err := newCodeTemplate(s.cfg.ChainID, req, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.InsertCodeTemplate(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ListCodeTemplates returns all versions of all code templates.
+func (s *ServiceImpl) ListCodeTemplates() ([]CodeTemplate, error) {
+	return s.store.CodeTemplates()
+}
+
+// GetCodeTemplate returns the given version of a code template, or the latest
+// version if version is 0.
+func (s *ServiceImpl) GetCodeTemplate(name string, version int) (*CodeTemplate, error) {
+	t, err := s.store.CodeTemplate(name, version)
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf(`code template not found: "%s"`, name),
+			}
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
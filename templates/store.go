@@ -10,4 +10,13 @@ type Store interface {
 	// Insert a token that is available only for this instances runtime (in-memory)
 	// Used when enabling a token via environment variables
 	InsertTemp(*Token)
+
+	// Code templates
+	CodeTemplates() ([]CodeTemplate, error)
+	// CodeTemplate returns the given version of a code template, or the
+	// latest version if version is 0.
+	CodeTemplate(name string, version int) (*CodeTemplate, error)
+	// InsertCodeTemplate inserts t as the next version of the code template
+	// with its name.
+	InsertCodeTemplate(t *CodeTemplate) error
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"strings"
 
+	"github.com/flow-hydraulics/flow-wallet-api/datastore/lib"
 	"gorm.io/gorm"
 )
 
@@ -80,3 +81,41 @@ func (s *GormStore) Remove(id uint64) error {
 func (s *GormStore) InsertTemp(token *Token) {
 	s.tempStore[strings.ToLower(token.Name)] = token
 }
+
+func (s *GormStore) CodeTemplates() (tt []CodeTemplate, err error) {
+	err = s.db.Order("name asc, version asc").Find(&tt).Error
+	return
+}
+
+func (s *GormStore) CodeTemplate(name string, version int) (*CodeTemplate, error) {
+	var t CodeTemplate
+
+	q := s.db.Where(&CodeTemplate{Name: name})
+	if version > 0 {
+		q = q.Where(&CodeTemplate{Version: version})
+	}
+
+	if err := q.Order("version desc").First(&t).Error; err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *GormStore) InsertCodeTemplate(t *CodeTemplate) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&CodeTemplate{}).
+			Where(&CodeTemplate{Name: t.Name}).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		// The unique index on name and version rejects concurrent inserts
+		// of the same version
+		t.Version = latest + 1
+
+		return tx.Omit("ID").Create(t).Error
+	})
+}
@@ -40,6 +40,19 @@ var chains []flow.ChainID = []flow.ChainID{
 
 var knownAddressesReplacers chainReplacers
 
+// Regex that matches all references to cadence source files
+// For example:
+// - "../../contracts/Source.cdc"
+// - "./Source.cdc"
+// - "Source.cdc"
+var matchCadenceFiles = regexp.MustCompile(`"(.*?)(\w+\.cdc)"`)
+
+// replaceCadenceFiles replaces all references to cadence source files with
+// just the filename, without quotes.
+func replaceCadenceFiles(code string) string {
+	return matchCadenceFiles.ReplaceAllString(code, "$2")
+}
+
 func makeReplacers(t templateVariables) chainReplacers {
 	r := make(chainReplacers, len(chains))
 	for _, c := range chains {
@@ -63,16 +76,6 @@ func (token Token) BasicToken() BasicToken {
 
 func TokenCode(chainId flow.ChainID, token *Token, tmplStr string) string {
 
-	// Regex that matches all references to cadence source files
-	// For example:
-	// - "../../contracts/Source.cdc"
-	// - "./Source.cdc"
-	// - "Source.cdc"
-	matchCadenceFiles := regexp.MustCompile(`"(.*?)(\w+\.cdc)"`)
-
-	// Replace all above matches with just the filename, without quotes
-	replaceCadenceFiles := "$2"
-
 	// Replaces all TokenName.cdc's with TOKEN_ADDRESS
 	sourceFileReplacer := strings.NewReplacer(
 		fmt.Sprintf("%s.cdc", token.Name), "TOKEN_ADDRESS",
@@ -91,7 +94,7 @@ func TokenCode(chainId flow.ChainID, token *Token, tmplStr string) string {
 	code := tmplStr
 
 	// Ordering matters here
-	code = matchCadenceFiles.ReplaceAllString(code, replaceCadenceFiles)
+	code = replaceCadenceFiles(code)
 	code = sourceFileReplacer.Replace(code)
 	code = templateReplacer.Replace(code)
 	code = knownAddressesReplacer.Replace(code)
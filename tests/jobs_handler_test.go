@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/handlers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/gorilla/mux"
+)
+
+func Test_JobsStream(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Minute))
+	svc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	jobType := "job"
+	wp.RegisterExecutor(jobType, func(ctx context.Context, j *jobs.Job) error {
+		return nil
+	})
+
+	router := mux.NewRouter()
+	router.Handle("/stream", handlers.NewJobs(svc).Stream()).Methods(http.MethodGet)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	// A job created before connecting is received when resuming from the start
+	j, err := wp.CreateJob(jobType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream?jobId="+j.ID.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "0")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	assertStatusCode(t, res, http.StatusOK)
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	var states []jobs.State
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var e jobs.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.JobID != j.ID {
+			t.Fatalf("expected events only for job %s, got %s", j.ID, e.JobID)
+		}
+
+		states = append(states, e.ToState)
+
+		if e.ToState == jobs.Complete {
+			break
+		}
+	}
+
+	expected := []jobs.State{jobs.Init, jobs.Accepted, jobs.Complete}
+	if len(states) != len(expected) {
+		t.Fatalf("expected states %v, got %v", expected, states)
+	}
+
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Fatalf("expected states %v, got %v", expected, states)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+)
+
+func Test_JobsListWithFilterAndCursor(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+	svc := jobs.NewService(jobStore, wp)
+
+	t0 := time.Now()
+	insert := func(i int, jobType string, state jobs.State, attributes string) *jobs.Job {
+		j := &jobs.Job{
+			ID:         uuid.New(),
+			State:      state,
+			Type:       jobType,
+			Attributes: datatypes.JSON(attributes),
+			CreatedAt:  t0.Add(time.Duration(-i) * time.Minute),
+			UpdatedAt:  t0.Add(time.Duration(-i) * time.Minute),
+		}
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	var expected []uuid.UUID
+	for i := 0; i < 5; i++ {
+		expected = append(expected, insert(i, "withdrawal", jobs.Complete, `{"sender":"0x01"}`).ID)
+	}
+	insert(5, "withdrawal", jobs.Failed, `{"sender":"0x01"}`)
+	insertedOtherSender := insert(6, "withdrawal", jobs.Complete, `{"sender":"0x02"}`).ID
+	insert(7, "other", jobs.Complete, `{"sender":"0x01"}`)
+
+	filter := jobs.Filter{
+		States: []jobs.State{jobs.Complete},
+		Types:  []string{"withdrawal"},
+	}
+
+	if db.Dialector.Name() != "sqlite" || cfg.JobStoreType == "redis" {
+		// JSON functions are not available in the default sqlite build
+		filter.Attributes = map[string]string{"sender": "0x01"}
+	} else {
+		expected = append(expected, insertedOtherSender)
+	}
+
+	var got []uuid.UUID
+	for {
+		page, next, err := svc.List(filter, 2, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, j := range *page {
+			got = append(got, j.ID)
+		}
+
+		if next == "" {
+			break
+		}
+
+		filter.Cursor = next
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d jobs, got %d", len(expected), len(got))
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected job %d to be %s, got %s", i, expected[i], got[i])
+		}
+	}
+
+	createdAfter := t0.Add(-90 * time.Second)
+	page, _, err := svc.List(jobs.Filter{CreatedAfter: &createdAfter}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*page) != 2 {
+		t.Fatalf("expected %d jobs, got %d", 2, len(*page))
+	}
+
+	if _, _, err := svc.List(jobs.Filter{Cursor: "invalid"}, 0, 0); err == nil {
+		t.Fatal("expected an error for an invalid cursor")
+	}
+
+	if _, _, err := svc.List(jobs.Filter{Cursor: jobs.NewCursor(jobs.Job{}).Encode()}, 1, 1); err == nil {
+		t.Fatal("expected an error for a cursor combined with an offset")
+	}
+}
+
+func Test_JobsHistory(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(
+		jobStore, 10, 1,
+		jobs.WithInstanceID("test-instance"),
+		jobs.WithDbJobPollInterval(100*time.Millisecond),
+		jobs.WithJobRetryBackoff(time.Millisecond, time.Millisecond, 1),
+	)
+	svc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	jobType := "job"
+	wp.RegisterExecutor(jobType, func(ctx context.Context, j *jobs.Job) error {
+		if j.ExecCount == 1 {
+			return errors.New("test error")
+		}
+		return nil
+	})
+
+	j, err := wp.CreateJob(jobType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(svc, j.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The final transition is recorded right after the job state is updated
+	var events *[]jobs.Event
+	for i := 0; i < 50; i++ {
+		events, err = svc.History(j.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*events) == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expected := []struct {
+		from, to jobs.State
+		attempt  int
+		err      string
+	}{
+		{"", jobs.Init, 0, ""},
+		{jobs.Init, jobs.Accepted, 1, ""},
+		{jobs.Accepted, jobs.Error, 1, "test error"},
+		{jobs.Error, jobs.Accepted, 2, ""},
+		{jobs.Accepted, jobs.Complete, 2, ""},
+	}
+
+	if len(*events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(*events))
+	}
+
+	for i, e := range *events {
+		if e.FromState != expected[i].from || e.ToState != expected[i].to || e.Attempt != expected[i].attempt || e.Error != expected[i].err {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected[i], e)
+		}
+		if e.InstanceID != "test-instance" {
+			t.Errorf("event %d: expected instance id %q, got %q", i, "test-instance", e.InstanceID)
+		}
+	}
+
+	if _, err := svc.History(uuid.NewString()); err == nil {
+		t.Fatal("expected an error for an unknown job")
+	}
+}
+
+func Test_JobsWait(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Minute))
+	svc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	jobType := "job"
+	wp.RegisterExecutor(jobType, func(ctx context.Context, j *jobs.Job) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	j, err := wp.CreateJob(jobType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := svc.Wait(context.Background(), j.ID.String(), 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != jobs.Complete {
+		t.Fatalf("expected job.State = %q, got %q", jobs.Complete, job.State)
+	}
+
+	// A job that is never scheduled is returned as is after the wait
+	unscheduled, err := wp.CreateJob(jobType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Now()
+
+	job, err = svc.Wait(context.Background(), unscheduled.ID.String(), 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != jobs.Init {
+		t.Fatalf("expected job.State = %q, got %q", jobs.Init, job.State)
+	}
+
+	if time.Since(t0) < 100*time.Millisecond {
+		t.Fatal("expected Wait to block for the wait duration")
+	}
+}
@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/google/uuid"
+)
+
+// channelJobListener is a jobs.JobListener for databases without
+// notifications.
+type channelJobListener struct {
+	c chan struct{}
+}
+
+func newChannelJobListener() *channelJobListener {
+	return &channelJobListener{c: make(chan struct{}, 1)}
+}
+
+func (l *channelJobListener) notify() {
+	select {
+	case l.c <- struct{}{}:
+	default:
+	}
+}
+
+func (l *channelJobListener) Notifications() <-chan struct{} { return l.c }
+func (l *channelJobListener) Close() error                   { return nil }
+
+func Test_ClaimJobs(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	t0 := time.Now()
+	insert := func(state jobs.State, nextRunAt, leaseExpiresAt time.Time) *jobs.Job {
+		j := &jobs.Job{
+			ID:             uuid.New(),
+			State:          state,
+			Type:           "job",
+			NextRunAt:      nextRunAt,
+			LeaseOwner:     "other",
+			LeaseExpiresAt: leaseExpiresAt,
+			CreatedAt:      t0,
+			UpdatedAt:      t0,
+		}
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	due := map[uuid.UUID]jobs.State{}
+	for _, j := range []*jobs.Job{
+		insert(jobs.NoAvailableWorkers, t0.Add(-time.Second), time.Time{}),
+		insert(jobs.Error, t0.Add(-time.Second), time.Time{}),
+		insert(jobs.Scheduled, t0.Add(-time.Second), time.Time{}),
+		insert(jobs.Accepted, time.Time{}, t0.Add(-time.Second)),
+	} {
+		due[j.ID] = j.State
+	}
+
+	insert(jobs.Error, t0.Add(time.Hour), time.Time{})
+	insert(jobs.Scheduled, t0.Add(time.Hour), time.Time{})
+	insert(jobs.Accepted, time.Time{}, t0.Add(time.Hour))
+	insert(jobs.Init, time.Time{}, time.Time{})
+	insert(jobs.Complete, t0.Add(-time.Second), time.Time{})
+
+	var (
+		mu      sync.Mutex
+		claimed = map[uuid.UUID]int{}
+		wg      sync.WaitGroup
+	)
+
+	// Concurrent claims never return the same job
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			claims, err := jobStore.ClaimJobs(time.Minute, owner, time.Minute, 10)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, c := range claims {
+				claimed[c.Job.ID]++
+				if c.From != due[c.Job.ID] {
+					t.Errorf("expected job to be claimed from %s, got %s", due[c.Job.ID], c.From)
+				}
+				if c.Job.State != jobs.Accepted || c.Job.LeaseOwner != owner {
+					t.Errorf("expected job to be accepted by %s, got %s by %s", owner, c.Job.State, c.Job.LeaseOwner)
+				}
+			}
+		}(uuid.NewString())
+	}
+
+	wg.Wait()
+
+	if len(claimed) != len(due) {
+		t.Fatalf("expected %d jobs to be claimed, got %d", len(due), len(claimed))
+	}
+
+	for id, n := range claimed {
+		if _, ok := due[id]; !ok || n != 1 {
+			t.Fatalf("expected due job %s to be claimed once, got %d", id, n)
+		}
+	}
+}
+
+func Test_WorkerPoolPushScheduling(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	var mu sync.Mutex
+	executedBy := map[uuid.UUID]string{}
+
+	executor := func(instance string) jobs.ExecutorFunc {
+		return func(ctx context.Context, j *jobs.Job) error {
+			mu.Lock()
+			executedBy[j.ID] = instance
+			mu.Unlock()
+			if instance == "busy" {
+				started <- struct{}{}
+				<-release
+			}
+			return nil
+		}
+	}
+
+	// An instance with a single worker and no queue capacity
+	busy := jobs.NewWorkerPool(jobStore, 0, 1, jobs.WithInstanceID("busy"), jobs.WithDbJobPollInterval(time.Hour), jobs.WithJobListener(newChannelJobListener()))
+	busy.RegisterExecutor("job", executor("busy"))
+
+	// An idle instance only learning about jobs via the listener
+	listener := newChannelJobListener()
+	idle := jobs.NewWorkerPool(jobStore, 0, 1, jobs.WithInstanceID("idle"), jobs.WithDbJobPollInterval(time.Hour), jobs.WithJobListener(listener))
+	idle.RegisterExecutor("job", executor("idle"))
+
+	t.Cleanup(func() {
+		close(release)
+		busy.Stop(false)
+		idle.Stop(false)
+	})
+
+	busy.Start()
+	idle.Start()
+
+	// Let the initial polls pass
+	time.Sleep(100 * time.Millisecond)
+
+	first, err := busy.CreateJob("job", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := busy.Schedule(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the worker to pick up the first job
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected first job to be executed")
+	}
+
+	// No available workers on the busy instance
+	var deferred []*jobs.Job
+	for i := 0; i < 2; i++ {
+		j, err := busy.CreateJob("job", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := busy.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+		if j.State != jobs.NoAvailableWorkers {
+			t.Fatalf("expected job.State = %q, got %q", jobs.NoAvailableWorkers, j.State)
+		}
+		deferred = append(deferred, j)
+	}
+
+	// The database would announce the deferred jobs
+	listener.notify()
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		mu.Lock()
+		n := 0
+		for _, j := range deferred {
+			if executedBy[j.ID] == "idle" {
+				n++
+			}
+		}
+		mu.Unlock()
+
+		if n == len(deferred) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected deferred jobs to be executed by the idle instance, got %d", n)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_NextDueAt(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	t0 := time.Now()
+
+	next, err := jobStore.NextDueAt(t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != nil {
+		t.Fatalf("expected no due job, got %s", next)
+	}
+
+	for _, j := range []*jobs.Job{
+		{State: jobs.Error, NextRunAt: t0.Add(-time.Second)},
+		{State: jobs.Complete, NextRunAt: t0.Add(time.Second)},
+		{State: jobs.Scheduled, NextRunAt: t0.Add(time.Hour)},
+		{State: jobs.Accepted, LeaseExpiresAt: t0.Add(time.Minute)},
+		{State: jobs.Error, NextRunAt: t0.Add(2 * time.Second)},
+	} {
+		j.ID = uuid.New()
+		j.Type = "job"
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next, err = jobStore.NextDueAt(t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next == nil || next.Sub(t0).Round(time.Second) != 2*time.Second {
+		t.Fatalf("expected the retry to be due next, got %v", next)
+	}
+}
+
+func Test_WorkerPoolPushSchedulingWakeUp(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	// A retry whose backoff passes without a notification
+	j := &jobs.Job{
+		ID:        uuid.New(),
+		Type:      "job",
+		State:     jobs.Error,
+		NextRunAt: time.Now().Add(300 * time.Millisecond),
+	}
+	if err := jobStore.InsertJob(j); err != nil {
+		t.Fatal(err)
+	}
+
+	executed := make(chan struct{}, 1)
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Hour), jobs.WithJobListener(newChannelJobListener()))
+	wp.RegisterExecutor("job", func(ctx context.Context, j *jobs.Job) error {
+		executed <- struct{}{}
+		return nil
+	})
+
+	t.Cleanup(func() { wp.Stop(false) })
+
+	wp.Start()
+
+	select {
+	case <-executed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the retry to be executed once due")
+	}
+}
@@ -2,6 +2,8 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +11,8 @@ import (
 	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/handlers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
 	"github.com/gorilla/mux"
 )
 
@@ -47,7 +51,7 @@ func Test_IdempotencyMiddleware(t *testing.T) {
 }
 
 // TODO: Move to test utils
-func sendWithHeaders(router *mux.Router, method, path string, body io.Reader, headers map[string]string) *http.Response {
+func sendWithHeaders(router http.Handler, method, path string, body io.Reader, headers map[string]string) *http.Response {
 	req := httptest.NewRequest(method, path, body)
 	req.Header.Set("content-type", "application/json")
 
@@ -59,3 +63,163 @@ func sendWithHeaders(router *mux.Router, method, path string, body io.Reader, he
 	router.ServeHTTP(rr, req)
 	return rr.Result()
 }
+
+func Test_CallbackURLMiddleware(t *testing.T) {
+	var callbackURL string
+
+	// Dummy endpoint for testing
+	testHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		callbackURL = jobs.CallbackURLFromContext(r.Context())
+
+		// Body must still be readable
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Error(err)
+		}
+
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	router := mux.NewRouter()
+	router.Handle("/test", handlers.UseCallbackURL(testHandler)).Methods(http.MethodPost)
+
+	t.Run("reads header", func(t *testing.T) {
+		callbackURL = ""
+		res := sendWithHeaders(router, http.MethodPost, "/test", bytes.NewBufferString(""), map[string]string{handlers.CallbackURLHeader: "https://example.com/a"})
+		assertStatusCode(t, res, http.StatusOK)
+		if callbackURL != "https://example.com/a" {
+			t.Fatalf("expected callback url from header, got %q", callbackURL)
+		}
+	})
+
+	t.Run("reads body", func(t *testing.T) {
+		callbackURL = ""
+		res := send(router, http.MethodPost, "/test", bytes.NewBufferString(`{"code":"","callbackUrl":"https://example.com/b"}`))
+		assertStatusCode(t, res, http.StatusOK)
+		if callbackURL != "https://example.com/b" {
+			t.Fatalf("expected callback url from body, got %q", callbackURL)
+		}
+	})
+
+	t.Run("returns 400 with an invalid url", func(t *testing.T) {
+		res := sendWithHeaders(router, http.MethodPost, "/test", bytes.NewBufferString(""), map[string]string{handlers.CallbackURLHeader: "ftp://example.com"})
+		assertStatusCode(t, res, http.StatusBadRequest)
+	})
+}
+
+func Test_BackpressureMiddleware(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithJobBackpressure(2, 0, 90*time.Second))
+	wp.RegisterExecutor("job", func(ctx context.Context, j *jobs.Job) error { return nil })
+	t.Cleanup(func() { wp.Stop(false) })
+
+	// Dummy endpoint for testing
+	testHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	router := mux.NewRouter()
+	routes := make(handlers.BackpressureRoutes)
+	routes.Add(router.Handle("/test", testHandler).Methods(http.MethodPost))
+	router.Handle("/other", testHandler).Methods(http.MethodPost)
+
+	h := handlers.UseIdempotency(router, handlers.IdempotencyHandlerOptions{Expiry: time.Minute}, handlers.NewIdempotencyStoreLocal())
+	h = handlers.UseBackpressure(h, wp, router, routes)
+
+	post := func(path, key string) *http.Response {
+		return sendWithHeaders(h, http.MethodPost, path, bytes.NewBufferString(""), map[string]string{"Idempotency-Key": key})
+	}
+
+	t.Run("returns 200 below the high-water mark", func(t *testing.T) {
+		res := post("/test", "below")
+		assertStatusCode(t, res, http.StatusOK)
+	})
+
+	// Fill the queue up to the high-water mark, the pool is not started yet
+	for i := 0; i < 2; i++ {
+		j, err := wp.CreateJob("job", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("returns 503 above the high-water mark", func(t *testing.T) {
+		res := post("/test", "above")
+		assertStatusCode(t, res, http.StatusServiceUnavailable)
+
+		if v := res.Header.Get("Retry-After"); v != "90" {
+			t.Fatalf("expected Retry-After header %q, got %q", "90", v)
+		}
+
+		var body jobs.BackpressureErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.Reason != jobs.BackpressureQueueFull || body.Outstanding != 2 || body.HighWaterMark != 2 || body.RetryAfter != 90 {
+			t.Fatalf("unexpected response body %+v", body)
+		}
+	})
+
+	t.Run("does not reject sync requests", func(t *testing.T) {
+		res := post("/test?sync=go", "sync")
+		assertStatusCode(t, res, http.StatusOK)
+	})
+
+	t.Run("does not reject other routes", func(t *testing.T) {
+		res := post("/other", "other")
+		assertStatusCode(t, res, http.StatusOK)
+	})
+
+	t.Run("accepts a rejected request with the same key once drained", func(t *testing.T) {
+		wp.Start()
+
+		for deadline := time.Now().Add(5 * time.Second); wp.QueueSize() > 0; {
+			if time.Now().After(deadline) {
+				t.Fatal("expected queue to be drained")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		res := post("/test", "above")
+		assertStatusCode(t, res, http.StatusOK)
+	})
+}
+
+func Test_BacklogBackpressure(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	for _, state := range []jobs.State{jobs.Init, jobs.Error, jobs.Scheduled, jobs.Complete} {
+		if err := jobStore.InsertJob(&jobs.Job{Type: "job", State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("below", func(t *testing.T) {
+		wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithJobBackpressure(0, 3, 0))
+		if err := wp.CheckBackpressure(); err != nil {
+			t.Fatalf("expected no backpressure, got %s", err)
+		}
+	})
+
+	t.Run("above", func(t *testing.T) {
+		wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithJobBackpressure(0, 2, 0))
+
+		err := wp.CheckBackpressure()
+		bpErr, ok := err.(*jobs.BackpressureError)
+		if !ok {
+			t.Fatalf("expected a backpressure error, got %v", err)
+		}
+
+		if bpErr.Reason != jobs.BackpressureBacklogFull || bpErr.Outstanding != 2 || bpErr.RetryAfterSeconds() != 30 {
+			t.Fatalf("unexpected backpressure error %+v", bpErr)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func insertAgedJob(t *testing.T, db *gorm.DB, store jobs.Store, jobType string, state jobs.State, age time.Duration) *jobs.Job {
+	t.Helper()
+
+	j := &jobs.Job{Type: jobType, State: state}
+	if err := store.InsertJob(j); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Model(&jobs.Job{}).Where("id = ?", j.ID).UpdateColumn("updated_at", time.Now().Add(-age)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return j
+}
+
+func jobExists(t *testing.T, db *gorm.DB, id uuid.UUID) bool {
+	t.Helper()
+
+	var count int64
+	if err := db.Unscoped().Model(&jobs.Job{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return count > 0
+}
+
+func Test_JobPruning(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 10,
+		jobs.WithJobRetentionPolicies([]string{
+			"send_job_status:COMPLETE:1h",
+			"*:*:720h",
+			"transaction:COMPLETE:8760h",
+		}),
+		jobs.WithJobPruning(time.Hour, 2),
+	)
+
+	day := 24 * time.Hour
+
+	pruned := []*jobs.Job{
+		insertAgedJob(t, db, jobStore, jobs.SendJobStatusJobType, jobs.Complete, 2*time.Hour),
+		insertAgedJob(t, db, jobStore, jobs.SendJobStatusJobType, jobs.Complete, 3*time.Hour),
+		insertAgedJob(t, db, jobStore, jobs.SendJobStatusJobType, jobs.Complete, 4*time.Hour),
+		insertAgedJob(t, db, jobStore, "account_create", jobs.Failed, 40*day),
+		insertAgedJob(t, db, jobStore, "transaction", jobs.Failed, 40*day),
+	}
+
+	kept := []*jobs.Job{
+		insertAgedJob(t, db, jobStore, jobs.SendJobStatusJobType, jobs.Complete, time.Minute),
+		insertAgedJob(t, db, jobStore, jobs.SendJobStatusJobType, jobs.Error, 40*day),
+		insertAgedJob(t, db, jobStore, "account_create", jobs.Complete, 20*day),
+		insertAgedJob(t, db, jobStore, "transaction", jobs.Complete, 40*day),
+		insertAgedJob(t, db, jobStore, "transaction", jobs.Init, 40*day),
+	}
+
+	n, err := wp.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != len(pruned) {
+		t.Fatalf("expected %d jobs to be pruned, got %d", len(pruned), n)
+	}
+
+	for _, j := range pruned {
+		if jobExists(t, db, j.ID) {
+			t.Errorf("expected %s job in state %s to be pruned", j.Type, j.State)
+		}
+	}
+
+	for _, j := range kept {
+		if !jobExists(t, db, j.ID) {
+			t.Errorf("expected %s job in state %s to be kept", j.Type, j.State)
+		}
+	}
+
+	status, err := wp.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.JobsCompleted != 3 || status.JobsFailed != 0 || status.JobsErrored != 1 || status.JobsInit != 1 {
+		t.Fatalf("expected status counts to reflect pruned jobs, got %+v", status.JobQueueStatus)
+	}
+}
+
+func Test_JobArchive(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		cfg := test.LoadConfig(t)
+		db := test.GetDatabase(t, cfg)
+		jobStore := jobs.NewGormStore(db)
+
+		wp := jobs.NewWorkerPool(jobStore, 10, 10,
+			jobs.WithJobRetentionPolicies([]string{"*:COMPLETE:1h"}),
+			jobs.WithJobArchive(jobs.JobArchiveTable, ""),
+		)
+
+		j := insertAgedJob(t, db, jobStore, "job", jobs.Complete, 2*time.Hour)
+
+		if _, err := wp.Prune(); err != nil {
+			t.Fatal(err)
+		}
+
+		if jobExists(t, db, j.ID) {
+			t.Fatal("expected job to be removed from the jobs table")
+		}
+
+		var archived jobs.ArchivedJob
+		if err := db.First(&archived, "id = ?", j.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if archived.Type != "job" || archived.State != jobs.Complete {
+			t.Fatalf("unexpected archived job %+v", archived)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		cfg := test.LoadConfig(t)
+		db := test.GetDatabase(t, cfg)
+		jobStore := jobs.NewGormStore(db)
+
+		path := filepath.Join(t.TempDir(), "jobs.ndjson")
+
+		wp := jobs.NewWorkerPool(jobStore, 10, 10,
+			jobs.WithJobRetentionPolicies([]string{"*:COMPLETE:1h"}),
+			jobs.WithJobArchive(jobs.JobArchiveFile, path),
+			jobs.WithJobPruning(time.Hour, 1),
+		)
+
+		j1 := insertAgedJob(t, db, jobStore, "job", jobs.Complete, 2*time.Hour)
+		j2 := insertAgedJob(t, db, jobStore, "job", jobs.Complete, 3*time.Hour)
+
+		if _, err := wp.Prune(); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		ids := make(map[uuid.UUID]bool)
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var archived jobs.ArchivedJob
+			if err := json.Unmarshal(scanner.Bytes(), &archived); err != nil {
+				t.Fatal(err)
+			}
+			ids[archived.ID] = true
+		}
+
+		if len(ids) != 2 || !ids[j1.ID] || !ids[j2.ID] {
+			t.Fatalf("expected both jobs in the archive file, got %v", ids)
+		}
+
+		if jobExists(t, db, j1.ID) || jobExists(t, db, j2.ID) {
+			t.Fatal("expected jobs to be removed from the jobs table")
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/google/uuid"
+)
+
+func Test_WorkerPoolRunAt(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 10, jobs.WithDbJobPollInterval(50*time.Millisecond))
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	executed := make(chan time.Time, 1)
+	wp.RegisterExecutor("job", func(ctx context.Context, j *jobs.Job) error {
+		executed <- time.Now()
+		return nil
+	})
+
+	wp.Start()
+
+	runAt := time.Now().Add(500 * time.Millisecond)
+
+	j, err := wp.CreateJob("job", "", jobs.WithRunAt(runAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := jobStore.Job(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != jobs.Scheduled {
+		t.Fatalf("expected job.State = %q, got %q", jobs.Scheduled, job.State)
+	}
+
+	select {
+	case at := <-executed:
+		if at.Before(runAt) {
+			t.Fatalf("expected job to be executed at or after %s, got %s", runAt, at)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected scheduled job to be executed")
+	}
+}
+
+func Test_JobScheduleMaterializedOnce(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	var mu sync.Mutex
+	executed := make(map[uuid.UUID]int)
+
+	executor := func(ctx context.Context, j *jobs.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		executed[j.ID]++
+		return nil
+	}
+
+	// Two instances polling the same database
+	pools := make([]jobs.WorkerPool, 2)
+	for i := range pools {
+		wp := jobs.NewWorkerPool(jobStore, 10, 10, jobs.WithDbJobPollInterval(20*time.Millisecond))
+		wp.RegisterExecutor("job", executor)
+		pools[i] = wp
+	}
+
+	t.Cleanup(func() {
+		for _, wp := range pools {
+			wp.Stop(false)
+		}
+	})
+
+	svc := jobs.NewScheduleService(jobStore, pools[0])
+
+	sch, err := svc.Create(jobs.JobScheduleJSONRequest{
+		Name:       "nightly sweep",
+		Cron:       "0 3 * * *",
+		JobType:    "job",
+		Attributes: json.RawMessage(`{"sweep":true}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !sch.NextRunAt.After(time.Now()) {
+		t.Fatalf("expected next run in the future, got %s", sch.NextRunAt)
+	}
+
+	// Make the schedule due
+	sch.NextRunAt = time.Now().Add(-time.Second)
+	if err := jobStore.UpdateJobSchedule(sch); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, wp := range pools {
+		wp.Start()
+	}
+
+	var stored jobs.JobSchedule
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		stored, err = jobStore.JobSchedule(sch.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.LastJobID != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected schedule to be run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Give the other instance a chance to run the schedule again
+	time.Sleep(200 * time.Millisecond)
+
+	stored, err = jobStore.JobSchedule(sch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.RunCount != 1 {
+		t.Fatalf("expected schedule to be run once, got %d", stored.RunCount)
+	}
+
+	if !stored.NextRunAt.After(time.Now()) {
+		t.Fatalf("expected next run in the future, got %s", stored.NextRunAt)
+	}
+
+	created, err := jobStore.Jobs(jobs.Filter{Types: []string{"job"}}, datastore.ParseListOptions(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 1 || created[0].ID != *stored.LastJobID {
+		t.Fatalf("expected exactly one job to be created, got %d", len(created))
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(created[0].Attributes, &attrs); err != nil {
+		t.Fatal(err)
+	}
+
+	if attrs["sweep"] != true || attrs["scheduleId"] != sch.ID.String() {
+		t.Fatalf("expected job attributes to contain the schedule attributes and id, got %v", attrs)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		mu.Lock()
+		n := executed[created[0].ID]
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected scheduled job to be executed once, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_JobScheduleValidation(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 10)
+	wp.RegisterExecutor("job", func(ctx context.Context, j *jobs.Job) error { return nil })
+	svc := jobs.NewScheduleService(jobStore, wp)
+
+	cases := []struct {
+		name string
+		req  jobs.JobScheduleJSONRequest
+	}{
+		{"invalid cron", jobs.JobScheduleJSONRequest{Cron: "* * *", JobType: "job"}},
+		{"never matching cron", jobs.JobScheduleJSONRequest{Cron: "0 0 31 feb *", JobType: "job"}},
+		{"unknown job type", jobs.JobScheduleJSONRequest{Cron: "@daily", JobType: "unknown"}},
+		{"invalid timezone", jobs.JobScheduleJSONRequest{Cron: "@daily", JobType: "job", Timezone: "Nowhere/Special"}},
+		{"non-object attributes", jobs.JobScheduleJSONRequest{Cron: "@daily", JobType: "job", Attributes: json.RawMessage(`[1]`)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.Create(c.req)
+			reqErr, ok := err.(*errors.RequestError)
+			if !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error, got %v", err)
+			}
+		})
+	}
+
+	sch, err := svc.Create(jobs.JobScheduleJSONRequest{Cron: "@daily", JobType: "job"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := false
+	updated, err := svc.Update(sch.ID.String(), jobs.JobScheduleJSONRequest{Cron: "@hourly", JobType: "job", Enabled: &disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Enabled || updated.ToJSONResponse().NextRunAt != nil {
+		t.Fatal("expected schedule to be disabled")
+	}
+
+	if err := svc.Delete(sch.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Details(sch.ID.String())
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/templates"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/onflow/cadence"
+)
+
+func Test_CodeTemplateVersions(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	svc := templates.NewService(cfg, templates.NewGormStore(test.GetDatabase(t, cfg)))
+
+	req := templates.CodeTemplateJSONRequest{
+		Name:      "transfer-flow",
+		Kind:      templates.TransactionTemplate,
+		Token:     "FlowToken",
+		Code:      "import FungibleToken from \"./FungibleToken.cdc\"\nimport TOKEN_DECLARATION_NAME from TOKEN_ADDRESS\ntransaction(amount: UFix64, recipient: Address) {}",
+		Arguments: templates.ArgumentSchemas{{Name: "amount", Type: "UFix64"}, {Name: "recipient", Type: "Address"}},
+	}
+
+	v1, err := svc.AddCodeTemplate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v1.Version != 1 {
+		t.Fatalf("expected version 1, got %d", v1.Version)
+	}
+
+	if strings.Contains(v1.Code, ".cdc") || strings.Contains(v1.Code, "TOKEN_") {
+		t.Fatalf("expected the placeholders to have been replaced, got %q", v1.Code)
+	}
+
+	if !strings.Contains(v1.Code, "import FlowToken from 0x0ae53cb6e3f42a79") {
+		t.Fatalf("expected the token to be imported from its address, got %q", v1.Code)
+	}
+
+	req.Arguments = append(req.Arguments, templates.ArgumentSchema{Name: "memo", Type: "String"})
+
+	v2, err := svc.AddCodeTemplate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v2.Version != 2 {
+		t.Fatalf("expected version 2, got %d", v2.Version)
+	}
+
+	latest, err := svc.GetCodeTemplate(req.Name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Version != 2 || len(latest.Arguments) != 3 {
+		t.Fatalf("expected the latest version to be 2 with 3 arguments, got %d with %d", latest.Version, len(latest.Arguments))
+	}
+
+	first, err := svc.GetCodeTemplate(req.Name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Version != 1 || len(first.Arguments) != 2 {
+		t.Fatalf("expected version 1 with 2 arguments, got %d with %d", first.Version, len(first.Arguments))
+	}
+
+	all, err := svc.ListCodeTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 2 {
+		t.Fatalf("expected 2 template versions, got %d", len(all))
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		for _, version := range []int{0, 3} {
+			name := req.Name
+			if version == 0 {
+				name = "unknown"
+			}
+
+			_, err := svc.GetCodeTemplate(name, version)
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusNotFound {
+				t.Fatalf("expected a not found error, got %#v", err)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []templates.CodeTemplateJSONRequest{
+			{Name: "with space", Kind: templates.ScriptTemplate, Code: "pub fun main() {}"},
+			{Name: "script", Kind: "contract", Code: "pub fun main() {}"},
+			{Name: "script", Kind: templates.ScriptTemplate, Code: " "},
+			{Name: "script", Kind: templates.ScriptTemplate, Code: "pub fun main(a: Int) {}", Arguments: templates.ArgumentSchemas{{Name: "a", Type: "Int"}, {Name: "a", Type: "Int"}}},
+			{Name: "script", Kind: templates.ScriptTemplate, Code: "pub fun main() {}", Token: "unknown"},
+		}
+
+		for _, req := range invalid {
+			_, err := svc.AddCodeTemplate(req)
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error for %+v, got %#v", req, err)
+			}
+		}
+	})
+}
+
+func Test_CodeTemplateScript(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	tmpl, err := svcs.GetTemplates().AddCodeTemplate(templates.CodeTemplateJSONRequest{
+		Name:      "add",
+		Kind:      templates.ScriptTemplate,
+		Code:      "pub fun main(a: Int, b: Int): Int { return a + b }",
+		Arguments: templates.ArgumentSchemas{{Name: "a", Type: "Int"}, {Name: "b", Type: "Int"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := tmpl.CadenceArguments(map[string]json.RawMessage{
+		"a": json.RawMessage(`1`),
+		"b": json.RawMessage(`{"type":"Int","value":"2"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := make([]transactions.Argument, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	res, err := svcs.GetTransactions().ExecuteScript(ctx, tmpl.Code, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sum, ok := res.(cadence.Int); !ok || sum.Int() != 3 {
+		t.Fatalf("expected 3, got %v", res)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
 	"github.com/onflow/flow-go-sdk"
-	"github.com/onflow/flow-go-sdk/client"
 	"github.com/onflow/flow-go-sdk/crypto"
 	"github.com/onflow/flow-go-sdk/templates"
 	"google.golang.org/grpc"
@@ -25,7 +24,7 @@ var (
 )
 
 func NewFlowClient(t *testing.T, cfg *configs.Config) flow_helpers.FlowClient {
-	fc, err := client.New(cfg.AccessAPIHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	fc, err := flow_helpers.NewClient(cfg.AccessAPIHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -47,6 +46,14 @@ func NewFlowClient(t *testing.T, cfg *configs.Config) flow_helpers.FlowClient {
 }
 
 func NewFlowAccount(t *testing.T, fc flow_helpers.FlowClient, creatorAddress flow.Address, creatorKey *flow.AccountKey, creatorSigner crypto.Signer) *flow.Account {
+	a, _ := NewFlowAccountWithSigner(t, fc, creatorAddress, creatorKey, creatorSigner)
+	return a
+}
+
+// NewFlowAccountWithSigner creates a new account like NewFlowAccount and also
+// returns a signer for its key, e.g. to sign as an account not held by the
+// wallet.
+func NewFlowAccountWithSigner(t *testing.T, fc flow_helpers.FlowClient, creatorAddress flow.Address, creatorKey *flow.AccountKey, creatorSigner crypto.Signer) (*flow.Account, crypto.Signer) {
 	seed := make([]byte, seed_length)
 	readRandom(t, seed)
 
@@ -110,7 +117,7 @@ func NewFlowAccount(t *testing.T, fc flow_helpers.FlowClient, creatorAddress flo
 		t.Fatal(err)
 	}
 
-	return a
+	return a, crypto.NewInMemorySigner(privateKey, accountKey.HashAlgo)
 }
 
 func readRandom(t *testing.T, buf []byte) {
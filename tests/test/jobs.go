@@ -2,9 +2,14 @@ package test
 
 import (
 	"fmt"
+	"testing"
 	"time"
 
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	upstreamgorm "gorm.io/gorm"
 )
 
 func WaitForJob(jobSvc jobs.Service, jobId string) (*jobs.Job, error) {
@@ -19,3 +24,46 @@ func WaitForJob(jobSvc jobs.Service, jobId string) (*jobs.Job, error) {
 		time.Sleep(100 * time.Millisecond)
 	}
 }
+
+// GetJobStore returns the job store configured with FLOW_WALLET_JOB_STORE_TYPE,
+// by default a store on the test database db. A Redis job store uses a key
+// prefix of its own for each test and its keys are removed after the test.
+func GetJobStore(t *testing.T, cfg *configs.Config, db *upstreamgorm.DB) jobs.Store {
+	if cfg.JobStoreType != "redis" {
+		return jobs.NewGormStore(db)
+	}
+
+	if cfg.JobStoreRedisURL == "" {
+		t.Fatal("job store set to redis but Redis URL is empty")
+	}
+
+	pool := &redis.Pool{
+		MaxIdle: 10,
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(cfg.JobStoreRedisURL)
+		},
+	}
+
+	prefix := fmt.Sprintf("%stest:%s:", cfg.JobStoreRedisKeyPrefix, uuid.NewString())
+
+	t.Cleanup(func() {
+		defer pool.Close()
+
+		c := pool.Get()
+		defer c.Close()
+
+		keys, err := redis.Strings(c.Do("KEYS", prefix+"*"))
+		if err != nil {
+			t.Logf("error while cleaning test job store: %s", err)
+			return
+		}
+
+		for _, key := range keys {
+			if _, err := c.Do("DEL", key); err != nil {
+				t.Logf("error while cleaning test job store: %s", err)
+			}
+		}
+	})
+
+	return jobs.NewRedisStore(pool, prefix)
+}
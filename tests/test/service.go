@@ -101,6 +101,7 @@ func GetServices(t *testing.T, cfg *configs.Config) Services {
 		jobs.WithDbJobPollInterval(time.Second),
 		jobs.WithAcceptedGracePeriod(1000),
 		jobs.WithReSchedulableGracePeriod(1000),
+		jobs.WithJobRetryBackoff(time.Millisecond, time.Second, 2),
 		jobs.WithSystemService(systemService),
 	)
 
@@ -109,7 +110,7 @@ func GetServices(t *testing.T, cfg *configs.Config) Services {
 	templateService := templates.NewService(cfg, templates.NewGormStore(db))
 	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp)
 	accountService := accounts.NewService(cfg, accounts.NewGormStore(db), km, fc, wp, transactionService)
-	jobService := jobs.NewService(jobs.NewGormStore(db))
+	jobService := jobs.NewService(jobs.NewGormStore(db), wp)
 	tokenService := tokens.NewService(cfg, tokens.NewGormStore(db), km, fc, wp, transactionService, templateService, accountService)
 
 	getTypes := func() ([]string, error) {
@@ -2,20 +2,28 @@ package tests
 
 import (
 	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
 	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
 	"github.com/flow-hydraulics/flow-wallet-api/transactions"
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
 )
 
 func Test_TransactionSignByAdmin(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	txSvc := test.GetServices(t, cfg).GetTransactions()
 
-	tx, err := txSvc.Sign(context.Background(), cfg.AdminAddress, "", nil)
+	tx, err := txSvc.Sign(context.Background(), cfg.AdminAddress, nil, "", nil)
 	if err != nil {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
@@ -45,7 +53,7 @@ func Test_TransactionSignByAnotherAccount(t *testing.T) {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
 
-	tx, err := svcs.GetTransactions().Sign(ctx, acc.Address, "", nil)
+	tx, err := svcs.GetTransactions().Sign(ctx, acc.Address, nil, "", nil)
 	if err != nil {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
@@ -66,6 +74,77 @@ func Test_TransactionSignByAnotherAccount(t *testing.T) {
 	}
 }
 
+func Test_TransactionSignWithMultipleAuthorizers(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+
+	_, acc1, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	_, acc2, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	code := "transaction() { prepare(a: AuthAccount, b: AuthAccount, c: AuthAccount){} execute {}}"
+	authorizers := []string{acc1.Address, acc2.Address, cfg.AdminAddress}
+
+	tx, err := svcs.GetTransactions().Sign(ctx, acc1.Address, authorizers, code, nil)
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	if len(tx.Authorizers) != len(authorizers) {
+		t.Fatalf("expected len(tx.Authorizers) == %d, got %d", len(authorizers), len(tx.Authorizers))
+	}
+
+	for i, a := range authorizers {
+		if tx.Authorizers[i] != flow.HexToAddress(a) {
+			t.Fatalf("expected authorizer %d to be %s, got %s", i, a, tx.Authorizers[i])
+		}
+	}
+
+	// Both custodial accounts sign the payload once, the admin (payer) signs the envelope.
+	if len(tx.PayloadSignatures) != 2 {
+		t.Fatalf("expected len(tx.PayloadSignatures) == 2, got %d", len(tx.PayloadSignatures))
+	}
+
+	if !addressExists(acc1.Address, tx.PayloadSignatures) || !addressExists(acc2.Address, tx.PayloadSignatures) {
+		t.Fatalf("couldn't find authorizers' addresses from payload signatures")
+	}
+
+	if !addressExists(cfg.AdminAddress, tx.EnvelopeSignatures) {
+		t.Fatalf("couldn't find payer's address from envelope signatures")
+	}
+}
+
+func Test_TransactionInvalidAuthorizers(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	txSvc := test.GetServices(t, cfg).GetTransactions()
+
+	testCases := []struct {
+		name        string
+		authorizers []string
+	}{
+		{name: "invalid address", authorizers: []string{"not-an-address"}},
+		{name: "duplicate address", authorizers: []string{cfg.AdminAddress, cfg.AdminAddress}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := txSvc.Sign(ctx, cfg.AdminAddress, tc.authorizers, "", nil)
+
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error, got %#v", err)
+			}
+		})
+	}
+}
+
 func addressExists(addr string, sigs []flow.TransactionSignature) bool {
 	addr = strings.TrimPrefix(addr, "0x")
 	for _, s := range sigs {
@@ -91,7 +170,7 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 
 		ctx := context.Background()
 
-		_, tx, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
+		_, tx, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -113,7 +192,7 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 	})
 
 	t.Run("update sequence number during job run", func(t *testing.T) {
-		t.Skip("not supported currently")
+		t.Skip("not supported currently, transactions are only rebuilt when their job is retried")
 
 		cfg := test.LoadConfig(t)
 		cfg.AdminProposalKeyCount = 1
@@ -129,12 +208,12 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 
 		ctx := context.Background()
 
-		job1, tx1, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
+		job1, tx1, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		job2, tx2, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
+		job2, tx2, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -169,6 +248,689 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 		if _, err := test.WaitForJob(app.GetJobs(), job2.ID.String()); err != nil {
 			t.Error(err)
 		}
+
 	})
 
+	t.Run("transaction is sent as built", func(t *testing.T) {
+		cfg := test.LoadConfig(t)
+		app := test.GetServices(t, cfg)
+		txSvc := app.GetTransactions()
+
+		ctx := context.Background()
+
+		job, original, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: AuthAccount){} execute {}}", nil, transactions.General)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := test.WaitForJob(app.GetJobs(), job.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only a transaction which can no longer be included is rebuilt
+		tx, err := txSvc.Details(ctx, original.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tx.TransactionId != original.TransactionId || tx.OriginalTransactionId != "" {
+			t.Fatalf("expected transaction %s not to have been rebuilt, got %s", original.TransactionId, tx.TransactionId)
+		}
+	})
+
+}
+
+func Test_TransactionStoreReplaceTransaction(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := transactions.NewGormStore(test.GetDatabase(t, cfg))
+
+	originalId := flow.HexToID("01").Hex()
+	rebuiltId := flow.HexToID("02").Hex()
+
+	tx := &transactions.Transaction{
+		TransactionId:   originalId,
+		TransactionType: transactions.General,
+		ProposerAddress: cfg.AdminAddress,
+		FlowTransaction: []byte("original"),
+	}
+
+	if err := store.InsertTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.OriginalTransactionId = originalId
+	tx.TransactionId = rebuiltId
+	tx.FlowTransaction = []byte("rebuilt")
+
+	if err := store.ReplaceTransaction(originalId, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{originalId, rebuiltId} {
+		stored, err := store.Transaction(id)
+		if err != nil {
+			t.Fatalf("expected to find transaction by id %s, got %#v", id, err)
+		}
+
+		if stored.TransactionId != rebuiltId || stored.OriginalTransactionId != originalId {
+			t.Fatalf("expected transaction %s (original %s), got %s (original %s)", rebuiltId, originalId, stored.TransactionId, stored.OriginalTransactionId)
+		}
+
+		if string(stored.FlowTransaction) != "rebuilt" {
+			t.Fatalf("expected the rebuilt flow transaction, got %q", stored.FlowTransaction)
+		}
+
+		if _, err := store.TransactionForAccount(transactions.General, cfg.AdminAddress, id); err != nil {
+			t.Fatalf("expected to find account transaction by id %s, got %#v", id, err)
+		}
+	}
+
+	if err := store.ReplaceTransaction(originalId, tx); err == nil {
+		t.Fatal("expected an error when replacing a transaction which no longer exists")
+	}
+}
+
+func Test_TransactionStoreResult(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := transactions.NewGormStore(test.GetDatabase(t, cfg))
+
+	tx := &transactions.Transaction{
+		TransactionId:   flow.HexToID("01").Hex(),
+		TransactionType: transactions.General,
+		ProposerAddress: cfg.AdminAddress,
+	}
+
+	if err := store.InsertTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.Transaction(tx.TransactionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Status != transactions.StatusPending {
+		t.Fatalf("expected new transaction to be %s, got %s", transactions.StatusPending, stored.Status)
+	}
+
+	payload := []byte(`{"type":"Event","value":{"id":"A.f919ee77447b7497.FlowFees.FeesDeducted","fields":[{"name":"amount","value":{"type":"UFix64","value":"0.00001000"}}]}}`)
+	value, err := c_json.Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.Status = transactions.StatusSealed
+	tx.BlockId = flow.HexToID("02").Hex()
+	tx.BlockHeight = 42
+	tx.Events = transactions.Events{{
+		Type:          "A.f919ee77447b7497.FlowFees.FeesDeducted",
+		TransactionID: flow.HexToID(tx.TransactionId),
+		Value:         value.(cadence.Event),
+		Payload:       payload,
+	}}
+	tx.Fees = tx.Events.Fees()
+
+	if err := store.UpdateTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err = store.Transaction(tx.TransactionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Status != transactions.StatusSealed || stored.BlockId != tx.BlockId || stored.BlockHeight != tx.BlockHeight {
+		t.Fatalf("expected a sealed transaction in block %s (%d), got %s in block %s (%d)", tx.BlockId, tx.BlockHeight, stored.Status, stored.BlockId, stored.BlockHeight)
+	}
+
+	if stored.Fees != "0.00001000" {
+		t.Fatalf("expected fees 0.00001000, got %q", stored.Fees)
+	}
+
+	if len(stored.Events) != 1 || stored.Events[0].Type != tx.Events[0].Type || stored.Events[0].Value.String() != tx.Events[0].Value.String() {
+		t.Fatalf("expected events %v, got %v", tx.Events, stored.Events)
+	}
+}
+
+func Test_TransactionSubmit(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+
+	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sign the payload "outside of the wallet", leaving the envelope to the admin payer
+	signer, err := svcs.GetKeyManager().UserAuthorizer(ctx, flow.HexToAddress(acc.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latestBlock, err := svcs.GetFlowClient().GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTx := func() *flow.Transaction {
+		return flow.NewTransaction().
+			SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+			SetReferenceBlockID(latestBlock.ID).
+			SetGasLimit(9999).
+			SetProposalKey(signer.Address, signer.Key.Index, signer.Key.SequenceNumber).
+			SetPayer(flow.HexToAddress(cfg.AdminAddress)).
+			AddAuthorizer(signer.Address)
+	}
+
+	flowTx := newTx()
+	if err := flowTx.SignPayload(signer.Address, signer.Key.Index, signer.Signer); err != nil {
+		t.Fatal(err)
+	}
+
+	_, tx, err := txSvc.Submit(ctx, true, flowTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Status != transactions.StatusSealed {
+		t.Fatalf("expected transaction to be %s, got %s (%s)", transactions.StatusSealed, tx.Status, tx.Error)
+	}
+
+	if !addressExists(cfg.AdminAddress, flowTx.EnvelopeSignatures) {
+		t.Fatal("expected the admin to have signed the envelope")
+	}
+
+	if _, err := txSvc.Details(ctx, tx.TransactionId); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("already submitted", func(t *testing.T) {
+		_, _, err := txSvc.Submit(ctx, true, flowTx)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+			t.Fatalf("expected a conflict error, got %#v", err)
+		}
+	})
+
+	t.Run("missing payer envelope signature", func(t *testing.T) {
+		flowTx := newTx().SetPayer(signer.Address)
+		if err := flowTx.SignPayload(signer.Address, signer.Key.Index, signer.Signer); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err := txSvc.Submit(ctx, true, flowTx)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a bad request error, got %#v", err)
+		}
+	})
+
+	t.Run("admin as authorizer", func(t *testing.T) {
+		flowTx := newTx().AddAuthorizer(flow.HexToAddress(cfg.AdminAddress))
+		if err := flowTx.SignPayload(signer.Address, signer.Key.Index, signer.Signer); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err := txSvc.Submit(ctx, true, flowTx)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a bad request error, got %#v", err)
+		}
+	})
+}
+
+func Test_TransactionCoSigningSession(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+	fc := svcs.GetFlowClient()
+
+	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := svcs.GetKeyManager().AdminAuthorizer(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An account whose keys are not held by the wallet
+	external, externalSigner := test.NewFlowAccountWithSigner(t, fc, admin.Address, admin.Key, admin.Signer)
+	externalAddress := flow_helpers.FormatAddress(external.Address)
+
+	session, err := txSvc.CreateCoSigningSession(ctx, transactions.CoSigningSessionJSONRequest{
+		Code:        "transaction() { prepare(a: AuthAccount, b: AuthAccount){} execute {}}",
+		Proposer:    acc.Address,
+		Authorizers: []string{acc.Address, externalAddress},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.State != transactions.CoSigningOpen {
+		t.Fatalf("expected session to be %s, got %s", transactions.CoSigningOpen, session.State)
+	}
+
+	if len(session.PendingSigners) != 1 || session.PendingSigners[0] != externalAddress {
+		t.Fatalf("expected %s to be the only pending signer, got %v", externalAddress, session.PendingSigners)
+	}
+
+	flowTx, err := flow.DecodeTransaction(session.FlowTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !addressExists(acc.Address, flowTx.PayloadSignatures) {
+		t.Fatal("expected the custodial proposer to have signed the payload")
+	}
+
+	if len(flowTx.EnvelopeSignatures) != 0 {
+		t.Fatal("expected the envelope not to be signed before every signer has signed")
+	}
+
+	// Sign the payload "outside of the wallet"
+	if err := flowTx.SignPayload(external.Address, external.Keys[0].Index, externalSigner); err != nil {
+		t.Fatal(err)
+	}
+
+	var signature transactions.TransactionSignatureJSON
+	for _, s := range flowTx.PayloadSignatures {
+		if s.Address == external.Address {
+			signature = transactions.TransactionSignatureJSON{
+				Address:   externalAddress,
+				KeyIndex:  s.KeyIndex,
+				Signature: hex.EncodeToString(s.Signature),
+			}
+		}
+	}
+
+	t.Run("invalid signature", func(t *testing.T) {
+		invalid := signature
+		invalid.Signature = hex.EncodeToString(make([]byte, 64))
+
+		_, err := txSvc.AddCoSigningSignature(ctx, session.ID.String(), invalid)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a bad request error, got %#v", err)
+		}
+	})
+
+	t.Run("not a pending signer", func(t *testing.T) {
+		notPending := signature
+		notPending.Address = acc.Address
+
+		_, err := txSvc.AddCoSigningSignature(ctx, session.ID.String(), notPending)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a bad request error, got %#v", err)
+		}
+	})
+
+	session, err = txSvc.AddCoSigningSignature(ctx, session.ID.String(), signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.State != transactions.CoSigningSubmitted || session.JobId == nil {
+		t.Fatalf("expected session to be %s as a job, got %s", transactions.CoSigningSubmitted, session.State)
+	}
+
+	if _, err := test.WaitForJob(svcs.GetJobs(), session.JobId.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := txSvc.Details(ctx, session.TransactionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Status != transactions.StatusSealed {
+		t.Fatalf("expected transaction to be %s, got %s (%s)", transactions.StatusSealed, tx.Status, tx.Error)
+	}
+
+	t.Run("already submitted", func(t *testing.T) {
+		_, err := txSvc.AddCoSigningSignature(ctx, session.ID.String(), signature)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+			t.Fatalf("expected a conflict error, got %#v", err)
+		}
+	})
+
+	t.Run("payer not held by the wallet", func(t *testing.T) {
+		_, err := txSvc.CreateCoSigningSession(ctx, transactions.CoSigningSessionJSONRequest{
+			Code:     "transaction() { prepare(a: AuthAccount){} execute {}}",
+			Proposer: acc.Address,
+			Payer:    externalAddress,
+		})
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a bad request error, got %#v", err)
+		}
+	})
+}
+
+func Test_TransactionStoreCoSigningSession(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	store := transactions.NewGormStore(test.GetDatabase(t, cfg))
+
+	session := &transactions.CoSigningSession{
+		State:           transactions.CoSigningOpen,
+		ProposerAddress: cfg.AdminAddress,
+		PayerAddress:    cfg.AdminAddress,
+		PendingSigners:  []string{"0x01", "0x02"},
+		FlowTransaction: []byte("unsigned"),
+	}
+
+	if err := store.InsertCoSigningSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := store.CoSigningSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session.PendingSigners = session.PendingSigners[1:]
+	session.FlowTransaction = []byte("signed by 0x01")
+
+	if err := store.UpdateCoSigningSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.CoSigningSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored.PendingSigners) != 1 || stored.PendingSigners[0] != "0x02" || string(stored.FlowTransaction) != "signed by 0x01" {
+		t.Fatalf("expected the updated session, got %v, %q", stored.PendingSigners, stored.FlowTransaction)
+	}
+
+	// Updating the session as read before the update above must fail
+	read.PendingSigners = read.PendingSigners[:1]
+	if err := store.UpdateCoSigningSession(&read); err != transactions.ErrCoSigningSessionChanged {
+		t.Fatalf("expected %v, got %v", transactions.ErrCoSigningSessionChanged, err)
+	}
+}
+
+// sealedHeightFlowClient returns a latest sealed block at height `height`.
+type sealedHeightFlowClient struct {
+	flow_helpers.FlowClient
+	height uint64
+}
+
+func (c *sealedHeightFlowClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{Height: c.height}, nil
+}
+
+func Test_TransactionCoSigningSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := transactions.NewGormStore(db)
+	fc := &sealedHeightFlowClient{height: 1000}
+	wp := jobs.NewWorkerPool(test.GetJobStore(t, cfg, db), 10, 1)
+	// Expired sessions are rejected before reaching the key manager
+	svc := transactions.NewService(cfg, store, nil, fc, wp)
+
+	session := &transactions.CoSigningSession{
+		State:           transactions.CoSigningOpen,
+		ProposerAddress: cfg.AdminAddress,
+		PayerAddress:    cfg.AdminAddress,
+		PendingSigners:  []string{"0x01cf0e2f2f715450"},
+		FlowTransaction: flow.NewTransaction().Encode(),
+		ExpiresAtHeight: fc.height,
+	}
+
+	if err := store.InsertCoSigningSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	signature := transactions.TransactionSignatureJSON{
+		Address:   "0x01cf0e2f2f715450",
+		Signature: hex.EncodeToString(make([]byte, 64)),
+	}
+
+	expectGone := func(err error) {
+		t.Helper()
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusGone {
+			t.Fatalf("expected a %d request error, got %#v", http.StatusGone, err)
+		}
+	}
+
+	_, err := svc.AddCoSigningSignature(ctx, session.ID.String(), signature)
+	expectGone(err)
+
+	stored, err := store.CoSigningSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.State != transactions.CoSigningExpired {
+		t.Fatalf("expected session to be %s, got %s", transactions.CoSigningExpired, stored.State)
+	}
+
+	// Expired sessions stay expired
+	_, err = svc.AddCoSigningSignature(ctx, session.ID.String(), signature)
+	expectGone(err)
+}
+
+func Test_TransactionCoSigningSessionInterruptedSubmit(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := transactions.NewGormStore(db)
+	wp := jobs.NewWorkerPool(test.GetJobStore(t, cfg, db), 10, 1)
+	// The signed transaction is submitted again without the key manager or
+	// the chain
+	svc := transactions.NewService(cfg, store, nil, nil, wp)
+
+	flowTx := flow.NewTransaction().
+		SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+		SetPayer(flow.HexToAddress(cfg.AdminAddress))
+	transactionId := flowTx.ID().Hex()
+
+	// Interrupted after storing the transaction but before the job was
+	// stored with the session
+	session := &transactions.CoSigningSession{
+		State:           transactions.CoSigningOpen,
+		ProposerAddress: cfg.AdminAddress,
+		PayerAddress:    cfg.AdminAddress,
+		FlowTransaction: flowTx.Encode(),
+		TransactionId:   transactionId,
+	}
+
+	if err := store.InsertCoSigningSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.InsertTransaction(&transactions.Transaction{
+		TransactionId:   transactionId,
+		TransactionType: transactions.General,
+		ProposerAddress: cfg.AdminAddress,
+		FlowTransaction: flowTx.Encode(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := svc.AddCoSigningSignature(ctx, session.ID.String(), transactions.TransactionSignatureJSON{
+		Address:   cfg.AdminAddress,
+		Signature: hex.EncodeToString(make([]byte, 64)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.CoSigningSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.State != transactions.CoSigningSubmitted || stored.JobId == nil || stored.TransactionId != transactionId {
+		t.Fatalf("expected session to be %s with a job for %s, got %s, %v, %s", transactions.CoSigningSubmitted, transactionId, stored.State, stored.JobId, stored.TransactionId)
+	}
+}
+
+func Test_TransactionCoSigningSessionPayer(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	wp := jobs.NewWorkerPool(test.GetJobStore(t, cfg, db), 10, 1)
+	// Rejected requests never reach the key manager or the chain
+	svc := transactions.NewService(cfg, transactions.NewGormStore(db), nil, nil, wp)
+
+	_, err := svc.CreateCoSigningSession(context.Background(), transactions.CoSigningSessionJSONRequest{
+		Code:     "transaction() { prepare(a: AuthAccount){} execute {}}",
+		Proposer: "0x01cf0e2f2f715450",
+		Payer:    "0x179b6b1cb6755e31",
+	})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error, got %#v", err)
+	}
+}
+
+func Test_TransactionCodeAllowlist(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.EnableCodeAllowlist = true
+
+	db := test.GetDatabase(t, cfg)
+	wp := jobs.NewWorkerPool(test.GetJobStore(t, cfg, db), 10, 1)
+	// Rejected requests never reach the key manager or the chain
+	svc := transactions.NewService(cfg, transactions.NewGormStore(db), nil, nil, wp)
+
+	code := "transaction { prepare(signer: AuthAccount) {} }"
+	codeHash := transactions.CodeHash([]byte(code))
+
+	expectForbidden := func(err error) {
+		t.Helper()
+		reqErr, ok := err.(*errors.RequestError)
+		if !ok || reqErr.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected a %d request error, got %#v", http.StatusForbidden, err)
+		}
+	}
+
+	ctx := transactions.ContextWithAPIKey(context.Background(), "client-key")
+
+	_, err := svc.Sign(ctx, cfg.AdminAddress, nil, code, nil)
+	expectForbidden(err)
+
+	_, _, err = svc.Create(ctx, true, cfg.AdminAddress, nil, code, nil, transactions.General)
+	expectForbidden(err)
+
+	// Entries limited to another API key or account do not allow the code
+	if _, err := svc.AddAllowedCode(transactions.AllowedCodeJSONRequest{Code: code, APIKey: "other-key"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AddAllowedCode(transactions.AllowedCodeJSONRequest{CodeHash: "0x" + codeHash, AccountAddress: "0x01cf0e2f2f715450"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Sign(ctx, cfg.AdminAddress, nil, code, nil)
+	expectForbidden(err)
+
+	entries, err := svc.AllowedCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].CodeHash != codeHash || entries[1].CodeHash != codeHash {
+		t.Fatalf("expected 2 entries for %s, got %+v", codeHash, entries)
+	}
+
+	if entries[0].APIKeyHash == "" || entries[0].APIKeyHash == "other-key" {
+		t.Fatalf("expected the API key to be stored hashed, got %q", entries[0].APIKeyHash)
+	}
+
+	audit, err := svc.CodeAuditLog(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(audit) != 3 {
+		t.Fatalf("expected 3 audit entries, got %d", len(audit))
+	}
+
+	latest := audit[0]
+	if latest.Operation != transactions.CodePolicySign || latest.CodeHash != codeHash || latest.ProposerAddress != cfg.AdminAddress || latest.APIKeyHash == "" {
+		t.Fatalf("unexpected audit entry: %+v", latest)
+	}
+
+	if latest.Reason == audit[2].Reason {
+		t.Fatalf("expected the reason to tell whether the code is in the allowlist, got %q", latest.Reason)
+	}
+
+	if err := svc.RemoveAllowedCode(strconv.FormatUint(entries[0].ID, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.RemoveAllowedCode(strconv.FormatUint(entries[0].ID, 10)); err == nil {
+		t.Fatal("expected an error when removing a removed entry")
+	}
+
+	if _, err := svc.AddAllowedCode(transactions.AllowedCodeJSONRequest{CodeHash: "abc"}); err == nil {
+		t.Fatal("expected an error for an invalid hash")
+	}
+
+	// The remaining entry limited to an account does not allow the code to
+	// be authorized by other accounts
+	_, err = svc.Sign(context.Background(), "0x01cf0e2f2f715450", []string{"0x01cf0e2f2f715450", cfg.AdminAddress}, code, nil)
+	expectForbidden(err)
+}
+
+func Test_TransactionGasLimit(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	cfg.TransactionGasLimit = 1000
+	cfg.TransactionTypeGasLimits = []string{"General:500"}
+	cfg.MaxTransactionGasLimit = 2000
+	app := test.GetServices(t, cfg)
+	txSvc := app.GetTransactions()
+
+	// Pause, so the transactions won't get sent
+	if err := app.GetSystem().Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	code := "transaction() { prepare(signer: AuthAccount){} execute {}}"
+
+	for _, tc := range []struct {
+		opts     []transactions.TransactionOption
+		expected uint64
+	}{
+		{nil, 500},
+		{[]transactions.TransactionOption{transactions.WithGasLimit(1500)}, 1500},
+	} {
+		_, tx, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, code, nil, transactions.General, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tx.GasLimit != tc.expected || flowTx.GasLimit != tc.expected {
+			t.Fatalf("expected gas limit %d, got %d stored and %d in the transaction", tc.expected, tx.GasLimit, flowTx.GasLimit)
+		}
+
+		stored, err := txSvc.Details(ctx, tx.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.GasLimit != tc.expected {
+			t.Fatalf("expected stored gas limit %d, got %d", tc.expected, stored.GasLimit)
+		}
+	}
+
+	_, _, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, code, nil, transactions.General, transactions.WithGasLimit(2001))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a %d request error, got %#v", http.StatusBadRequest, err)
+	}
+
+	signed, err := txSvc.Sign(ctx, cfg.AdminAddress, nil, code, nil, transactions.WithGasLimit(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signed.GasLimit != 100 {
+		t.Fatalf("expected gas limit 100, got %d", signed.GasLimit)
+	}
 }
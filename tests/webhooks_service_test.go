@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
+)
+
+func Test_WebhookSubscriptionDelivery(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 10, jobs.WithJobStatusWebhook("", time.Second))
+	svc := jobs.NewWebhookService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	var mu sync.Mutex
+	var secret string
+	received := 0
+	valid := 0
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(jobs.WebhookTimestampHeader), 10, 64)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		received++
+		if r.Header.Get(jobs.WebhookSignatureHeader) == jobs.SignWebhookPayload(secret, timestamp, body) {
+			valid++
+		}
+	}))
+	defer svr.Close()
+
+	sub, err := svc.Create(jobs.WebhookSubscriptionJSONRequest{
+		URL:      svr.URL,
+		JobTypes: []string{"job"},
+		States:   []jobs.State{jobs.Complete},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	secret = sub.Secret
+	mu.Unlock()
+
+	if secret == "" {
+		t.Fatal("expected a secret to be generated")
+	}
+
+	// Should not match the type filter
+	if _, err := svc.Create(jobs.WebhookSubscriptionJSONRequest{
+		URL:      svr.URL,
+		JobTypes: []string{"other"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Create(jobs.WebhookSubscriptionJSONRequest{
+		URL:    svr.URL,
+		States: []jobs.State{jobs.Accepted},
+	}); err == nil {
+		t.Fatal("expected an error for a non-terminal state filter")
+	}
+
+	wp.RegisterExecutor("job", func(ctx context.Context, j *jobs.Job) error {
+		j.ShouldSendNotification = true
+		return nil
+	})
+	wp.Start()
+
+	j, err := wp.CreateJob("job", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForDeliveries := func(count int) []jobs.WebhookDelivery {
+		for i := 0; i < 200; i++ {
+			deliveries, err := svc.Deliveries(jobs.DeliveryFilter{SubscriptionID: &sub.ID}, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) >= count {
+				return deliveries
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected %d deliveries", count)
+		return nil
+	}
+
+	deliveries := waitForDeliveries(1)
+
+	if !deliveries[0].Success || deliveries[0].StatusCode != http.StatusOK {
+		t.Fatalf("expected a successful delivery, got %+v", deliveries[0])
+	}
+
+	if deliveries[0].ParentJobID != j.ID {
+		t.Fatalf("expected delivery of job %s, got %s", j.ID, deliveries[0].ParentJobID)
+	}
+
+	if _, err := svc.Redeliver(strconv.FormatUint(deliveries[0].ID, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForDeliveries(2)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if received != 2 {
+		t.Fatalf("expected 2 webhook requests, got %d", received)
+	}
+
+	if valid != received {
+		t.Fatalf("expected all webhook requests to have a valid signature, got %d/%d", valid, received)
+	}
+}
@@ -3,10 +3,12 @@ package tests
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/tests/test"
 	"github.com/google/uuid"
@@ -15,7 +17,7 @@ import (
 func Test_WorkerPoolExecutesJobWithSuccess(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 	wp := jobs.NewWorkerPool(jobStore, 10, 10)
 
 	t.Cleanup(func() {
@@ -68,7 +70,7 @@ func Test_WorkerPoolExecutesJobWithSuccess(t *testing.T) {
 func Test_WorkerPoolExecutesJobWithError(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 	wp := jobs.NewWorkerPool(jobStore, 10, 10)
 
 	t.Cleanup(func() {
@@ -121,7 +123,7 @@ func Test_WorkerPoolExecutesJobWithError(t *testing.T) {
 func Test_WorkerPoolExecutesJobWithPermanentError(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 	wp := jobs.NewWorkerPool(jobStore, 10, 10)
 
 	t.Cleanup(func() {
@@ -174,7 +176,7 @@ func Test_WorkerPoolExecutesJobWithPermanentError(t *testing.T) {
 func Test_WorkerPoolPicksUpInitJob(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 
 	executedWG := &sync.WaitGroup{}
 	jobType := "job"
@@ -195,7 +197,7 @@ func Test_WorkerPoolPicksUpInitJob(t *testing.T) {
 	}
 
 	// Directly insert "old" job into DB.
-	err := db.Create(j).Error
+	err := jobStore.InsertJob(j)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -234,7 +236,7 @@ func Test_WorkerPoolPicksUpInitJob(t *testing.T) {
 func Test_WorkerPoolPicksUpErroredJob(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 
 	executedWG := &sync.WaitGroup{}
 	jobType := "job"
@@ -255,7 +257,7 @@ func Test_WorkerPoolPicksUpErroredJob(t *testing.T) {
 	}
 
 	// Directly insert "old" job into DB.
-	err := db.Create(j).Error
+	err := jobStore.InsertJob(j)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -305,7 +307,7 @@ func Test_WorkerPoolDoesntPickupFailedJob(t *testing.T) {
 	// does not happen, even over longer period of time.
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 
 	jobType := "job"
 	jobFunc := func(ctx context.Context, j *jobs.Job) error {
@@ -325,7 +327,7 @@ func Test_WorkerPoolDoesntPickupFailedJob(t *testing.T) {
 	}
 
 	// Directly insert "old" job into DB.
-	err := db.Create(j).Error
+	err := jobStore.InsertJob(j)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -364,7 +366,7 @@ func Test_WorkerPoolDoesntPickupFailedJob(t *testing.T) {
 func Test_ExceedingWorkerpoolCapacity(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	db := test.GetDatabase(t, cfg)
-	jobStore := jobs.NewGormStore(db)
+	jobStore := test.GetJobStore(t, cfg, db)
 	wp := jobs.NewWorkerPool(
 		jobStore, 2, 1,
 		jobs.WithDbJobPollInterval(time.Minute), // Poll every minute, basically pausing
@@ -411,3 +413,622 @@ func Test_ExceedingWorkerpoolCapacity(t *testing.T) {
 		t.Errorf("expected job.State = %q, got %q", jobs.NoAvailableWorkers, j.State)
 	}
 }
+
+func Test_WorkerPoolCancelJob(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	jobType := "job"
+	jobFunc := func(ctx context.Context, j *jobs.Job) error {
+		t.Fatal("cancelled job executed")
+		return nil
+	}
+
+	t0 := time.Now()
+	j := &jobs.Job{
+		ID:            uuid.New(),
+		State:         jobs.Error,
+		Type:          jobType,
+		TransactionID: "0xf00d",
+		ExecCount:     2,
+		CreatedAt:     t0.Add(-10 * time.Minute),
+		UpdatedAt:     t0.Add(-10 * time.Minute),
+	}
+
+	// Directly insert "old" job into DB.
+	err := jobStore.InsertJob(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 10)
+	wp.RegisterExecutor(jobType, jobFunc)
+
+	cancelled, err := wp.Cancel(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cancelled.State != jobs.Cancelled {
+		t.Fatalf("expected job.State = %q, got %q", jobs.Cancelled, cancelled.State)
+	}
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	// Gotta give DB job poller a bit of time to catch up.
+	time.Sleep(100 * time.Millisecond)
+
+	job, err := jobStore.Job(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.State != jobs.Cancelled {
+		t.Fatalf("expected job.State = %q, got %q", jobs.Cancelled, job.State)
+	}
+
+	if _, err := wp.Cancel(j.ID); !errors.Is(err, jobs.ErrJobNotCancellable) {
+		t.Fatalf("expected error %q, got %v", jobs.ErrJobNotCancellable, err)
+	}
+}
+
+func Test_WorkerPoolRetryFailedJob(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	executedWG := &sync.WaitGroup{}
+	jobType := "job"
+	jobFunc := func(ctx context.Context, j *jobs.Job) error {
+		defer executedWG.Done()
+		return nil
+	}
+
+	t0 := time.Now()
+	j := &jobs.Job{
+		ID:            uuid.New(),
+		State:         jobs.Failed,
+		Type:          jobType,
+		TransactionID: "0xf00d",
+		Error:         "test error",
+		Errors:        []string{"test error"},
+		ExecCount:     11,
+		CreatedAt:     t0.Add(-10 * time.Minute),
+		UpdatedAt:     t0.Add(-10 * time.Minute),
+	}
+
+	// Directly insert "old" job into DB.
+	err := jobStore.InsertJob(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 10, jobs.WithDbJobPollInterval(time.Minute))
+	wp.RegisterExecutor(jobType, jobFunc)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	executedWG.Add(1)
+	if _, err := wp.Retry(j.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	executedWG.Wait()
+
+	var job jobs.Job
+	for {
+		job, err = jobStore.Job(j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Complete {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		break
+	}
+
+	if job.ExecCount != 1 {
+		t.Fatalf("expected job.ExecCount = %d, got %d", 1, job.ExecCount)
+	}
+
+	if len(job.Errors) != 1 || job.Errors[0] != "test error" {
+		t.Fatalf("expected job.Errors to be kept, got %v", job.Errors)
+	}
+
+	if _, err := wp.Retry(j.ID); !errors.Is(err, jobs.ErrJobNotRetryable) {
+		t.Fatalf("expected error %q, got %v", jobs.ErrJobNotRetryable, err)
+	}
+}
+
+func Test_WorkerPoolRetryFailedJobsWithFilter(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	t0 := time.Now()
+	insertFailed := func(jobType, errorMessage string) *jobs.Job {
+		j := &jobs.Job{
+			ID:        uuid.New(),
+			State:     jobs.Failed,
+			Type:      jobType,
+			Error:     errorMessage,
+			Errors:    []string{errorMessage},
+			ExecCount: 11,
+			CreatedAt: t0.Add(-10 * time.Minute),
+			UpdatedAt: t0.Add(-10 * time.Minute),
+		}
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	match := insertFailed("job", "insufficient balance")
+	insertFailed("job", "some other error")
+	insertFailed("other", "insufficient balance")
+
+	// Poll every minute, basically pausing; workers are not started
+	wp := jobs.NewWorkerPool(jobStore, 10, 10, jobs.WithDbJobPollInterval(time.Minute))
+
+	after := t0.Add(-time.Hour)
+	retried, err := wp.RetryFailed(jobs.RetryFilter{
+		Type:          "job",
+		ErrorContains: "balance",
+		FailedAfter:   &after,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(retried) != 1 || retried[0].ID != match.ID {
+		t.Fatalf("expected only job %s to be retried, got %d jobs", match.ID, len(retried))
+	}
+
+	if retried[0].State != jobs.Init || retried[0].ExecCount != 0 {
+		t.Fatalf("expected retried job to be reset, got state %q and exec count %d", retried[0].State, retried[0].ExecCount)
+	}
+}
+
+func Test_SchedulableJobsRespectNextRunAt(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	t0 := time.Now()
+	insertErrored := func(nextRunAt time.Time) *jobs.Job {
+		j := &jobs.Job{
+			ID:        uuid.New(),
+			State:     jobs.Error,
+			Type:      "job",
+			ExecCount: 2,
+			NextRunAt: nextRunAt,
+			CreatedAt: t0.Add(-10 * time.Minute),
+			UpdatedAt: t0.Add(-10 * time.Minute),
+		}
+		if err := jobStore.InsertJob(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	due := insertErrored(t0.Add(-time.Second))
+	insertErrored(t0.Add(time.Hour))
+
+	jj, err := jobStore.SchedulableJobs(time.Minute, datastore.ParseListOptions(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jj) != 1 || jj[0].ID != due.ID {
+		t.Fatalf("expected only job %s to be schedulable, got %d jobs", due.ID, len(jj))
+	}
+}
+
+func Test_WorkerPoolJobTypeConcurrencyLimit(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(
+		jobStore, 10, 4,
+		jobs.WithDbJobPollInterval(time.Minute),
+		jobs.WithJobTypeConcurrencyLimits([]string{"limited:1"}),
+		jobs.WithJobTypePriorities([]string{"limited:1"}),
+	)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	release := make(chan struct{})
+	executedWG := &sync.WaitGroup{}
+
+	wp.RegisterExecutor("limited", func(ctx context.Context, j *jobs.Job) error {
+		defer executedWG.Done()
+
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return nil
+	})
+
+	wp.Start()
+
+	var scheduled []*jobs.Job
+	for i := 0; i < 3; i++ {
+		j, err := wp.CreateJob("limited", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		scheduled = append(scheduled, j)
+	}
+
+	// One running, one queued, the rest deferred
+	deferred := 0
+	for _, j := range scheduled {
+		if j.State == jobs.NoAvailableWorkers {
+			deferred++
+		}
+	}
+
+	if deferred == 0 {
+		t.Fatal("expected jobs exceeding the concurrency limit to be deferred")
+	}
+
+	executedWG.Add(len(scheduled) - deferred)
+
+	// Give the other workers a chance to pick up a queued job
+	time.Sleep(100 * time.Millisecond)
+
+	status, err := wp.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := status.JobTypes["limited"]; s.Running != 1 || s.MaxConcurrency != 1 || s.Priority != 1 {
+		t.Fatalf("unexpected job type status %+v", s)
+	}
+
+	close(release)
+	executedWG.Wait()
+
+	if maxRunning != 1 {
+		t.Fatalf("expected at most 1 concurrent execution, got %d", maxRunning)
+	}
+}
+
+func Test_WorkerPoolJobLease(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	lease := 300 * time.Millisecond
+	wp := jobs.NewWorkerPool(
+		jobStore, 10, 2,
+		jobs.WithDbJobPollInterval(time.Minute),
+		jobs.WithInstanceID("owner"),
+		jobs.WithJobLeaseDuration(lease),
+	)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	started := make(chan uuid.UUID, 2)
+	executedWG := &sync.WaitGroup{}
+
+	// Runs well past the lease duration
+	wp.RegisterExecutor("long", func(ctx context.Context, j *jobs.Job) error {
+		defer executedWG.Done()
+		started <- j.ID
+		select {
+		case <-time.After(3 * lease):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	wp.Start()
+
+	waitForState := func(id uuid.UUID, state jobs.State) jobs.Job {
+		for i := 0; i < 100; i++ {
+			job, err := jobStore.Job(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State == state {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected job to reach state %q", state)
+		return jobs.Job{}
+	}
+
+	t.Run("lease is renewed while executing", func(t *testing.T) {
+		executedWG.Add(1)
+		j, err := wp.CreateJob("long", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		<-started
+		time.Sleep(2 * lease)
+
+		job, err := jobStore.Job(j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.LeaseOwner != "owner" {
+			t.Fatalf("expected lease owner %q, got %q", "owner", job.LeaseOwner)
+		}
+
+		if err := jobStore.AcceptJob(&job, "other", lease); err == nil {
+			t.Fatal("expected job with a valid lease not to be acceptable")
+		}
+
+		executedWG.Wait()
+
+		job = waitForState(j.ID, jobs.Complete)
+		if job.LeaseOwner != "" {
+			t.Fatalf("expected lease to be released, got owner %q", job.LeaseOwner)
+		}
+	})
+
+	t.Run("execution stops when lease is lost", func(t *testing.T) {
+		executedWG.Add(1)
+		j, err := wp.CreateJob("long", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		<-started
+		t0 := time.Now()
+
+		// Simulate another instance taking over the job after an expired lease
+		job, err := jobStore.Job(j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.LeaseOwner = "other"
+		job.ExecCount = 2
+		if err := jobStore.UpdateJob(&job); err != nil {
+			t.Fatal(err)
+		}
+
+		executedWG.Wait()
+
+		if time.Since(t0) >= 3*lease {
+			t.Fatal("expected execution to be cancelled when the lease was lost")
+		}
+
+		time.Sleep(lease)
+
+		job, err = jobStore.Job(j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Accepted || job.LeaseOwner != "other" {
+			t.Fatalf("expected job to be left to the new owner, got state %q and owner %q", job.State, job.LeaseOwner)
+		}
+	})
+}
+
+func Test_WorkerPoolJobExecutionTimeout(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Minute))
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	// Blocks until its context is done, like a hanging Access API call
+	wp.RegisterExecutor("stuck", func(ctx context.Context, j *jobs.Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, jobs.WithExecutorTimeout(100*time.Millisecond))
+
+	wp.Start()
+
+	j, err := wp.CreateJob("stuck", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(j); err != nil {
+		t.Fatal(err)
+	}
+
+	var job jobs.Job
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		job, err = jobStore.Job(j.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State == jobs.Error {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected job to time out, got state %q", job.State)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.HasPrefix(job.Error, jobs.ErrJobTimeout.Error()) {
+		t.Fatalf("expected a timeout error, got %q", job.Error)
+	}
+
+	if job.NextRunAt.Before(time.Now()) {
+		t.Fatal("expected timed out job to be re-scheduled")
+	}
+
+	events, err := jobStore.Events(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := events[len(events)-1]
+	if last.FromState != jobs.Accepted || last.ToState != jobs.Error || last.Error != job.Error {
+		t.Fatalf("expected the timed out attempt in the job history, got %+v", last)
+	}
+}
+
+func Test_WorkerPoolShutdown(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := test.GetJobStore(t, cfg, db)
+
+	waitForState := func(t *testing.T, id uuid.UUID, state jobs.State) jobs.Job {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			job, err := jobStore.Job(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State == state {
+				return job
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected job to reach state %q, got %q", state, job.State)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// A pool with a single worker executing the first job and two more
+	// jobs waiting in the queue. Job types differ between subtests as the
+	// released jobs of one would be picked up by the pool of the next.
+	start := func(t *testing.T, jobType string, executor jobs.ExecutorFunc) (jobs.WorkerPool, []*jobs.Job) {
+		started := make(chan struct{}, 3)
+
+		wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Hour))
+		wp.RegisterExecutor(jobType, func(ctx context.Context, j *jobs.Job) error {
+			started <- struct{}{}
+			return executor(ctx, j)
+		})
+		wp.Start()
+
+		var jj []*jobs.Job
+		for i := 0; i < 3; i++ {
+			j, err := wp.CreateJob(jobType, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wp.Schedule(j); err != nil {
+				t.Fatal(err)
+			}
+			jj = append(jj, j)
+
+			if i == 0 {
+				<-started
+			}
+		}
+
+		return wp, jj
+	}
+
+	t.Run("running job finishes within the drain period", func(t *testing.T) {
+		release := make(chan struct{})
+		wp, jj := start(t, "finishing", func(ctx context.Context, j *jobs.Job) error {
+			<-release
+			return nil
+		})
+
+		time.AfterFunc(100*time.Millisecond, func() { close(release) })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := wp.Shutdown(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		waitForState(t, jj[0].ID, jobs.Complete)
+
+		for _, j := range jj[1:] {
+			job := waitForState(t, j.ID, jobs.NoAvailableWorkers)
+			if job.ExecCount != 0 || job.NextRunAt.After(time.Now()) {
+				t.Fatalf("expected queued job to be released for immediate re-scheduling, got %+v", job)
+			}
+		}
+	})
+
+	t.Run("running job is released after the drain period", func(t *testing.T) {
+		wp, jj := start(t, "interrupted", func(ctx context.Context, j *jobs.Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		if err := wp.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected drain period to be exceeded, got %v", err)
+		}
+
+		job := waitForState(t, jj[0].ID, jobs.NoAvailableWorkers)
+		if job.NextRunAt.After(time.Now()) || len(job.Errors) != 0 {
+			t.Fatalf("expected interrupted job to be released for immediate re-scheduling, got %+v", job)
+		}
+
+		events, err := jobStore.Events(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last := events[len(events)-1]
+		if last.FromState != jobs.Accepted || last.Error != jobs.ErrJobInterrupted.Error() {
+			t.Fatalf("expected the interrupted attempt in the job history, got %+v", last)
+		}
+	})
+
+	t.Run("stopping after shutdown", func(t *testing.T) {
+		wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Hour))
+		wp.Start()
+
+		if err := wp.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Stop(true)
+	})
+}
@@ -70,7 +70,7 @@ func NewService(
 	}
 
 	// Register asynchronous job executor.
-	wp.RegisterExecutor(WithdrawalCreateJobType, svc.executeCreateWithdrawalJob)
+	wp.RegisterExecutor(WithdrawalCreateJobType, svc.executeCreateWithdrawalJob, jobs.WithExecutorTimeout(transactions.TransactionJobTimeout), jobs.WithStatusNotifications())
 
 	return svc
 }
@@ -102,7 +102,7 @@ func (s *ServiceImpl) Setup(ctx context.Context, sync bool, tokenName, address s
 		txType = transactions.NftSetup
 	}
 
-	job, tx, err := s.transactions.Create(ctx, sync, address, token.Setup, nil, txType)
+	job, tx, err := s.transactions.Create(ctx, sync, address, nil, token.Setup, nil, txType)
 
 	if err == nil || strings.Contains(err.Error(), "vault exists") {
 		// Handle adding token to account in database
@@ -197,7 +197,7 @@ func (s *ServiceImpl) CreateWithdrawal(ctx context.Context, sync bool, sender st
 			return nil, nil, err
 		}
 
-		job, err := s.wp.CreateJob(WithdrawalCreateJobType, "", jobs.WithAttributes(attrBytes))
+		job, err := s.wp.CreateJob(WithdrawalCreateJobType, "", jobs.WithAttributes(attrBytes), jobs.WithContextCallbackURL(ctx))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -451,7 +451,7 @@ func (s *ServiceImpl) createWithdrawal(ctx context.Context, sender string, reque
 	}
 
 	// Create the transaction, must be sync here
-	_, transaction, err := s.transactions.Create(ctx, true, sender, token.Transfer, arguments, txType)
+	_, transaction, err := s.transactions.Create(ctx, true, sender, nil, token.Transfer, arguments, txType)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +85,8 @@ func (s *GormStore) TokenWithdrawal(address, transactionId string, token *templa
 		Joins("left join transactions on token_transfers.transaction_id = transactions.transaction_id").
 		Where("token_transfers.sender_address = ?", address).
 		Where("transactions.transaction_type = ?", txType).
-		Where("transactions.transaction_id = ?", transactionId).
+		// The transaction may have been rebuilt with a new id
+		Where("transactions.transaction_id = ? OR transactions.original_transaction_id = ?", transactionId, transactionId).
 		Where("token_transfers.token_name = ?", token.Name).
 		Order("token_transfers.created_at desc").
 		First(&t).Error
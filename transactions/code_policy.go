@@ -0,0 +1,255 @@
+package transactions
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// Operations checked against the code allowlist, recorded in audit entries.
+const (
+	CodePolicyCreate    = "create"
+	CodePolicySign      = "sign"
+	CodePolicySubmit    = "submit"
+	CodePolicyCoSigning = "cosigning"
+)
+
+// AllowedCode is an entry of the code allowlist. When the allowlist is
+// enabled, raw transactions are only accepted if the hash of their code
+// matches an entry. An entry can be limited to an account, which must then be
+// the only account signing the transaction besides the payer, and to the API
+// key of the request.
+type AllowedCode struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	CodeHash       string    `json:"codeHash" gorm:"index;not null"`                  // Hex encoded SHA3-256 hash of the code
+	Description    string    `json:"description"`                                     // E.g. the name and audit of the code
+	AccountAddress string    `json:"accountAddress,omitempty"`                        // Any proposer and authorizers if empty
+	APIKeyHash     string    `json:"apiKeyHash,omitempty" gorm:"column:api_key_hash"` // Any API key if empty
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func (AllowedCode) TableName() string {
+	return "code_allowlist"
+}
+
+// CodeAuditEntry records a request rejected by the code allowlist.
+type CodeAuditEntry struct {
+	ID              uint64    `json:"id" gorm:"primaryKey"`
+	Operation       string    `json:"operation"`
+	CodeHash        string    `json:"codeHash" gorm:"index"`
+	ProposerAddress string    `json:"proposerAddress"`
+	APIKeyHash      string    `json:"apiKeyHash,omitempty" gorm:"column:api_key_hash"`
+	Reason          string    `json:"reason"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"index"`
+}
+
+func (CodeAuditEntry) TableName() string {
+	return "code_audit_log"
+}
+
+// Allowed code JSON HTTP request
+type AllowedCodeJSONRequest struct {
+	Code           string `json:"code"`     // Either the code
+	CodeHash       string `json:"codeHash"` // or its hash
+	Description    string `json:"description"`
+	AccountAddress string `json:"accountAddress"` // Optional
+	APIKey         string `json:"apiKey"`         // Optional, only its hash is stored
+}
+
+type apiKeyContextKey struct{}
+type codePolicyExemptContextKey struct{}
+
+// ContextWithAPIKey returns a copy of ctx carrying the API key of a request.
+func ContextWithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, apiKey)
+}
+
+// APIKeyFromContext returns the API key carried by ctx, if any.
+func APIKeyFromContext(ctx context.Context) string {
+	k, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return k
+}
+
+// WithoutCodePolicy returns a copy of ctx for sending code which the wallet
+// itself provides, such as adding account keys. The code allowlist is not
+// applied to it.
+func WithoutCodePolicy(ctx context.Context) context.Context {
+	return context.WithValue(ctx, codePolicyExemptContextKey{}, true)
+}
+
+func codePolicyExempt(ctx context.Context) bool {
+	exempt, _ := ctx.Value(codePolicyExemptContextKey{}).(bool)
+	return exempt
+}
+
+// CodeHash returns the hex encoded SHA3-256 hash of code, which identifies
+// the code in the allowlist.
+func CodeHash(code []byte) string {
+	return hex.EncodeToString(crypto.NewSHA3_256().ComputeHash(code))
+}
+
+// apiKeyHash hashes an API key like code, API keys are not stored as is.
+func apiKeyHash(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	return CodeHash([]byte(apiKey))
+}
+
+// allows tells whether the entry allows code signed by the accounts in
+// signerAddresses, i.e. the proposer and the authorizers, with the API key
+// hashed as keyHash.
+func (a AllowedCode) allows(signerAddresses []string, keyHash string) bool {
+	if a.AccountAddress != "" {
+		for _, address := range signerAddresses {
+			if address != a.AccountAddress {
+				return false
+			}
+		}
+	}
+	if a.APIKeyHash != "" && a.APIKeyHash != keyHash {
+		return false
+	}
+	return true
+}
+
+// checkCodePolicy checks code proposed by proposerAddress and authorized by
+// the accounts in authorizerAddresses against the code allowlist, if enabled.
+// Rejected requests are recorded in the audit log.
+func (s *ServiceImpl) checkCodePolicy(ctx context.Context, operation, proposerAddress string, authorizerAddresses []string, code []byte) error {
+	if !s.cfg.EnableCodeAllowlist || codePolicyExempt(ctx) {
+		return nil
+	}
+
+	proposerAddress, err := flow_helpers.ValidateAddress(proposerAddress, s.cfg.ChainID)
+	if err != nil {
+		return err
+	}
+
+	signerAddresses := []string{proposerAddress}
+	for _, address := range authorizerAddresses {
+		address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
+		if err != nil {
+			return err
+		}
+		signerAddresses = append(signerAddresses, address)
+	}
+
+	codeHash := CodeHash(code)
+	keyHash := apiKeyHash(APIKeyFromContext(ctx))
+
+	entries, err := s.store.AllowedCodeByHash(codeHash)
+	if err != nil {
+		return fmt.Errorf("error while getting code allowlist: %w", err)
+	}
+
+	for _, e := range entries {
+		if e.allows(signerAddresses, keyHash) {
+			return nil
+		}
+	}
+
+	reason := "code not in allowlist"
+	if len(entries) > 0 {
+		reason = "code not allowed for signing accounts or API key"
+	}
+
+	audit := &CodeAuditEntry{
+		Operation:       operation,
+		CodeHash:        codeHash,
+		ProposerAddress: proposerAddress,
+		APIKeyHash:      keyHash,
+		Reason:          reason,
+	}
+
+	if err := s.store.InsertCodeAuditEntry(audit); err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "codeHash": codeHash}).
+			Warn("Error while recording rejected code")
+	}
+
+	return &errors.RequestError{
+		StatusCode: http.StatusForbidden,
+		Err:        fmt.Errorf("%s: %s", reason, codeHash),
+	}
+}
+
+// AllowedCode returns the entries of the code allowlist.
+func (s *ServiceImpl) AllowedCode() ([]AllowedCode, error) {
+	return s.store.AllowedCode()
+}
+
+// AddAllowedCode adds an entry to the code allowlist.
+func (s *ServiceImpl) AddAllowedCode(req AllowedCodeJSONRequest) (*AllowedCode, error) {
+	badRequest := func(format string, a ...interface{}) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(format, a...),
+		}
+	}
+
+	codeHash := strings.ToLower(strings.TrimPrefix(req.CodeHash, "0x"))
+
+	switch {
+	case req.Code != "" && codeHash != "":
+		return nil, badRequest(`give either "code" or "codeHash"`)
+	case req.Code != "":
+		codeHash = CodeHash([]byte(req.Code))
+	case codeHash == "":
+		return nil, badRequest(`"code" or "codeHash" required`)
+	}
+
+	if b, err := hex.DecodeString(codeHash); err != nil || len(b) != crypto.NewSHA3_256().Size() {
+		return nil, badRequest(`not a valid SHA3-256 hash: "%s"`, req.CodeHash)
+	}
+
+	entry := &AllowedCode{
+		CodeHash:    codeHash,
+		Description: req.Description,
+		APIKeyHash:  apiKeyHash(req.APIKey),
+	}
+
+	if req.AccountAddress != "" {
+		address, err := flow_helpers.ValidateAddress(req.AccountAddress, s.cfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		entry.AccountAddress = address
+	}
+
+	if err := s.store.InsertAllowedCode(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// RemoveAllowedCode removes an entry from the code allowlist.
+func (s *ServiceImpl) RemoveAllowedCode(id string) error {
+	entryId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid id"),
+		}
+	}
+
+	return s.store.DeleteAllowedCode(entryId)
+}
+
+// CodeAuditLog returns requests rejected by the code allowlist, latest
+// first.
+func (s *ServiceImpl) CodeAuditLog(limit, offset int) ([]CodeAuditEntry, error) {
+	o := datastore.ParseListOptions(limit, offset)
+	return s.store.CodeAuditEntries(o)
+}
@@ -0,0 +1,62 @@
+package transactions
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCodeHash(t *testing.T) {
+	// SHA3-256 of the empty string
+	expected := "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"
+
+	if h := CodeHash(nil); h != expected {
+		t.Fatalf("expected %s, got %s", expected, h)
+	}
+
+	if CodeHash([]byte("transaction {}")) == CodeHash([]byte("transaction {} ")) {
+		t.Fatal("expected different code to have different hashes")
+	}
+}
+
+func TestAllowedCodeAllows(t *testing.T) {
+	keyHash := apiKeyHash("key")
+
+	cases := []struct {
+		name    string
+		entry   AllowedCode
+		signers []string
+		keyHash string
+		allowed bool
+	}{
+		{"unscoped", AllowedCode{}, []string{"0x01"}, "", true},
+		{"account", AllowedCode{AccountAddress: "0x01"}, []string{"0x01"}, "", true},
+		{"other account", AllowedCode{AccountAddress: "0x01"}, []string{"0x02"}, "", false},
+		{"account authorizing", AllowedCode{AccountAddress: "0x01"}, []string{"0x01", "0x01"}, "", true},
+		{"other account authorizing", AllowedCode{AccountAddress: "0x01"}, []string{"0x01", "0x02"}, "", false},
+		{"unscoped with other authorizers", AllowedCode{}, []string{"0x01", "0x02"}, "", true},
+		{"API key", AllowedCode{APIKeyHash: keyHash}, []string{"0x01"}, keyHash, true},
+		{"other API key", AllowedCode{APIKeyHash: keyHash}, []string{"0x01"}, apiKeyHash("other"), false},
+		{"no API key", AllowedCode{APIKeyHash: keyHash}, []string{"0x01"}, "", false},
+		{"account and API key", AllowedCode{AccountAddress: "0x01", APIKeyHash: keyHash}, []string{"0x02"}, keyHash, false},
+	}
+
+	for _, c := range cases {
+		if allowed := c.entry.allows(c.signers, c.keyHash); allowed != c.allowed {
+			t.Errorf("%s: expected %t, got %t", c.name, c.allowed, allowed)
+		}
+	}
+}
+
+func TestCodePolicyContext(t *testing.T) {
+	ctx := context.Background()
+
+	if APIKeyFromContext(ctx) != "" || codePolicyExempt(ctx) {
+		t.Fatal("expected no API key and no exemption by default")
+	}
+
+	ctx = WithoutCodePolicy(ContextWithAPIKey(ctx, "key"))
+
+	if APIKeyFromContext(ctx) != "key" || !codePolicyExempt(ctx) {
+		t.Fatal("expected the API key and the exemption from the context")
+	}
+}
@@ -0,0 +1,665 @@
+package transactions
+
+import (
+	"context"
+	"encoding/hex"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/flow-hydraulics/flow-wallet-api/flow_helpers"
+	"github.com/flow-hydraulics/flow-wallet-api/jobs"
+	"github.com/flow-hydraulics/flow-wallet-api/keys"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"gorm.io/gorm"
+)
+
+// How many times adding a signature is attempted when the co-signing session
+// is concurrently updated by another request.
+const maxCoSigningUpdateAttempts = 3
+
+// ErrCoSigningSessionChanged is returned when a co-signing session has been
+// updated since it was read, e.g. by another signer.
+var ErrCoSigningSessionChanged = goerrors.New("co-signing session changed")
+
+// errCoSigningSessionExpired is returned when signing a co-signing session
+// whose transaction can no longer be included.
+var errCoSigningSessionExpired = &errors.RequestError{
+	StatusCode: http.StatusGone,
+	Err:        fmt.Errorf("co-signing session expired"),
+}
+
+// CoSigningState is the state of a co-signing session.
+type CoSigningState string
+
+const (
+	CoSigningOpen      CoSigningState = "open"      // Collecting payload signatures
+	CoSigningSubmitted CoSigningState = "submitted" // Signed by every required signer and submitted
+	CoSigningExpired   CoSigningState = "expired"   // Reference block expired before every required signer had signed
+)
+
+// CoSigningSession collects the payload signatures of a transaction from
+// accounts whose keys are not held by the wallet. Once every external signer
+// has signed, the payer signs the envelope and the transaction is submitted.
+type CoSigningSession struct {
+	ID              uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	State           CoSigningState `gorm:"column:state;default:open;index"`
+	ProposerAddress string         `gorm:"column:proposer_address;index"`
+	PayerAddress    string         `gorm:"column:payer_address"`
+	PendingSigners  pq.StringArray `gorm:"column:pending_signers;type:text[]"` // External accounts which have not signed the payload yet
+	FlowTransaction []byte         `gorm:"column:flow_transaction;type:bytes"`
+	TransactionId   string         `gorm:"column:transaction_id;index"` // Set once submitted
+	JobId           *uuid.UUID     `gorm:"column:job_id;type:uuid"`
+	ExpiresAtHeight uint64         `gorm:"column:expires_at_height"` // Sealed block height at which the reference block of the transaction expires
+	Version         int            `gorm:"column:version;default:0"` // Identifies the state of the session, see Store.UpdateCoSigningSession
+	CreatedAt       time.Time      `gorm:"column:created_at"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (CoSigningSession) TableName() string {
+	return "cosigning_sessions"
+}
+
+func (s *CoSigningSession) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// Co-signing session JSON HTTP request
+type CoSigningSessionJSONRequest struct {
+	Code             string     `json:"code"`
+	Arguments        []Argument `json:"arguments"`
+	Proposer         string     `json:"proposer"`
+	ProposalKeyIndex int        `json:"proposalKeyIndex"` // Used if the proposer is not an account of this wallet
+	Payer            string     `json:"payer"`            // The admin account (default) or the proposer
+	Authorizers      []string   `json:"authorizers"`      // Defaults to the proposer
+	GasLimit         uint64     `json:"gasLimit"`         // Defaults to the gas limit configured for raw transactions
+}
+
+// Co-signing session JSON HTTP response
+type CoSigningSessionJSONResponse struct {
+	ID              uuid.UUID                     `json:"id"`
+	State           CoSigningState                `json:"state"`
+	Proposer        string                        `json:"proposer"`
+	Payer           string                        `json:"payer"`
+	PendingSigners  []string                      `json:"pendingSigners"`
+	Transaction     SignedTransactionJSONResponse `json:"transaction"`
+	RLP             string                        `json:"rlp"`
+	TransactionId   string                        `json:"transactionId,omitempty"`
+	JobId           *uuid.UUID                    `json:"jobId,omitempty"`
+	ExpiresAtHeight uint64                        `json:"expiresAtHeight"`
+	CreatedAt       time.Time                     `json:"createdAt"`
+	UpdatedAt       time.Time                     `json:"updatedAt"`
+}
+
+func (s CoSigningSession) ToJSONResponse() (CoSigningSessionJSONResponse, error) {
+	flowTx, err := flow.DecodeTransaction(s.FlowTransaction)
+	if err != nil {
+		return CoSigningSessionJSONResponse{}, err
+	}
+
+	signed := SignedTransaction{Transaction: *flowTx}
+	tx, err := signed.ToJSONResponse()
+	if err != nil {
+		return CoSigningSessionJSONResponse{}, err
+	}
+
+	return CoSigningSessionJSONResponse{
+		ID:              s.ID,
+		State:           s.State,
+		Proposer:        s.ProposerAddress,
+		Payer:           s.PayerAddress,
+		PendingSigners:  append([]string{}, s.PendingSigners...),
+		Transaction:     tx,
+		RLP:             hex.EncodeToString(s.FlowTransaction),
+		TransactionId:   s.TransactionId,
+		JobId:           s.JobId,
+		ExpiresAtHeight: s.ExpiresAtHeight,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+	}, nil
+}
+
+// CreateCoSigningSession builds an unsigned transaction with a fixed
+// proposer, payer and authorizers and starts collecting its signatures.
+// Accounts of this wallet sign the payload right away, other accounts are
+// left pending until they post their signatures with AddCoSigningSignature.
+// The transaction is submitted as soon as no signers are pending. The payer
+// is either the admin account or the proposer.
+func (s *ServiceImpl) CreateCoSigningSession(ctx context.Context, req CoSigningSessionJSONRequest) (*CoSigningSession, error) {
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("empty code"),
+		}
+	}
+
+	proposerAddress, err := flow_helpers.ValidateAddress(req.Proposer, s.cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	payerAddress := s.cfg.AdminAddress
+	if req.Payer != "" {
+		payerAddress, err = flow_helpers.ValidateAddress(req.Payer, s.cfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Any other account would pay for a transaction it does not propose
+		if payerAddress != s.cfg.AdminAddress && payerAddress != proposerAddress {
+			return nil, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf(`payer must be the admin account or the proposer: "%s"`, payerAddress),
+			}
+		}
+	}
+
+	authorizerAddresses, err := s.validateAuthorizerAddresses(req.Authorizers)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authorizerAddresses) == 0 {
+		authorizerAddresses = []string{proposerAddress}
+	}
+
+	if err := s.checkCodePolicy(ctx, CodePolicyCoSigning, proposerAddress, authorizerAddresses, []byte(req.Code)); err != nil {
+		return nil, err
+	}
+
+	gasLimit, err := s.gasLimit(General, []TransactionOption{WithGasLimit(req.GasLimit)})
+	if err != nil {
+		return nil, err
+	}
+
+	// The payer signs the envelope, which the wallet does once every
+	// other signer has signed
+	payer, err := s.walletAuthorizer(ctx, payerAddress)
+	if goerrors.Is(err, keys.ErrAccountKeyNotFound) {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(`payer is not an account of this wallet: "%s"`, payerAddress),
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while getting payer authorizer: %w", err)
+	}
+
+	latestBlock, err := s.fc.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	flowTx := flow.NewTransaction()
+	flowTx.
+		SetReferenceBlockID(latestBlock.ID).
+		SetPayer(payer.Address).
+		SetGasLimit(gasLimit).
+		SetScript([]byte(req.Code))
+
+	for _, arg := range req.Arguments {
+		cv, err := ArgAsCadence(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := flowTx.AddArgument(cv); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		signers  []keys.Authorizer // Accounts of this wallet signing the payload
+		external []string
+	)
+
+	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	switch {
+	case goerrors.Is(err, keys.ErrAccountKeyNotFound):
+		key, err := s.externalAccountKey(ctx, flow.HexToAddress(proposerAddress), req.ProposalKeyIndex)
+		if err != nil {
+			return nil, err
+		}
+		flowTx.SetProposalKey(flow.HexToAddress(proposerAddress), key.Index, key.SequenceNumber)
+		external = append(external, proposerAddress)
+	case err != nil:
+		return nil, err
+	default:
+		flowTx.SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber)
+		// Proposer signs the payload (unless proposer == payer)
+		if !proposer.Equals(payer) {
+			signers = append(signers, proposer)
+		}
+	}
+
+	for _, address := range authorizerAddresses {
+		flowAddress := flow.HexToAddress(address)
+		flowTx.AddAuthorizer(flowAddress)
+
+		// The signatures of the proposer and the payer already cover their accounts
+		if address == proposerAddress || flowAddress == payer.Address {
+			continue
+		}
+
+		authorizer, err := s.walletAuthorizer(ctx, address)
+		if goerrors.Is(err, keys.ErrAccountKeyNotFound) {
+			external = append(external, address)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while getting authorizer %s: %w", address, err)
+		}
+		signers = append(signers, authorizer)
+	}
+
+	for _, signer := range signers {
+		if err := flowTx.SignPayload(signer.Address, signer.Key.Index, signer.Signer); err != nil {
+			return nil, err
+		}
+	}
+
+	session := &CoSigningSession{
+		State:           CoSigningOpen,
+		ProposerAddress: proposerAddress,
+		PayerAddress:    payerAddress,
+		PendingSigners:  external,
+		FlowTransaction: flowTx.Encode(),
+		ExpiresAtHeight: latestBlock.Height + flow_helpers.TransactionExpiry,
+	}
+
+	if err := s.store.InsertCoSigningSession(session); err != nil {
+		return nil, fmt.Errorf("error while inserting co-signing session in db: %w", err)
+	}
+
+	if len(session.PendingSigners) == 0 {
+		if err := s.submitCoSigningSession(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// CoSigningSessionDetails returns a specific co-signing session.
+func (s *ServiceImpl) CoSigningSessionDetails(id string) (*CoSigningSession, error) {
+	sessionId, err := uuid.Parse(id)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid co-signing session id"),
+		}
+		return nil, err
+	}
+
+	session, err := s.store.CoSigningSession(sessionId)
+	if err != nil {
+		if err.Error() == "record not found" {
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("co-signing session not found"),
+			}
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// AddCoSigningSignature adds the payload signature of a pending external
+// signer to a co-signing session. The signature is verified against the
+// public key of the account on chain. A signer is no longer pending once its
+// signatures reach the full key weight, including the proposal key if it is
+// the proposer. Posting a signature to a session with no pending signers
+// retries submitting it. Once the reference block of the transaction has
+// expired the session is marked expired and 410 Gone is returned.
+func (s *ServiceImpl) AddCoSigningSignature(ctx context.Context, id string, req TransactionSignatureJSON) (*CoSigningSession, error) {
+	address, err := flow_helpers.ValidateAddress(req.Address, s.cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "0x"))
+	if err != nil || len(signature) == 0 {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid signature"),
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		session, err := s.CoSigningSessionDetails(id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.addCoSigningSignature(ctx, session, address, req.KeyIndex, signature)
+		if goerrors.Is(err, ErrCoSigningSessionChanged) {
+			if attempt < maxCoSigningUpdateAttempts {
+				continue
+			}
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        err,
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return session, nil
+	}
+}
+
+func (s *ServiceImpl) addCoSigningSignature(ctx context.Context, session *CoSigningSession, address string, keyIndex int, signature []byte) error {
+	if session.State == CoSigningExpired {
+		return errCoSigningSessionExpired
+	}
+
+	if session.State != CoSigningOpen {
+		return &errors.RequestError{
+			StatusCode: http.StatusConflict,
+			Err:        fmt.Errorf("co-signing session already submitted"),
+		}
+	}
+
+	expired, err := s.coSigningSessionExpired(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		session.State = CoSigningExpired
+		if err := s.store.UpdateCoSigningSession(session); err != nil {
+			return err
+		}
+		return errCoSigningSessionExpired
+	}
+
+	if len(session.PendingSigners) == 0 {
+		// Submitting the session failed previously
+		return s.submitCoSigningSession(ctx, session)
+	}
+
+	pending := -1
+	for i, a := range session.PendingSigners {
+		if a == address {
+			pending = i
+		}
+	}
+
+	if pending < 0 {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(`not a pending signer of the co-signing session: "%s"`, address),
+		}
+	}
+
+	flowTx, err := flow.DecodeTransaction(session.FlowTransaction)
+	if err != nil {
+		return err
+	}
+
+	flowAddress := flow.HexToAddress(address)
+
+	for _, sig := range flowTx.PayloadSignatures {
+		if sig.Address == flowAddress && sig.KeyIndex == keyIndex {
+			return &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("key %d of %s has already signed", keyIndex, address),
+			}
+		}
+	}
+
+	account, err := s.fc.GetAccount(ctx, flowAddress)
+	if err != nil {
+		return fmt.Errorf("error while getting signer account: %w", err)
+	}
+
+	key := accountKey(account, keyIndex)
+	if key == nil {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("not a valid key of %s: %d", address, keyIndex),
+		}
+	}
+
+	if err := verifyPayloadSignature(flowTx, key, signature); err != nil {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid signature: %w", err),
+		}
+	}
+
+	flowTx.AddPayloadSignature(flowAddress, keyIndex, signature)
+
+	if payloadSignedBy(flowTx, account) {
+		session.PendingSigners = append(session.PendingSigners[:pending:pending], session.PendingSigners[pending+1:]...)
+	}
+
+	session.FlowTransaction = flowTx.Encode()
+
+	if err := s.store.UpdateCoSigningSession(session); err != nil {
+		return err
+	}
+
+	if len(session.PendingSigners) == 0 {
+		return s.submitCoSigningSession(ctx, session)
+	}
+
+	return nil
+}
+
+// coSigningSessionExpired tells whether the reference block of the
+// transaction of session has expired, in which case it can no longer be
+// included.
+func (s *ServiceImpl) coSigningSessionExpired(ctx context.Context, session *CoSigningSession) (bool, error) {
+	if session.ExpiresAtHeight == 0 {
+		// Expiry unknown, leave it to the chain
+		return false, nil
+	}
+
+	latestBlock, err := s.fc.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return false, err
+	}
+
+	return latestBlock.Height >= session.ExpiresAtHeight, nil
+}
+
+// submitCoSigningSession signs the envelope of a co-signing session with the
+// payer key and submits the transaction as a job. The signed transaction is
+// stored with the session before it is submitted, so that retries submit the
+// same transaction. The session stays open, and can be retried, until the job
+// is stored with it.
+func (s *ServiceImpl) submitCoSigningSession(ctx context.Context, session *CoSigningSession) error {
+	if session.TransactionId == "" {
+		flowTx, err := flow.DecodeTransaction(session.FlowTransaction)
+		if err != nil {
+			return err
+		}
+
+		payer, err := s.walletAuthorizer(ctx, session.PayerAddress)
+		if err != nil {
+			return fmt.Errorf("error while getting payer authorizer: %w", err)
+		}
+
+		if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
+			return err
+		}
+
+		// Only one request can sign the envelope
+		session.TransactionId = flowTx.ID().Hex()
+		session.FlowTransaction = flowTx.Encode()
+
+		if err := s.store.UpdateCoSigningSession(session); err != nil {
+			return err
+		}
+	}
+
+	job, err := s.submitCoSigningTransaction(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		session.State = CoSigningSubmitted
+		session.JobId = &job.ID
+
+		err := s.store.UpdateCoSigningSession(session)
+		if !goerrors.Is(err, ErrCoSigningSessionChanged) || attempt >= maxCoSigningUpdateAttempts {
+			return err
+		}
+
+		// Submitted concurrently by a request retrying it
+		read, err := s.store.CoSigningSession(session.ID)
+		if err != nil {
+			return err
+		}
+
+		*session = read
+
+		if session.State == CoSigningSubmitted {
+			return nil
+		}
+	}
+}
+
+// submitCoSigningTransaction submits the signed transaction of a co-signing
+// session as a job. If the transaction was already stored by an interrupted
+// submission, whose job may have been lost, a new job is created for it.
+// Sending a transaction that has already been sent only waits for its result.
+func (s *ServiceImpl) submitCoSigningTransaction(ctx context.Context, session *CoSigningSession) (*jobs.Job, error) {
+	_, err := s.store.Transaction(session.TransactionId)
+	if err != nil && err.Error() != "record not found" {
+		return nil, err
+	}
+
+	if err == nil {
+		job, err := s.wp.CreateJob(SubmittedTransactionJobType, session.TransactionId, jobs.WithContextCallbackURL(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error while creating job: %w", err)
+		}
+
+		if err := s.wp.Schedule(job); err != nil {
+			return nil, fmt.Errorf("error while scheduling job: %w", err)
+		}
+
+		return job, nil
+	}
+
+	flowTx, err := flow.DecodeTransaction(session.FlowTransaction)
+	if err != nil {
+		return nil, err
+	}
+
+	// The code was checked against the allowlist when the session was created
+	job, _, err := s.Submit(WithoutCodePolicy(ctx), false, flowTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// walletAuthorizer returns an authorizer for the admin account or a custodial
+// account of this wallet. Returns keys.ErrAccountKeyNotFound for other
+// accounts.
+func (s *ServiceImpl) walletAuthorizer(ctx context.Context, address string) (keys.Authorizer, error) {
+	if address == s.cfg.AdminAddress {
+		return s.km.AdminAuthorizer(ctx)
+	}
+	return s.km.UserAuthorizer(ctx, flow.HexToAddress(address))
+}
+
+// externalAccountKey returns the key with the given index of an account not
+// held by this wallet, checking it can be used to propose transactions.
+func (s *ServiceImpl) externalAccountKey(ctx context.Context, address flow.Address, keyIndex int) (*flow.AccountKey, error) {
+	account, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting proposer account: %w", err)
+	}
+
+	key := accountKey(account, keyIndex)
+	if key == nil {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("not a valid proposal key of %s: %d", address, keyIndex),
+		}
+	}
+
+	return key, nil
+}
+
+// accountKey returns the key of account with the given index, or nil if there
+// is no such key or it has been revoked.
+func accountKey(account *flow.Account, keyIndex int) *flow.AccountKey {
+	for _, key := range account.Keys {
+		if key.Index == keyIndex && !key.Revoked {
+			return key
+		}
+	}
+	return nil
+}
+
+// verifyPayloadSignature verifies a payload signature of flowTx made with key.
+func verifyPayloadSignature(flowTx *flow.Transaction, key *flow.AccountKey, signature []byte) error {
+	hasher, err := crypto.NewHasher(key.HashAlgo)
+	if err != nil {
+		return err
+	}
+
+	message := append(flow.TransactionDomainTag[:], flowTx.PayloadMessage()...)
+
+	valid, err := key.PublicKey.Verify(signature, message, hasher)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return fmt.Errorf("signature does not match the payload")
+	}
+
+	return nil
+}
+
+// payloadSignedBy checks whether the payload signatures of account in flowTx
+// reach the full key weight and, if account is the proposer, include the
+// proposal key.
+func payloadSignedBy(flowTx *flow.Transaction, account *flow.Account) bool {
+	isProposer := account.Address == flowTx.ProposalKey.Address
+	proposalKeySigned := false
+	weight := 0
+
+	for _, sig := range flowTx.PayloadSignatures {
+		if sig.Address != account.Address {
+			continue
+		}
+
+		key := accountKey(account, sig.KeyIndex)
+		if key == nil {
+			continue
+		}
+
+		weight += key.Weight
+
+		if isProposer && sig.KeyIndex == flowTx.ProposalKey.KeyIndex {
+			proposalKeySigned = true
+		}
+	}
+
+	if isProposer && !proposalKeySigned {
+		return false
+	}
+
+	return weight >= flow.AccountKeyWeightThreshold
+}
@@ -0,0 +1,133 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+func testAccountKey(t *testing.T, index, weight int) (*flow.AccountKey, crypto.Signer) {
+	t.Helper()
+
+	seed := make([]byte, crypto.MinSeedLength)
+	seed[0] = byte(index + 1)
+
+	privateKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := flow.NewAccountKey().
+		FromPrivateKey(privateKey).
+		SetHashAlgo(crypto.SHA3_256).
+		SetWeight(weight)
+	key.Index = index
+
+	return key, crypto.NewInMemorySigner(privateKey, key.HashAlgo)
+}
+
+func TestPayloadSignedBy(t *testing.T) {
+	address := flow.HexToAddress("01cf0e2f2f715450")
+
+	key0, signer0 := testAccountKey(t, 0, 500)
+	key1, signer1 := testAccountKey(t, 1, 500)
+	account := &flow.Account{Address: address, Keys: []*flow.AccountKey{key0, key1}}
+
+	newTx := func(proposalKeyIndex int) *flow.Transaction {
+		return flow.NewTransaction().
+			SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+			SetReferenceBlockID(flow.HexToID("ff25699272a9f42b5268e1b9c80b40275ef772528d4dfe8aadb8e5aebdea9bd9")).
+			SetGasLimit(9999).
+			SetProposalKey(address, proposalKeyIndex, 42).
+			SetPayer(flow.HexToAddress("f8d6e0586b0a20c7")).
+			AddAuthorizer(address)
+	}
+
+	t.Run("full weight", func(t *testing.T) {
+		flowTx := newTx(0)
+
+		if err := flowTx.SignPayload(address, 0, signer0); err != nil {
+			t.Fatal(err)
+		}
+
+		if payloadSignedBy(flowTx, account) {
+			t.Fatal("expected half of the key weight not to be enough")
+		}
+
+		if err := flowTx.SignPayload(address, 1, signer1); err != nil {
+			t.Fatal(err)
+		}
+
+		if !payloadSignedBy(flowTx, account) {
+			t.Fatal("expected the full key weight to be enough")
+		}
+	})
+
+	t.Run("proposal key", func(t *testing.T) {
+		key2, signer2 := testAccountKey(t, 2, flow.AccountKeyWeightThreshold)
+		account := &flow.Account{Address: address, Keys: []*flow.AccountKey{key0, key1, key2}}
+
+		flowTx := newTx(0)
+
+		if err := flowTx.SignPayload(address, 2, signer2); err != nil {
+			t.Fatal(err)
+		}
+
+		if payloadSignedBy(flowTx, account) {
+			t.Fatal("expected the proposal key to be required")
+		}
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revoked := *key1
+		revoked.Revoked = true
+		account := &flow.Account{Address: address, Keys: []*flow.AccountKey{key0, &revoked}}
+
+		flowTx := newTx(0)
+
+		for i, signer := range []crypto.Signer{signer0, signer1} {
+			if err := flowTx.SignPayload(address, i, signer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if payloadSignedBy(flowTx, account) {
+			t.Fatal("expected the weight of a revoked key not to count")
+		}
+	})
+}
+
+func TestVerifyPayloadSignature(t *testing.T) {
+	address := flow.HexToAddress("01cf0e2f2f715450")
+	key0, signer0 := testAccountKey(t, 0, flow.AccountKeyWeightThreshold)
+	key1, _ := testAccountKey(t, 1, flow.AccountKeyWeightThreshold)
+
+	flowTx := flow.NewTransaction().
+		SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+		SetReferenceBlockID(flow.HexToID("ff25699272a9f42b5268e1b9c80b40275ef772528d4dfe8aadb8e5aebdea9bd9")).
+		SetGasLimit(9999).
+		SetProposalKey(address, 0, 42).
+		SetPayer(flow.HexToAddress("f8d6e0586b0a20c7")).
+		AddAuthorizer(address)
+
+	if err := flowTx.SignPayload(address, 0, signer0); err != nil {
+		t.Fatal(err)
+	}
+
+	signature := flowTx.PayloadSignatures[0].Signature
+
+	if err := verifyPayloadSignature(flowTx, key0, signature); err != nil {
+		t.Fatalf("expected the signature to be valid, got %v", err)
+	}
+
+	if err := verifyPayloadSignature(flowTx, key1, signature); err == nil {
+		t.Fatal("expected the signature not to match another key")
+	}
+
+	flowTx.SetGasLimit(100)
+
+	if err := verifyPayloadSignature(flowTx, key0, signature); err == nil {
+		t.Fatal("expected the signature not to match a changed payload")
+	}
+}
@@ -0,0 +1,140 @@
+package transactions
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Types of the events carrying the fees paid for a transaction, in order of
+// preference. Older networks only emit the FlowFees.TokensDeposited event.
+var feeEventTypeSuffixes = []string{
+	".FlowFees.FeesDeducted",
+	".FlowFees.TokensDeposited",
+}
+
+// Events is a list of transaction events, stored in the database as JSON
+// with the event values in JSON-Cadence Data Interchange Format.
+type Events []flow.Event
+
+type storedEvent struct {
+	Type             string `json:"type"`
+	TransactionId    string `json:"transactionId"`
+	TransactionIndex int    `json:"transactionIndex"`
+	EventIndex       int    `json:"eventIndex"`
+	Payload          []byte `json:"payload"`
+}
+
+func (ee Events) Value() (driver.Value, error) {
+	if ee == nil {
+		return nil, nil
+	}
+
+	stored := make([]storedEvent, len(ee))
+
+	for i, e := range ee {
+		payload := e.Payload
+		if len(payload) == 0 {
+			var err error
+			if payload, err = c_json.Encode(e.Value); err != nil {
+				return nil, fmt.Errorf("error while encoding event: %w", err)
+			}
+		}
+
+		stored[i] = storedEvent{
+			Type:             e.Type,
+			TransactionId:    e.TransactionID.Hex(),
+			TransactionIndex: e.TransactionIndex,
+			EventIndex:       e.EventIndex,
+			Payload:          payload,
+		}
+	}
+
+	return json.Marshal(stored)
+}
+
+func (ee *Events) Scan(value interface{}) error {
+	var b []byte
+
+	switch v := value.(type) {
+	case nil:
+		*ee = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported events value type %T", value)
+	}
+
+	var stored []storedEvent
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return err
+	}
+
+	events := make(Events, len(stored))
+
+	for i, s := range stored {
+		v, err := c_json.Decode(s.Payload)
+		if err != nil {
+			return fmt.Errorf("error while decoding event: %w", err)
+		}
+
+		value, ok := v.(cadence.Event)
+		if !ok {
+			return fmt.Errorf("error while decoding event: unexpected value type %T", v)
+		}
+
+		events[i] = flow.Event{
+			Type:             s.Type,
+			TransactionID:    flow.HexToID(s.TransactionId),
+			TransactionIndex: s.TransactionIndex,
+			EventIndex:       s.EventIndex,
+			Value:            value,
+			Payload:          s.Payload,
+		}
+	}
+
+	*ee = events
+
+	return nil
+}
+
+// Fees returns the amount of fees paid for the transaction which emitted the
+// events, or an empty string if there are no fee events.
+func (ee Events) Fees() string {
+	for _, suffix := range feeEventTypeSuffixes {
+		for _, e := range ee {
+			if !strings.HasSuffix(e.Type, suffix) {
+				continue
+			}
+
+			if amount := eventField(e.Value, "amount"); amount != nil {
+				return amount.String()
+			}
+		}
+	}
+
+	return ""
+}
+
+// eventField returns the value of the field of event with the given name.
+func eventField(event cadence.Event, name string) cadence.Value {
+	if event.EventType == nil {
+		return nil
+	}
+
+	for i, f := range event.EventType.Fields {
+		if f.Identifier == name && i < len(event.Fields) {
+			return event.Fields[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func testEvent(t *testing.T, eventType string, payload string) flow.Event {
+	t.Helper()
+
+	v, err := c_json.Decode([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return flow.Event{
+		Type:             eventType,
+		TransactionID:    flow.HexToID("01"),
+		TransactionIndex: 1,
+		EventIndex:       2,
+		Value:            v.(cadence.Event),
+		Payload:          []byte(payload),
+	}
+}
+
+const (
+	feesDeductedType    = "A.f919ee77447b7497.FlowFees.FeesDeducted"
+	feesDeductedPayload = `{"type":"Event","value":{"id":"A.f919ee77447b7497.FlowFees.FeesDeducted","fields":[{"name":"amount","value":{"type":"UFix64","value":"0.00001000"}},{"name":"inclusionEffort","value":{"type":"UFix64","value":"1.00000000"}},{"name":"executionEffort","value":{"type":"UFix64","value":"0.00000010"}}]}}`
+
+	tokensDepositedType    = "A.f919ee77447b7497.FlowFees.TokensDeposited"
+	tokensDepositedPayload = `{"type":"Event","value":{"id":"A.f919ee77447b7497.FlowFees.TokensDeposited","fields":[{"name":"amount","value":{"type":"UFix64","value":"0.00000100"}}]}}`
+
+	greetingType    = "A.01cf0e2f2f715450.Greeting.Greeted"
+	greetingPayload = `{"type":"Event","value":{"id":"A.01cf0e2f2f715450.Greeting.Greeted","fields":[{"name":"greeting","value":{"type":"String","value":"Hello"}}]}}`
+)
+
+func TestEventsValueScan(t *testing.T) {
+	events := Events{
+		testEvent(t, greetingType, greetingPayload),
+		testEvent(t, feesDeductedType, feesDeductedPayload),
+	}
+
+	value, err := events.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned Events
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scanned) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(scanned))
+	}
+
+	for i, e := range scanned {
+		if e.Type != events[i].Type ||
+			e.TransactionID != events[i].TransactionID ||
+			e.TransactionIndex != events[i].TransactionIndex ||
+			e.EventIndex != events[i].EventIndex {
+			t.Fatalf("expected event %v, got %v", events[i], e)
+		}
+
+		if e.Value.String() != events[i].Value.String() {
+			t.Fatalf("expected event value %s, got %s", events[i].Value, e.Value)
+		}
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		value, err := Events(nil).Value()
+		if err != nil || value != nil {
+			t.Fatalf("expected nil value, got %v, %v", value, err)
+		}
+
+		scanned := Events{}
+		if err := scanned.Scan(nil); err != nil || scanned != nil {
+			t.Fatalf("expected nil events, got %v, %v", scanned, err)
+		}
+	})
+}
+
+func TestEventsFees(t *testing.T) {
+	testCases := []struct {
+		name     string
+		events   Events
+		expected string
+	}{
+		{
+			name:     "fees deducted",
+			events:   Events{testEvent(t, tokensDepositedType, tokensDepositedPayload), testEvent(t, feesDeductedType, feesDeductedPayload)},
+			expected: "0.00001000",
+		},
+		{
+			name:     "tokens deposited",
+			events:   Events{testEvent(t, greetingType, greetingPayload), testEvent(t, tokensDepositedType, tokensDepositedPayload)},
+			expected: "0.00000100",
+		},
+		{
+			name:     "no fee events",
+			events:   Events{testEvent(t, greetingType, greetingPayload)},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if fees := tc.events.Fees(); fees != tc.expected {
+				t.Fatalf("expected fees %q, got %q", tc.expected, fees)
+			}
+		})
+	}
+}
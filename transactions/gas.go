@@ -0,0 +1,114 @@
+package transactions
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+)
+
+// TransactionOption sets optional parameters of a transaction built by the
+// service.
+type TransactionOption func(*transactionOptions)
+
+type transactionOptions struct {
+	gasLimit uint64
+}
+
+// WithGasLimit sets the gas limit of a transaction, overriding the gas limit
+// configured for its type. The limit may not exceed MaxTransactionGasLimit.
+// 0 keeps the configured limit.
+func WithGasLimit(limit uint64) TransactionOption {
+	return func(o *transactionOptions) {
+		o.gasLimit = limit
+	}
+}
+
+// ParseTypeGasLimit parses a per transaction type gas limit in the format
+// "<type>:<limit>", e.g. "FtTransfer:100".
+func ParseTypeGasLimit(s string) (Type, uint64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Unknown, 0, fmt.Errorf("invalid transaction gas limit %q, expected <type>:<limit>", s)
+	}
+
+	tType := StatusFromText(strings.TrimSpace(parts[0]))
+	if tType == Unknown {
+		return Unknown, 0, fmt.Errorf("invalid transaction gas limit %q, unknown transaction type", s)
+	}
+
+	limit, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return Unknown, 0, fmt.Errorf("invalid transaction gas limit %q: %w", s, err)
+	}
+
+	if limit == 0 {
+		return Unknown, 0, fmt.Errorf("invalid transaction gas limit %q, expected limit >= 1", s)
+	}
+
+	return tType, limit, nil
+}
+
+// ParseTypeGasLimits parses the per transaction type gas limits of cfg, see
+// configs.Config.TransactionTypeGasLimits. None of them may exceed the
+// maximum transaction gas limit.
+func ParseTypeGasLimits(cfg *configs.Config) (map[Type]uint64, error) {
+	limits := make(map[Type]uint64, len(cfg.TransactionTypeGasLimits))
+
+	for _, spec := range cfg.TransactionTypeGasLimits {
+		tType, limit, err := ParseTypeGasLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if max := cfg.MaxGasLimit(); limit > max {
+			return nil, fmt.Errorf("transaction gas limit %q exceeds the maximum transaction gas limit of %d", spec, max)
+		}
+
+		limits[tType] = limit
+	}
+
+	return limits, nil
+}
+
+// typeGasLimit returns the gas limit configured for transactions of tType.
+func (s *ServiceImpl) typeGasLimit(tType Type) uint64 {
+	if limit, ok := s.typeGasLimits[tType]; ok {
+		return limit
+	}
+
+	if s.cfg.TransactionGasLimit > 0 {
+		return s.cfg.TransactionGasLimit
+	}
+
+	return configs.DefaultGasLimit
+}
+
+// maxGasLimit returns the highest gas limit a request can ask for.
+func (s *ServiceImpl) maxGasLimit() uint64 {
+	return s.cfg.MaxGasLimit()
+}
+
+// gasLimit returns the gas limit of a transaction of tType built with opts.
+func (s *ServiceImpl) gasLimit(tType Type, opts []TransactionOption) (uint64, error) {
+	var o transactionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.gasLimit == 0 {
+		return s.typeGasLimit(tType), nil
+	}
+
+	if max := s.maxGasLimit(); o.gasLimit > max {
+		return 0, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("gas limit %d exceeds the maximum of %d", o.gasLimit, max),
+		}
+	}
+
+	return o.gasLimit, nil
+}
@@ -0,0 +1,110 @@
+package transactions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+)
+
+func TestParseTypeGasLimit(t *testing.T) {
+	tType, limit, err := ParseTypeGasLimit(" FtTransfer : 100 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tType != FtTransfer || limit != 100 {
+		t.Fatalf("expected FtTransfer:100, got %s:%d", tType, limit)
+	}
+
+	for _, spec := range []string{"", "General", "General:", "Unknown:100", "Foo:100", "General:-1", "General:0", "General:1:2"} {
+		if _, _, err := ParseTypeGasLimit(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+
+	// The config accepts gas limits for every transaction type
+	for tType := General; tType <= NftTransfer; tType++ {
+		parsed, _, err := ParseTypeGasLimit(tType.String() + ":100")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed != tType {
+			t.Errorf("expected %s, got %s", tType, parsed)
+		}
+	}
+}
+
+func TestParseTypeGasLimits(t *testing.T) {
+	cfg := &configs.Config{
+		MaxTransactionGasLimit:   1000,
+		TransactionTypeGasLimits: []string{"FtSetup:100", "FtTransfer:1000"},
+	}
+
+	limits, err := ParseTypeGasLimits(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(limits) != 2 || limits[FtSetup] != 100 || limits[FtTransfer] != 1000 {
+		t.Fatalf("expected FtSetup:100 and FtTransfer:1000, got %v", limits)
+	}
+
+	for _, spec := range []string{"FtSetup", "FtSetup:0", "Foo:100", "FtSetup:1001"} {
+		cfg.TransactionTypeGasLimits = []string{"FtTransfer:100", spec}
+		if _, err := ParseTypeGasLimits(cfg); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestGasLimit(t *testing.T) {
+	svc := &ServiceImpl{
+		cfg: &configs.Config{
+			TransactionGasLimit:    1000,
+			MaxTransactionGasLimit: 2000,
+		},
+		typeGasLimits: map[Type]uint64{FtTransfer: 100},
+	}
+
+	cases := []struct {
+		name     string
+		tType    Type
+		opts     []TransactionOption
+		expected uint64
+	}{
+		{"default", General, nil, 1000},
+		{"type", FtTransfer, nil, 100},
+		{"requested", General, []TransactionOption{WithGasLimit(1500)}, 1500},
+		{"requested maximum", General, []TransactionOption{WithGasLimit(2000)}, 2000},
+		{"requested zero", FtTransfer, []TransactionOption{WithGasLimit(0)}, 100},
+	}
+
+	for _, c := range cases {
+		limit, err := svc.gasLimit(c.tType, c.opts)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if limit != c.expected {
+			t.Errorf("%s: expected %d, got %d", c.name, c.expected, limit)
+		}
+	}
+
+	_, err := svc.gasLimit(General, []TransactionOption{WithGasLimit(2001)})
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a %d request error, got %#v", http.StatusBadRequest, err)
+	}
+
+	// Unconfigured limits fall back to the default
+	svc = &ServiceImpl{cfg: &configs.Config{}}
+
+	if limit, err := svc.gasLimit(General, nil); err != nil || limit != configs.DefaultGasLimit {
+		t.Fatalf("expected %d, got %d, %v", configs.DefaultGasLimit, limit, err)
+	}
+
+	if _, err := svc.gasLimit(General, []TransactionOption{WithGasLimit(configs.DefaultGasLimit + 1)}); err == nil {
+		t.Fatal("expected an error for a gas limit over the default maximum")
+	}
+}
@@ -2,12 +2,21 @@ package transactions
 
 import (
 	"context"
+	"time"
 
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 )
 
 const TransactionJobType = "transaction"
 
+// SubmittedTransactionJobType is the job type of sending a transaction signed
+// outside of the wallet, see Service.Submit.
+const SubmittedTransactionJobType = "submitted_transaction"
+
+// TransactionJobTimeout is the execution timeout of jobs sending a
+// transaction and waiting for it to be sealed.
+const TransactionJobTimeout = 10 * time.Minute
+
 func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) error {
 	if j.Type != TransactionJobType {
 		return jobs.ErrInvalidJobType
@@ -20,6 +29,15 @@ func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) er
 		return err
 	}
 
+	// The reference block or the proposal key sequence number of the
+	// transaction may be out of date by the time the job runs, e.g. when it
+	// is retried or was deferred
+	if err := s.rebuildStaleTransaction(ctx, &tx); err != nil {
+		return err
+	}
+
+	j.TransactionID = tx.TransactionId
+
 	err = s.sendTransaction(ctx, &tx)
 	if err != nil {
 		return err
@@ -27,3 +45,19 @@ func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) er
 
 	return nil
 }
+
+func (s *ServiceImpl) executeSubmittedTransactionJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != SubmittedTransactionJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	tx, err := s.store.Transaction(j.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	// Signed outside of the wallet, so the transaction can not be rebuilt
+	return s.sendTransaction(ctx, &tx)
+}
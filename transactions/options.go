@@ -9,3 +9,11 @@ func WithTxRatelimiter(limiter ratelimit.Limiter) ServiceOption {
 		svc.txRateLimiter = limiter
 	}
 }
+
+// WithTypeGasLimits sets the per transaction type gas limits, as parsed from
+// the config with ParseTypeGasLimits when it was loaded.
+func WithTypeGasLimits(limits map[Type]uint64) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.typeGasLimits = limits
+	}
+}
@@ -2,6 +2,7 @@ package transactions
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"net/http"
 
@@ -12,15 +13,25 @@ import (
 	"github.com/flow-hydraulics/flow-wallet-api/jobs"
 	"github.com/flow-hydraulics/flow-wallet-api/keys"
 	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/client"
+	log "github.com/sirupsen/logrus"
 	"go.uber.org/ratelimit"
 	"google.golang.org/grpc/codes"
 )
 
 type Service interface {
-	Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error)
-	Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error)
+	Create(ctx context.Context, sync bool, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error)
+	Sign(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error)
+	Submit(ctx context.Context, sync bool, flowTx *flow.Transaction) (*jobs.Job, *Transaction, error)
+	CreateCoSigningSession(ctx context.Context, req CoSigningSessionJSONRequest) (*CoSigningSession, error)
+	CoSigningSessionDetails(id string) (*CoSigningSession, error)
+	AddCoSigningSignature(ctx context.Context, id string, req TransactionSignatureJSON) (*CoSigningSession, error)
+	AllowedCode() ([]AllowedCode, error)
+	AddAllowedCode(req AllowedCodeJSONRequest) (*AllowedCode, error)
+	RemoveAllowedCode(id string) error
+	CodeAuditLog(limit, offset int) ([]CodeAuditEntry, error)
 	List(limit, offset int) ([]Transaction, error)
 	ListForAccount(tType Type, address string, limit, offset int) ([]Transaction, error)
 	Details(ctx context.Context, transactionId string) (*Transaction, error)
@@ -38,6 +49,7 @@ type ServiceImpl struct {
 	wp            jobs.WorkerPool
 	cfg           *configs.Config
 	txRateLimiter ratelimit.Limiter
+	typeGasLimits map[Type]uint64
 }
 
 // NewService initiates a new transaction service.
@@ -52,24 +64,51 @@ func NewService(
 	var defaultTxRatelimiter = ratelimit.NewUnlimited()
 
 	// TODO(latenssi): safeguard against nil config?
-	svc := &ServiceImpl{store, km, fc, wp, cfg, defaultTxRatelimiter}
+	svc := &ServiceImpl{store, km, fc, wp, cfg, defaultTxRatelimiter, nil}
 
 	for _, opt := range opts {
 		opt(svc)
 	}
 
+	if svc.typeGasLimits == nil {
+		// Not parsed when the config was loaded, see WithTypeGasLimits
+		limits, err := ParseTypeGasLimits(cfg)
+		if err != nil {
+			panic(err)
+		}
+		svc.typeGasLimits = limits
+	}
+
 	if wp == nil {
 		panic("workerpool nil")
 	}
 
 	// Register asynchronous job executor.
-	wp.RegisterExecutor(TransactionJobType, svc.executeTransactionJob)
+	wp.RegisterExecutor(TransactionJobType, svc.executeTransactionJob, jobs.WithExecutorTimeout(TransactionJobTimeout), jobs.WithStatusNotifications())
+	wp.RegisterExecutor(SubmittedTransactionJobType, svc.executeSubmittedTransactionJob, jobs.WithExecutorTimeout(TransactionJobTimeout), jobs.WithStatusNotifications())
 
 	return svc
 }
 
-func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error) {
-	transaction, err := s.newTransaction(ctx, proposerAddress, code, args, tType)
+// Create creates a transaction proposed by proposerAddress and sends it,
+// either synchronously or as a job. The transaction is authorized by the
+// accounts in authorizerAddresses, or by the proposer if none are given.
+// The code of General transactions is checked against the code allowlist.
+// The gas limit is the one configured for tType unless given with
+// WithGasLimit.
+func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error) {
+	if tType == General {
+		if err := s.checkCodePolicy(ctx, CodePolicyCreate, proposerAddress, authorizerAddresses, []byte(code)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	gasLimit, err := s.gasLimit(tType, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction, err := s.newTransaction(ctx, proposerAddress, authorizerAddresses, code, args, tType, gasLimit)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error while getting new transaction: %w", err)
 	}
@@ -80,7 +119,7 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress str
 
 	if !sync {
 		// Async
-		job, err := s.wp.CreateJob(TransactionJobType, transaction.TransactionId)
+		job, err := s.wp.CreateJob(TransactionJobType, transaction.TransactionId, jobs.WithContextCallbackURL(ctx))
 		if err != nil {
 			return nil, nil, fmt.Errorf("error while creating job: %w", err)
 		}
@@ -101,8 +140,18 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress str
 	}
 }
 
-func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error) {
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+// Sign builds and signs a transaction like Create, without sending it.
+func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error) {
+	if err := s.checkCodePolicy(ctx, CodePolicySign, proposerAddress, authorizerAddresses, []byte(code)); err != nil {
+		return nil, err
+	}
+
+	gasLimit, err := s.gasLimit(General, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, authorizerAddresses, code, args, gasLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +159,67 @@ func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code str
 	return &SignedTransaction{Transaction: *flowTx}, nil
 }
 
+// Submit sends a transaction signed outside of the wallet, either
+// synchronously or as a job. The admin account signs the envelope if it is
+// the payer and has not signed the transaction yet. As the wallet can not
+// re-sign the transaction, it is sent as is.
+func (s *ServiceImpl) Submit(ctx context.Context, sync bool, flowTx *flow.Transaction) (*jobs.Job, *Transaction, error) {
+	authorizerAddresses := make([]string, len(flowTx.Authorizers))
+	for i, a := range flowTx.Authorizers {
+		authorizerAddresses[i] = a.Hex()
+	}
+
+	if err := s.checkCodePolicy(ctx, CodePolicySubmit, flowTx.ProposalKey.Address.Hex(), authorizerAddresses, flowTx.Script); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.signSubmittedTransaction(ctx, flowTx); err != nil {
+		return nil, nil, err
+	}
+
+	transactionId := flowTx.ID().Hex()
+
+	if _, err := s.store.Transaction(transactionId); err == nil {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusConflict,
+			Err:        fmt.Errorf("transaction already submitted: %s", transactionId),
+		}
+	}
+
+	transaction := &Transaction{
+		TransactionId:   transactionId,
+		TransactionType: General,
+		ProposerAddress: flow_helpers.FormatAddress(flowTx.ProposalKey.Address),
+		FlowTransaction: flowTx.Encode(),
+		GasLimit:        flowTx.GasLimit,
+	}
+
+	if err := s.store.InsertTransaction(transaction); err != nil {
+		return nil, nil, fmt.Errorf("error while inserting transaction in db: %w", err)
+	}
+
+	if !sync {
+		// Async
+		job, err := s.wp.CreateJob(SubmittedTransactionJobType, transaction.TransactionId, jobs.WithContextCallbackURL(ctx))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while creating job: %w", err)
+		}
+
+		if err := s.wp.Schedule(job); err != nil {
+			return nil, nil, fmt.Errorf("error while scheduling job: %w", err)
+		}
+
+		return job, transaction, nil
+	}
+
+	// Sync
+	if err := s.sendTransaction(ctx, transaction); err != nil {
+		return nil, nil, err
+	}
+
+	return nil, transaction, nil
+}
+
 // List returns all transactions in the datastore.
 func (s *ServiceImpl) List(limit, offset int) ([]Transaction, error) {
 	o := datastore.ParseListOptions(limit, offset)
@@ -147,13 +257,10 @@ func (s *ServiceImpl) Details(ctx context.Context, transactionId string) (*Trans
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
-	if err != nil {
+	if err := s.refreshResult(ctx, &transaction); err != nil {
 		return nil, err
 	}
 
-	transaction.Events = result.Events
-
 	return &transaction, nil
 }
 
@@ -181,13 +288,10 @@ func (s *ServiceImpl) DetailsForAccount(ctx context.Context, tType Type, address
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
-	if err != nil {
+	if err := s.refreshResult(ctx, &transaction); err != nil {
 		return nil, err
 	}
 
-	transaction.Events = result.Events
-
 	return &transaction, nil
 }
 
@@ -208,7 +312,23 @@ func (s *ServiceImpl) GetOrCreateTransaction(transactionId string) *Transaction
 	return s.store.GetOrCreateTransaction(transactionId)
 }
 
-func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress, code string, arguments []Argument) (*flow.Transaction, error) {
+func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, arguments []Argument, gasLimit uint64) (*flow.Transaction, error) {
+	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildFlowTransactionWithProposer(ctx, proposer, authorizerAddresses, code, arguments, gasLimit)
+}
+
+// buildFlowTransactionWithProposer builds and signs a Flow transaction
+// proposed with the key of proposer.
+func (s *ServiceImpl) buildFlowTransactionWithProposer(ctx context.Context, proposer keys.Authorizer, authorizerAddresses []string, code string, arguments []Argument, gasLimit uint64) (*flow.Transaction, error) {
+	authorizerAddresses, err := s.validateAuthorizerAddresses(authorizerAddresses)
+	if err != nil {
+		return nil, err
+	}
+
 	latestBlockID, err := flow_helpers.LatestBlockId(ctx, s.fc)
 	if err != nil {
 		return nil, err
@@ -220,7 +340,7 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 		return nil, fmt.Errorf("error while getting admin authorizer for payer: %w", err)
 	}
 
-	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	authorizers, err := s.getAuthorizers(ctx, proposer, payer, authorizerAddresses)
 	if err != nil {
 		return nil, err
 	}
@@ -230,7 +350,7 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 		SetReferenceBlockID(*latestBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetGasLimit(maxGasLimit).
+		SetGasLimit(gasLimit).
 		SetScript([]byte(code))
 
 	for _, arg := range arguments {
@@ -245,9 +365,9 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 		}
 	}
 
-	// Add authorizers. We assume proposer is always the sole authorizer
-	// https://github.com/flow-hydraulics/flow-wallet-api/issues/79
-	flowTx.AddAuthorizer(proposer.Address)
+	for _, authorizer := range authorizers {
+		flowTx.AddAuthorizer(authorizer.Address)
+	}
 
 	// Proposer signs the payload (unless proposer == payer).
 	if !proposer.Equals(payer) {
@@ -256,6 +376,18 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 		}
 	}
 
+	// Other authorizers sign the payload with their own keys. The signatures
+	// of the proposer and the payer already cover their accounts.
+	for _, authorizer := range authorizers {
+		if authorizer.Address == proposer.Address || authorizer.Address == payer.Address {
+			continue
+		}
+
+		if err := flowTx.SignPayload(authorizer.Address, authorizer.Key.Index, authorizer.Signer); err != nil {
+			return nil, err
+		}
+	}
+
 	// Payer signs the envelope
 	if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
 		return nil, err
@@ -264,13 +396,14 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 	return flowTx, nil
 }
 
-func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, code string, args []Argument, tType Type) (*Transaction, error) {
+func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, gasLimit uint64) (*Transaction, error) {
 	tx := &Transaction{
 		ProposerAddress: proposerAddress,
 		TransactionType: tType,
+		GasLimit:        gasLimit,
 	}
 
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, authorizerAddresses, code, args, gasLimit)
 	if err != nil {
 		return nil, fmt.Errorf("error while building transaction: %w", err)
 	}
@@ -304,41 +437,361 @@ func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress
 	return proposer, nil
 }
 
-func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) error {
-	// TODO: we should "recreate" the transaction as proposal key sequence numbering
-	// might have gotten out of sync by now (in async situations)
+// signSubmittedTransaction validates a transaction signed outside of the
+// wallet and, if the admin account is the payer, signs its envelope.
+func (s *ServiceImpl) signSubmittedTransaction(ctx context.Context, flowTx *flow.Transaction) error {
+	invalid := func(format string, a ...interface{}) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid transaction: "+format, a...),
+		}
+	}
+
+	if len(flowTx.Script) == 0 {
+		return invalid("empty code")
+	}
+
+	if flowTx.ReferenceBlockID == flow.EmptyID {
+		return invalid("missing reference block id")
+	}
+
+	for _, address := range append([]flow.Address{flowTx.ProposalKey.Address, flowTx.Payer}, flowTx.Authorizers...) {
+		if !address.IsValid(s.cfg.ChainID) {
+			return invalid(`not a valid address: "%s"`, address)
+		}
+	}
+
+	if flowTx.Payer != flow.HexToAddress(s.cfg.AdminAddress) {
+		// Paid by another account which must have signed the envelope
+		for _, sig := range flowTx.EnvelopeSignatures {
+			if sig.Address == flowTx.Payer {
+				return nil
+			}
+		}
+		return invalid("missing payer envelope signature")
+	}
+
+	if max := s.maxGasLimit(); flowTx.GasLimit > max {
+		return invalid("gas limit %d exceeds the maximum of %d", flowTx.GasLimit, max)
+	}
+
+	for _, sig := range flowTx.EnvelopeSignatures {
+		if sig.Address == flowTx.Payer {
+			// Already signed by the admin account
+			return nil
+		}
+	}
+
+	// The admin envelope signature would also stand for the admin account as
+	// a proposer or an authorizer
+	if flowTx.ProposalKey.Address == flowTx.Payer {
+		return invalid("admin account can not be the proposer")
+	}
+
+	for _, address := range flowTx.Authorizers {
+		if address == flowTx.Payer {
+			return invalid("admin account can not be an authorizer")
+		}
+	}
+
+	payer, err := s.km.AdminAuthorizer(ctx)
+	if err != nil {
+		return fmt.Errorf("error while getting admin authorizer for payer: %w", err)
+	}
+
+	if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+// validateAuthorizerAddresses validates and formats the given authorizer
+// addresses. The same account can not authorize a transaction twice.
+func (s *ServiceImpl) validateAuthorizerAddresses(addresses []string) ([]string, error) {
+	validated := make([]string, 0, len(addresses))
+	seen := make(map[string]bool, len(addresses))
+
+	for _, address := range addresses {
+		address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[address] {
+			return nil, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf(`duplicate authorizer: "%s"`, address),
+			}
+		}
+
+		seen[address] = true
+		validated = append(validated, address)
+	}
+
+	return validated, nil
+}
+
+// getAuthorizers returns the authorizers of a transaction for the given
+// (validated) addresses. The proposer is the sole authorizer if no addresses
+// are given. The admin account and custodial accounts can authorize, each
+// using its own key.
+func (s *ServiceImpl) getAuthorizers(ctx context.Context, proposer, payer keys.Authorizer, addresses []string) ([]keys.Authorizer, error) {
+	if len(addresses) == 0 {
+		return []keys.Authorizer{proposer}, nil
+	}
+
+	authorizers := make([]keys.Authorizer, 0, len(addresses))
+
+	for _, address := range addresses {
+		flowAddress := flow.HexToAddress(address)
+
+		switch flowAddress {
+		case proposer.Address:
+			authorizers = append(authorizers, proposer)
+		case payer.Address:
+			authorizers = append(authorizers, payer)
+		default:
+			authorizer, err := s.km.UserAuthorizer(ctx, flowAddress)
+			if goerrors.Is(err, keys.ErrAccountKeyNotFound) {
+				return nil, &errors.RequestError{
+					StatusCode: http.StatusBadRequest,
+					Err:        fmt.Errorf(`authorizer is not an account of this wallet: "%s"`, address),
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error while getting authorizer %s: %w", address, err)
+			}
+			authorizers = append(authorizers, authorizer)
+		}
+	}
+
+	return authorizers, nil
+}
+
+// rebuildStaleTransaction rebuilds and re-signs the Flow transaction of tx
+// if it can no longer be included in a block: its reference block has expired
+// or its proposal key sequence number has been used by another transaction.
+// The original proposal key is used again with its current sequence number,
+// so that the original and the rebuilt transaction can never both be
+// executed. The transaction is stored with its new id, keeping the id of the
+// originally built transaction in OriginalTransactionId.
+func (s *ServiceImpl) rebuildStaleTransaction(ctx context.Context, tx *Transaction) error {
 	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
 	if err != nil {
 		return err
 	}
 
-	// Check if transaction has been sent already.
-	_, err = s.fc.GetTransaction(ctx, flowTx.ID())
+	included, err := s.isIncluded(ctx, flowTx.ID())
+	if err != nil {
+		return err
+	}
+
+	if included {
+		return nil
+	}
+
+	proposer, err := s.km.ProposalKeyAuthorizer(ctx, flowTx.ProposalKey.Address, flowTx.ProposalKey.KeyIndex)
+	if err != nil {
+		return fmt.Errorf("error while getting proposal key for rebuilding transaction: %w", err)
+	}
+
+	if proposer.Key.SequenceNumber == flowTx.ProposalKey.SequenceNumber {
+		expired, err := flow_helpers.ReferenceBlockExpired(ctx, s.fc, flowTx.ReferenceBlockID)
+		if err != nil {
+			return err
+		}
+
+		if !expired {
+			// The transaction can still be executed as is
+			return nil
+		}
+	}
+
+	args := make([]Argument, len(flowTx.Arguments))
+	for i, a := range flowTx.Arguments {
+		args[i], err = c_json.Decode(a)
+		if err != nil {
+			return fmt.Errorf("error while decoding transaction argument: %w", err)
+		}
+	}
+
+	authorizers := make([]string, len(flowTx.Authorizers))
+	for i, a := range flowTx.Authorizers {
+		authorizers[i] = a.Hex()
+	}
+
+	// Keep the gas limit the transaction was originally built with
+	rebuilt, err := s.buildFlowTransactionWithProposer(ctx, proposer, authorizers, string(flowTx.Script), args, flowTx.GasLimit)
+	if err != nil {
+		return fmt.Errorf("error while rebuilding transaction: %w", err)
+	}
+
+	previousId := tx.TransactionId
+
+	if tx.OriginalTransactionId == "" {
+		tx.OriginalTransactionId = previousId
+	}
+	tx.TransactionId = rebuilt.ID().Hex()
+	tx.FlowTransaction = rebuilt.Encode()
+
+	// Store before sending, so the sent transaction can always be found
+	if err := s.store.ReplaceTransaction(previousId, tx); err != nil {
+		return fmt.Errorf("error while updating rebuilt transaction in db: %w", err)
+	}
+
+	return nil
+}
+
+// isSubmitted checks whether the Flow transaction with the given id has been
+// submitted to the network.
+func (s *ServiceImpl) isSubmitted(ctx context.Context, id flow.Identifier) (bool, error) {
+	_, err := s.fc.GetTransaction(ctx, id)
 	if err != nil {
 		rpcErr, ok := err.(client.RPCError)
 		if !ok {
 			// The error wasn't from gRPC.
-			return err
+			return false, err
 		}
 
 		if rpcErr.GRPCStatus().Code() != codes.NotFound {
 			// Something unexpected went wrong in the gRPC call or in the Access API.
-			return err
+			return false, err
 		}
 
-		// The Flow transaction was not found. All good. Continue.
+		// The Flow transaction was not found.
+		return false, nil
 	}
 
-	// Ratelimit
-	s.txRateLimiter.Take()
+	return true, nil
+}
+
+// isIncluded checks whether the Flow transaction with the given id has been
+// included in a block.
+func (s *ServiceImpl) isIncluded(ctx context.Context, id flow.Identifier) (bool, error) {
+	submitted, err := s.isSubmitted(ctx, id)
+	if err != nil || !submitted {
+		return false, err
+	}
+
+	result, err := s.fc.GetTransactionResult(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	switch result.Status {
+	case flow.TransactionStatusFinalized, flow.TransactionStatusExecuted, flow.TransactionStatusSealed:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) error {
+	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
+	if err != nil {
+		return err
+	}
+
+	// Check if transaction has been sent already.
+	submitted, err := s.isSubmitted(ctx, flowTx.ID())
+	if err != nil {
+		return err
+	}
+
+	var resp *flow.TransactionResult
+
+	if submitted {
+		// Only wait for the result, e.g. when retrying a job
+		resp, err = flow_helpers.WaitForSeal(ctx, s.fc, flowTx.ID(), s.cfg.TransactionTimeout)
+	} else {
+		// Ratelimit
+		s.txRateLimiter.Take()
+
+		resp, err = flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+	}
+	if resp != nil {
+		// Sealed, failed or expired. The result is left to be stored on a
+		// later Details call if it can not be completed now.
+		if err := s.setResult(ctx, tx, resp); err != nil {
+			log.
+				WithFields(log.Fields{"error": err, "transactionId": tx.TransactionId}).
+				Warn("Could not get the result of a final transaction")
+		} else if err := s.store.UpdateTransaction(tx); err != nil {
+			return fmt.Errorf("error while updating transaction result in db: %w", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// refreshResult fetches the result of tx from the chain while it is still
+// pending, storing it once the transaction is final.
+func (s *ServiceImpl) refreshResult(ctx context.Context, tx *Transaction) error {
+	if tx.Status.IsFinal() {
+		return nil
+	}
 
-	resp, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+	// The transaction may have been rebuilt with a new id
+	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(tx.TransactionId))
 	if err != nil {
 		return err
 	}
 
-	tx.Events = resp.Events
+	if err := s.setResult(ctx, tx, result); err != nil {
+		return err
+	}
+
+	if tx.Status.IsFinal() {
+		if err := s.store.UpdateTransaction(tx); err != nil {
+			return fmt.Errorf("error while updating transaction result in db: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setResult sets the status, error, events and, once included in a block,
+// the block and fees of tx from result.
+func (s *ServiceImpl) setResult(ctx context.Context, tx *Transaction, result *flow.TransactionResult) error {
+	tx.Events = result.Events
+
+	switch {
+	case result.Error != nil:
+		tx.Status = StatusFailed
+		tx.Error = result.Error.Error()
+	case result.Status == flow.TransactionStatusExpired:
+		// Never included in a block
+		tx.Status = StatusExpired
+		tx.Error = "transaction expired"
+		return nil
+	case result.Status == flow.TransactionStatusSealed:
+		tx.Status = StatusSealed
+		tx.Error = ""
+	default:
+		tx.Status = StatusPending
+		return nil
+	}
+
+	blockId, err := s.fc.GetTransactionResultBlockID(ctx, flow.HexToID(tx.TransactionId))
+	if err != nil {
+		return fmt.Errorf("error while getting transaction block: %w", err)
+	}
+
+	if blockId != flow.EmptyID {
+		block, err := s.fc.GetBlockHeaderByID(ctx, blockId)
+		if err != nil {
+			return fmt.Errorf("error while getting transaction block: %w", err)
+		}
+
+		tx.BlockId = block.ID.Hex()
+		tx.BlockHeight = block.Height
+	}
+
+	tx.Fees = tx.Events.Fees()
 
 	return nil
 }
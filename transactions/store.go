@@ -2,6 +2,7 @@ package transactions
 
 import (
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/google/uuid"
 )
 
 // Store manages data regarding transactions.
@@ -13,4 +14,23 @@ type Store interface {
 	GetOrCreateTransaction(txId string) *Transaction
 	InsertTransaction(*Transaction) error
 	UpdateTransaction(*Transaction) error
+	// ReplaceTransaction replaces the Flow transaction of the transaction
+	// previously identified by previousTxId with that of t, including its id.
+	ReplaceTransaction(previousTxId string, t *Transaction) error
+
+	// Co-signing sessions
+	CoSigningSession(id uuid.UUID) (CoSigningSession, error)
+	InsertCoSigningSession(*CoSigningSession) error
+	// UpdateCoSigningSession updates the co-signing session unless it has
+	// been updated since it was read, in which case ErrCoSigningSessionChanged
+	// is returned.
+	UpdateCoSigningSession(*CoSigningSession) error
+
+	// Code allowlist
+	AllowedCode() ([]AllowedCode, error)
+	AllowedCodeByHash(codeHash string) ([]AllowedCode, error)
+	InsertAllowedCode(*AllowedCode) error
+	DeleteAllowedCode(id uint64) error
+	CodeAuditEntries(opt datastore.ListOptions) ([]CodeAuditEntry, error)
+	InsertCodeAuditEntry(*CodeAuditEntry) error
 }
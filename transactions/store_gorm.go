@@ -1,7 +1,10 @@
 package transactions
 
 import (
+	"time"
+
 	"github.com/flow-hydraulics/flow-wallet-api/datastore"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -26,9 +29,12 @@ func (s *GormStore) Transactions(o datastore.ListOptions) (tt []Transaction, err
 	return
 }
 
+// Transaction returns the transaction with the given id, or the transaction
+// rebuilt from the transaction originally having the given id.
 func (s *GormStore) Transaction(txId string) (t Transaction, err error) {
-	q := &Transaction{TransactionId: txId}
-	err = s.db.Where(q).First(&t).Error
+	err = s.db.
+		Where("(transaction_id = ? OR original_transaction_id = ?)", txId, txId).
+		First(&t).Error
 	return
 }
 
@@ -46,8 +52,11 @@ func (s *GormStore) TransactionsForAccount(tType Type, address string, o datasto
 }
 
 func (s *GormStore) TransactionForAccount(tType Type, address, txId string) (t Transaction, err error) {
-	q := &Transaction{ProposerAddress: address, TransactionType: tType, TransactionId: txId}
-	err = s.db.Where(q).First(&t).Error
+	q := &Transaction{ProposerAddress: address, TransactionType: tType}
+	err = s.db.
+		Where(q).
+		Where("(transaction_id = ? OR original_transaction_id = ?)", txId, txId).
+		First(&t).Error
 	return
 }
 
@@ -68,3 +77,108 @@ func (s *GormStore) InsertTransaction(t *Transaction) error {
 func (s *GormStore) UpdateTransaction(t *Transaction) error {
 	return s.db.Save(t).Error
 }
+
+func (s *GormStore) ReplaceTransaction(previousTxId string, t *Transaction) error {
+	t.UpdatedAt = time.Now()
+
+	res := s.db.Model(&Transaction{}).
+		Where(&Transaction{TransactionId: previousTxId}).
+		Updates(map[string]interface{}{
+			"transaction_id":          t.TransactionId,
+			"original_transaction_id": t.OriginalTransactionId,
+			"flow_transaction":        t.FlowTransaction,
+			"updated_at":              t.UpdatedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// -- Co-signing sessions
+
+func (s *GormStore) CoSigningSession(id uuid.UUID) (session CoSigningSession, err error) {
+	err = s.db.First(&session, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertCoSigningSession(session *CoSigningSession) error {
+	return s.db.Create(session).Error
+}
+
+func (s *GormStore) UpdateCoSigningSession(session *CoSigningSession) error {
+	now := time.Now()
+
+	// The version identifies the state the session was read in
+	res := s.db.Model(&CoSigningSession{}).
+		Where("id = ? AND version = ?", session.ID, session.Version).
+		UpdateColumns(map[string]interface{}{
+			"state":            session.State,
+			"pending_signers":  session.PendingSigners,
+			"flow_transaction": session.FlowTransaction,
+			"transaction_id":   session.TransactionId,
+			"job_id":           session.JobId,
+			"version":          session.Version + 1,
+			"updated_at":       now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrCoSigningSessionChanged
+	}
+
+	session.Version++
+	session.UpdatedAt = now
+
+	return nil
+}
+
+// -- Code allowlist
+
+func (s *GormStore) AllowedCode() (aa []AllowedCode, err error) {
+	err = s.db.Order("id asc").Find(&aa).Error
+	return
+}
+
+func (s *GormStore) AllowedCodeByHash(codeHash string) (aa []AllowedCode, err error) {
+	err = s.db.Where("code_hash = ?", codeHash).Find(&aa).Error
+	return
+}
+
+func (s *GormStore) InsertAllowedCode(a *AllowedCode) error {
+	return s.db.Create(a).Error
+}
+
+func (s *GormStore) DeleteAllowedCode(id uint64) error {
+	res := s.db.Delete(&AllowedCode{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (s *GormStore) CodeAuditEntries(o datastore.ListOptions) (ee []CodeAuditEntry, err error) {
+	err = s.db.
+		Order("created_at desc").
+		Order("id desc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) InsertCodeAuditEntry(e *CodeAuditEntry) error {
+	return s.db.Create(e).Error
+}
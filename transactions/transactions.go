@@ -1,15 +1,15 @@
 package transactions
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/onflow/flow-go-sdk"
 	"gorm.io/gorm"
 )
 
-const maxGasLimit = 9999
-
 type SignedTransaction struct {
 	flow.Transaction
 }
@@ -80,16 +80,89 @@ func (st *SignedTransaction) ToJSONResponse() (SignedTransactionJSONResponse, er
 	return res, nil
 }
 
+// ToFlowTransaction converts a signed transaction in the JSON format back to
+// a Flow transaction.
+func (st *SignedTransactionJSONResponse) ToFlowTransaction() (*flow.Transaction, error) {
+	flowTx := flow.NewTransaction().
+		SetScript([]byte(st.Code)).
+		SetReferenceBlockID(flow.HexToID(st.ReferenceBlockID)).
+		SetGasLimit(st.GasLimit).
+		SetProposalKey(flow.HexToAddress(st.ProposalKey.Address), st.ProposalKey.KeyIndex, st.ProposalKey.SequenceNumber).
+		SetPayer(flow.HexToAddress(st.Payer))
+
+	flowTx.Arguments = append(flowTx.Arguments, st.Arguments...)
+
+	for _, a := range st.Authorizers {
+		flowTx.AddAuthorizer(flow.HexToAddress(a))
+	}
+
+	for _, s := range st.PayloadSignatures {
+		sig, err := hex.DecodeString(strings.TrimPrefix(s.Signature, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload signature: %w", err)
+		}
+		flowTx.AddPayloadSignature(flow.HexToAddress(s.Address), s.KeyIndex, sig)
+	}
+
+	for _, s := range st.EnvelopeSignatures {
+		sig, err := hex.DecodeString(strings.TrimPrefix(s.Signature, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid envelope signature: %w", err)
+		}
+		flowTx.AddEnvelopeSignature(flow.HexToAddress(s.Address), s.KeyIndex, sig)
+	}
+
+	return flowTx, nil
+}
+
+// Submit transaction JSON HTTP request. The transaction is given either as a
+// signed transaction in the same format as returned when signing, or as a
+// hex encoded RLP-encoded Flow transaction in `rlp`.
+type SubmitJSONRequest struct {
+	SignedTransactionJSONResponse
+	RLP string `json:"rlp"`
+}
+
+// ToFlowTransaction decodes the submitted Flow transaction.
+func (r *SubmitJSONRequest) ToFlowTransaction() (*flow.Transaction, error) {
+	if r.RLP == "" {
+		return r.SignedTransactionJSONResponse.ToFlowTransaction()
+	}
+
+	if r.Code != "" {
+		return nil, fmt.Errorf("expected either a signed transaction or rlp, got both")
+	}
+
+	b, err := hex.DecodeString(strings.TrimPrefix(r.RLP, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rlp: %w", err)
+	}
+
+	flowTx, err := flow.DecodeTransaction(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rlp: %w", err)
+	}
+
+	return flowTx, nil
+}
+
 // Transaction is the database model for all transactions.
 type Transaction struct {
-	TransactionId   string         `gorm:"column:transaction_id;primaryKey"`
-	TransactionType Type           `gorm:"column:transaction_type;index"`
-	ProposerAddress string         `gorm:"column:proposer_address;index"`
-	FlowTransaction []byte         `gorm:"column:flow_transaction;type:bytes"`
-	CreatedAt       time.Time      `gorm:"column:created_at"`
-	UpdatedAt       time.Time      `gorm:"column:updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"`
-	Events          []flow.Event   `gorm:"-"`
+	TransactionId         string         `gorm:"column:transaction_id;primaryKey"`
+	OriginalTransactionId string         `gorm:"column:original_transaction_id;index"` // Set when the transaction has been rebuilt before sending
+	TransactionType       Type           `gorm:"column:transaction_type;index"`
+	ProposerAddress       string         `gorm:"column:proposer_address;index"`
+	FlowTransaction       []byte         `gorm:"column:flow_transaction;type:bytes"`
+	Status                Status         `gorm:"column:status;default:pending;index"`
+	Error                 string         `gorm:"column:error"`
+	BlockHeight           uint64         `gorm:"column:block_height"`
+	BlockId               string         `gorm:"column:block_id"`
+	Events                Events         `gorm:"column:events;type:bytes"` // Persisted once the transaction is final
+	Fees                  string         `gorm:"column:fees"`
+	GasLimit              uint64         `gorm:"column:gas_limit"`
+	CreatedAt             time.Time      `gorm:"column:created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"column:deleted_at;index"`
 }
 
 func (Transaction) TableName() string {
@@ -98,25 +171,41 @@ func (Transaction) TableName() string {
 
 // Transaction JSON HTTP request
 type JSONRequest struct {
-	Code      string     `json:"code"`
-	Arguments []Argument `json:"arguments"`
+	Code        string     `json:"code"`
+	Arguments   []Argument `json:"arguments"`
+	Authorizers []string   `json:"authorizers"` // Defaults to the proposer
+	GasLimit    uint64     `json:"gasLimit"`    // Defaults to the gas limit configured for raw transactions
 }
 
 // Transaction JSON HTTP response
 type JSONResponse struct {
-	TransactionId   string       `json:"transactionId"`
-	TransactionType Type         `json:"transactionType"`
-	Events          []flow.Event `json:"events,omitempty"`
-	CreatedAt       time.Time    `json:"createdAt"`
-	UpdatedAt       time.Time    `json:"updatedAt"`
+	TransactionId         string       `json:"transactionId"`
+	OriginalTransactionId string       `json:"originalTransactionId,omitempty"`
+	TransactionType       Type         `json:"transactionType"`
+	Status                Status       `json:"status"`
+	Error                 string       `json:"error,omitempty"`
+	BlockHeight           uint64       `json:"blockHeight,omitempty"`
+	BlockId               string       `json:"blockId,omitempty"`
+	Events                []flow.Event `json:"events,omitempty"`
+	Fees                  string       `json:"fees,omitempty"`
+	GasLimit              uint64       `json:"gasLimit,omitempty"`
+	CreatedAt             time.Time    `json:"createdAt"`
+	UpdatedAt             time.Time    `json:"updatedAt"`
 }
 
 func (t Transaction) ToJSONResponse() JSONResponse {
 	return JSONResponse{
-		TransactionId:   t.TransactionId,
-		TransactionType: t.TransactionType,
-		Events:          t.Events,
-		CreatedAt:       t.CreatedAt,
-		UpdatedAt:       t.UpdatedAt,
+		TransactionId:         t.TransactionId,
+		OriginalTransactionId: t.OriginalTransactionId,
+		TransactionType:       t.TransactionType,
+		Status:                t.Status,
+		Error:                 t.Error,
+		BlockHeight:           t.BlockHeight,
+		BlockId:               t.BlockId,
+		Events:                t.Events,
+		Fees:                  t.Fees,
+		GasLimit:              t.GasLimit,
+		CreatedAt:             t.CreatedAt,
+		UpdatedAt:             t.UpdatedAt,
 	}
 }
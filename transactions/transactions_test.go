@@ -0,0 +1,157 @@
+package transactions
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-wallet-api/configs"
+	"github.com/flow-hydraulics/flow-wallet-api/errors"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func testSignedFlowTransaction() *flow.Transaction {
+	flowTx := flow.NewTransaction().
+		SetScript([]byte("transaction(greeting: String) { prepare(signer: AuthAccount){} execute { log(greeting) }}")).
+		SetReferenceBlockID(flow.HexToID("ff25699272a9f42b5268e1b9c80b40275ef772528d4dfe8aadb8e5aebdea9bd9")).
+		SetGasLimit(9999).
+		SetProposalKey(flow.HexToAddress("01cf0e2f2f715450"), 1, 42).
+		SetPayer(flow.HexToAddress("f8d6e0586b0a20c7")).
+		AddAuthorizer(flow.HexToAddress("01cf0e2f2f715450"))
+
+	flowTx.Arguments = [][]byte{[]byte(`{"type":"String","value":"Hello"}`)}
+	flowTx.AddPayloadSignature(flow.HexToAddress("01cf0e2f2f715450"), 1, []byte{1, 2, 3})
+	flowTx.AddEnvelopeSignature(flow.HexToAddress("f8d6e0586b0a20c7"), 0, []byte{4, 5, 6})
+
+	return flowTx
+}
+
+func TestSubmitJSONRequest(t *testing.T) {
+	flowTx := testSignedFlowTransaction()
+
+	t.Run("signed transaction", func(t *testing.T) {
+		signed, err := (&SignedTransaction{*flowTx}).ToJSONResponse()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Round trip through JSON like an HTTP request
+		b, err := json.Marshal(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var req SubmitJSONRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := req.ToFlowTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.ID() != flowTx.ID() {
+			t.Fatalf("expected transaction %s, got %s", flowTx.ID(), decoded.ID())
+		}
+	})
+
+	t.Run("rlp", func(t *testing.T) {
+		req := SubmitJSONRequest{RLP: hex.EncodeToString(flowTx.Encode())}
+
+		decoded, err := req.ToFlowTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.ID() != flowTx.ID() {
+			t.Fatalf("expected transaction %s, got %s", flowTx.ID(), decoded.ID())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			req  SubmitJSONRequest
+		}{
+			{
+				name: "both signed transaction and rlp",
+				req: SubmitJSONRequest{
+					SignedTransactionJSONResponse: SignedTransactionJSONResponse{Code: "transaction() {}"},
+					RLP:                           hex.EncodeToString(flowTx.Encode()),
+				},
+			},
+			{
+				name: "invalid rlp",
+				req:  SubmitJSONRequest{RLP: "f8"},
+			},
+			{
+				name: "invalid signature",
+				req: SubmitJSONRequest{
+					SignedTransactionJSONResponse: SignedTransactionJSONResponse{
+						PayloadSignatures: []TransactionSignatureJSON{{Address: "01cf0e2f2f715450", Signature: "not-hex"}},
+					},
+				},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := tc.req.ToFlowTransaction(); err == nil {
+					t.Fatal("expected an error")
+				}
+			})
+		}
+	})
+}
+
+func TestSignSubmittedTransactionAdminRoles(t *testing.T) {
+	svc := &ServiceImpl{
+		cfg: &configs.Config{
+			AdminAddress: "0xf8d6e0586b0a20c7",
+			ChainID:      flow.Emulator,
+		},
+	}
+
+	admin := flow.HexToAddress("f8d6e0586b0a20c7")
+	user := flow.HexToAddress("01cf0e2f2f715450")
+
+	cases := []struct {
+		name   string
+		flowTx *flow.Transaction
+	}{
+		{
+			name: "admin as proposer",
+			flowTx: flow.NewTransaction().
+				SetProposalKey(admin, 0, 0).
+				AddAuthorizer(user),
+		},
+		{
+			name: "admin as authorizer",
+			flowTx: flow.NewTransaction().
+				SetProposalKey(user, 0, 0).
+				AddAuthorizer(user).
+				AddAuthorizer(admin),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.flowTx.
+				SetScript([]byte("transaction() { prepare(signer: AuthAccount){} execute {}}")).
+				SetReferenceBlockID(flow.HexToID("ff25699272a9f42b5268e1b9c80b40275ef772528d4dfe8aadb8e5aebdea9bd9")).
+				SetPayer(admin)
+
+			err := svc.signSubmittedTransaction(context.Background(), c.flowTx)
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error, got %#v", err)
+			}
+
+			if len(c.flowTx.EnvelopeSignatures) != 0 {
+				t.Fatal("did not expect the admin to have signed the envelope")
+			}
+		})
+	}
+}
@@ -39,3 +39,23 @@ func StatusFromText(text string) Type {
 		return NftTransfer
 	}
 }
+
+// Status is the status of a transaction on chain.
+type Status string
+
+const (
+	StatusPending Status = "pending" // Not yet final, or not yet sent
+	StatusSealed  Status = "sealed"
+	StatusExpired Status = "expired"
+	StatusFailed  Status = "failed"
+)
+
+// IsFinal returns true if the transaction status can no longer change.
+func (s Status) IsFinal() bool {
+	switch s {
+	case StatusSealed, StatusExpired, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}